@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Account is a NeoProtect account monitored next to others by the same process, e.g. one per customer
+type Account struct {
+	Name        string   `json:"name"`
+	APIKey      string   `json:"apiKey"`
+	APIEndpoint string   `json:"apiEndpoint"`
+	MonitorMode string   `json:"monitorMode"`
+	SpecificIPs []string `json:"specificIPs"`
+	// Integrations restricts the notifications about the account's attacks to these integrations, empty sends
+	// them to all enabled integrations
+	Integrations []string `json:"integrations"`
+}
+
+func validateAccounts(cfg *Config) error {
+	enabled := make(map[string]bool)
+	for _, name := range cfg.EnabledIntegrations {
+		enabled[name] = true
+	}
+
+	names := make(map[string]bool)
+	for i := range cfg.Accounts {
+		account := &cfg.Accounts[i]
+
+		if account.Name == "" {
+			return fmt.Errorf("accounts[%d] must have a name", i)
+		}
+		if names[account.Name] {
+			return fmt.Errorf("account name %q is used more than once", account.Name)
+		}
+		names[account.Name] = true
+
+		if account.APIKey == "" {
+			return fmt.Errorf("account %s must have an apiKey", account.Name)
+		}
+
+		if account.MonitorMode == "" {
+			account.MonitorMode = "all"
+		} else if account.MonitorMode != "all" && account.MonitorMode != "specific" {
+			return fmt.Errorf("monitorMode of account %s must be either 'all' or 'specific'", account.Name)
+		}
+		if account.MonitorMode == "specific" && len(account.SpecificIPs) == 0 {
+			return fmt.Errorf("account %s must list specificIPs when its monitorMode is 'specific'", account.Name)
+		}
+
+		for _, integration := range account.Integrations {
+			if !enabled[integration] {
+				return fmt.Errorf("account %s routes to integration %s, which is not in enabledIntegrations", account.Name, integration)
+			}
+		}
+	}
+
+	return nil
+}
+
+// AccountConfigs returns one configuration per monitored account. Without accounts that is the configuration
+// itself, otherwise a copy per account with its API key, endpoint and IPs. The peak records and delivery log of
+// each account are kept in their own files, named after the account.
+func (c *Config) AccountConfigs() []*Config {
+	if len(c.Accounts) == 0 {
+		return []*Config{c}
+	}
+
+	configs := make([]*Config, 0, len(c.Accounts))
+	for _, account := range c.Accounts {
+		accountCfg := *c
+		accountCfg.AccountName = account.Name
+		accountCfg.APIKey = account.APIKey
+		if account.APIEndpoint != "" {
+			accountCfg.APIEndpoint = account.APIEndpoint
+			accountCfg.APIEndpoints = []string{account.APIEndpoint}
+		}
+		accountCfg.MonitorMode = account.MonitorMode
+		accountCfg.SpecificIPs = account.SpecificIPs
+		accountCfg.PeakRecordsFile = accountFile(c.PeakRecordsFile, account.Name)
+		accountCfg.DeliveryLogFile = accountFile(c.DeliveryLogFile, account.Name)
+		if c.AttackStateFile != "" {
+			accountCfg.AttackStateFile = accountFile(c.AttackStateFile, account.Name)
+		}
+
+		configs = append(configs, &accountCfg)
+	}
+	return configs
+}
+
+// accountFile inserts the account name before the extension of a state file, e.g. peak_records.acme.json
+func accountFile(path, account string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + account + ext
+}
+
+// AccountRoutesTo reports whether notifications about attacks of the named account go to the integration.
+// Attacks without an account, and accounts without an integrations list, go to every integration.
+func (c *Config) AccountRoutesTo(account, integration string) bool {
+	if account == "" {
+		return true
+	}
+
+	for _, candidate := range c.Accounts {
+		if candidate.Name != account {
+			continue
+		}
+		if len(candidate.Integrations) == 0 {
+			return true
+		}
+		for _, name := range candidate.Integrations {
+			if name == integration {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
@@ -3,33 +3,362 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"log"
+	"net"
+	"net/url"
 	"os"
+	"sort"
+	"strings"
 	"time"
 )
 
 type Config struct {
-	APIKey      string `json:"apiKey"`
-	APIEndpoint string `json:"apiEndpoint"`
+	APIKey       string            `json:"apiKey"`
+	APIEndpoint  string            `json:"apiEndpoint"`
+	APIEndpoints []string          `json:"apiEndpoints"`
+	APIHeaders   map[string]string `json:"apiHeaders"`
 
-	PollInterval        time.Duration `json:"-"`
-	PollIntervalSeconds int           `json:"pollIntervalSeconds"`
+	Accounts []Account `json:"accounts"`
+	// AccountName is set on the copies returned by AccountConfigs to the account they monitor
+	AccountName string `json:"-"`
+
+	RequestTimeout        time.Duration `json:"-"`
+	RequestTimeoutSeconds int           `json:"requestTimeoutSeconds"`
+
+	PaginationTimeout        time.Duration `json:"-"`
+	PaginationTimeoutSeconds int           `json:"paginationTimeoutSeconds"`
+	PaginationMode           string        `json:"paginationMode"`
+
+	PollInterval           time.Duration `json:"-"`
+	PollIntervalSeconds    int           `json:"pollIntervalSeconds"`
+	MinPollIntervalSeconds int           `json:"minPollIntervalSeconds"`
+
+	APIRateLimitPerMinute int `json:"apiRateLimitPerMinute"`
+
+	MaxPollBackoff        time.Duration `json:"-"`
+	MaxPollBackoffSeconds int           `json:"maxPollBackoffSeconds"`
+
+	ShutdownTimeout        time.Duration `json:"-"`
+	ShutdownTimeoutSeconds int           `json:"shutdownTimeoutSeconds"`
+
+	UpdateInterval        time.Duration `json:"-"`
+	UpdateIntervalSeconds int           `json:"updateIntervalSeconds"`
+
+	ReminderInterval        time.Duration `json:"-"`
+	ReminderIntervalMinutes int           `json:"reminderIntervalMinutes"`
+
+	StaleAfterPolls int `json:"staleAfterPolls"`
+
+	SubsidingThresholdPercent int `json:"subsidingThresholdPercent"`
+
+	Escalations []EscalationRule `json:"escalations"`
+
+	QuietHours *QuietHours `json:"quietHours"`
+
+	MaintenanceMode    bool `json:"maintenanceMode"`
+	MaintenanceSummary bool `json:"maintenanceSummary"`
+
+	Retention      time.Duration `json:"-"`
+	RetentionHours int           `json:"retentionHours"`
 
 	MonitorMode    string   `json:"monitorMode"`
 	SpecificIPs    []string `json:"specificIPs"`
 	BlacklistedIPs []string `json:"blacklistedIPs"`
+	BlacklistFile  string   `json:"blacklistFile"`
+
+	WarnOnIPRemoval bool `json:"warnOnIpRemoval"`
+
+	IPGroups map[string][]string `json:"ipGroups"`
+	// ipGroupNets holds the parsed ipGroups entries
+	ipGroupNets map[string][]*net.IPNet
+
+	DesiredIPSettings      map[string]DesiredIPSettings `json:"desiredIpSettings"`
+	CorrectIPSettingsDrift bool                         `json:"correctIpSettingsDrift"`
 
 	EnabledIntegrations []string `json:"enabledIntegrations"`
 
+	FilterPlugins []string `json:"filterPlugins"`
+
+	PeakRecordsFile string `json:"peakRecordsFile"`
+
+	DeliveryLogFile string `json:"deliveryLogFile"`
+
+	// AttackStateFile persists the known attacks across restarts, empty keeps them in memory only
+	AttackStateFile string `json:"attackStateFile"`
+
+	DeliveryRetryAttempts     int           `json:"deliveryRetryAttempts"`
+	DeliveryRetryDelay        time.Duration `json:"-"`
+	DeliveryRetryDelaySeconds int           `json:"deliveryRetryDelaySeconds"`
+	DeadLetterFile            string        `json:"deadLetterFile"`
+
+	// DisableAfterFailures disables an integration after this many consecutive deliveries failed
+	// because its destination is gone, e.g. a deleted Discord channel. 0 never disables integrations.
+	DisableAfterFailures int  `json:"disableAfterFailures"`
+	NotifyOnDisabled     bool `json:"notifyOnDisabled"`
+
+	AuditLogFile      string `json:"auditLogFile"`
+	AuditLogMaxSizeMB int    `json:"auditLogMaxSizeMb"`
+	AuditLogBackups   int    `json:"auditLogBackups"`
+
+	ReconcileOnStartup bool `json:"reconcileOnStartup"`
+
+	BackfillHours int `json:"backfillHours"`
+
+	EndOnSignaturesEnded bool `json:"endOnSignaturesEnded"`
+
+	SignatureNames map[string]string `json:"signatureNames"`
+
+	RatePrecision int `json:"ratePrecision"`
+
+	CorrectSampleRate bool `json:"correctSampleRate"`
+
+	RetainPeaks bool `json:"retainPeaks"`
+
+	EmojiStyle string `json:"emojiStyle"`
+
+	NumberLocale string `json:"numberLocale"`
+
+	// AttackIDDisplayLength is a number of characters or "full", parsed into AttackIDLength with 0 meaning full
+	AttackIDDisplayLength json.RawMessage `json:"attackIdDisplayLength"`
+	AttackIDLength        int             `json:"-"`
+
+	TargetPortsLimit int `json:"targetPortsLimit"`
+
+	PanelBaseURL string `json:"panelBaseUrl"`
+
+	LinkCapacityBps     int64            `json:"linkCapacityBps"`
+	LinkCapacityBpsByIP map[string]int64 `json:"linkCapacityBpsByIp"`
+	SaturationPercent   int              `json:"saturationPercent"`
+
+	CorrelateBySourceASN bool `json:"correlateBySourceAsn"`
+
+	// MaxConcurrentIPs caps the per-IP and per-attack API requests of a poll cycle running at the same time
+	MaxConcurrentIPs int `json:"maxConcurrentIps"`
+
+	DebugLogging bool `json:"debugLogging"`
+
+	DebugListenAddr string `json:"debugListenAddr"`
+
+	HealthListenAddr     string        `json:"healthListenAddr"`
+	LivenessGrace        time.Duration `json:"-"`
+	LivenessGraceSeconds int           `json:"livenessGraceSeconds"`
+
+	InboundWebhookAddr   string `json:"inboundWebhookAddr"`
+	InboundWebhookSecret string `json:"inboundWebhookSecret"`
+
 	IntegrationConfigs map[string]json.RawMessage `json:"integrationConfigs"`
 }
 
+// EscalationRule re-notifies about an attack once it has been running for the given number of minutes.
+// Mention is the on-call ping added to the escalation, e.g. "<@&123>"; when empty the Discord integrations fall
+// back to the mention of their criticalAlert settings.
+type EscalationRule struct {
+	After        time.Duration `json:"-"`
+	AfterMinutes int           `json:"afterMinutes"`
+	Mention      string        `json:"mention"`
+}
+
+// QuietHours suppresses notifications about attacks below the critical thresholds during the given windows
+type QuietHours struct {
+	Timezone                  string         `json:"timezone"`
+	Location                  *time.Location `json:"-"`
+	Windows                   []QuietWindow  `json:"windows"`
+	CriticalMinBandwidthMbps  float64        `json:"criticalMinBandwidthMbps"`
+	CriticalMinPacketRateKpps float64        `json:"criticalMinPacketRateKpps"`
+}
+
+// QuietWindow is a daily window given as "HH:MM" local times; a window whose end is before its start spans midnight
+type QuietWindow struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+	// StartMinute and EndMinute are minutes since midnight
+	StartMinute int `json:"-"`
+	EndMinute   int `json:"-"`
+}
+
+// Active reports whether t falls into one of the quiet windows
+func (q *QuietHours) Active(t time.Time) bool {
+	if q == nil {
+		return false
+	}
+
+	t = t.In(q.Location)
+	minute := t.Hour()*60 + t.Minute()
+
+	for _, window := range q.Windows {
+		if window.StartMinute < window.EndMinute {
+			if minute >= window.StartMinute && minute < window.EndMinute {
+				return true
+			}
+		} else if minute >= window.StartMinute || minute < window.EndMinute {
+			return true
+		}
+	}
+	return false
+}
+
+func validateQuietHours(q *QuietHours) error {
+	location, err := time.LoadLocation(q.Timezone)
+	if err != nil {
+		return fmt.Errorf("invalid quietHours timezone: %w", err)
+	}
+	q.Location = location
+
+	if len(q.Windows) == 0 {
+		return fmt.Errorf("quietHours must contain at least one window")
+	}
+
+	for i := range q.Windows {
+		window := &q.Windows[i]
+		if window.StartMinute, err = parseClock(window.Start); err != nil {
+			return fmt.Errorf("invalid quietHours window start: %w", err)
+		}
+		if window.EndMinute, err = parseClock(window.End); err != nil {
+			return fmt.Errorf("invalid quietHours window end: %w", err)
+		}
+		if window.StartMinute == window.EndMinute {
+			return fmt.Errorf("quietHours window %s-%s must not start and end at the same time", window.Start, window.End)
+		}
+	}
+
+	if q.CriticalMinBandwidthMbps < 0 || q.CriticalMinPacketRateKpps < 0 {
+		return fmt.Errorf("quietHours critical thresholds must not be negative")
+	}
+
+	return nil
+}
+
+// parseClock parses "HH:MM" into minutes since midnight
+func parseClock(value string) (int, error) {
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a HH:MM time", value)
+	}
+	return clock.Hour()*60 + clock.Minute(), nil
+}
+
+// DesiredIPSettings is the protection posture an IP must keep; unset fields are not checked
+type DesiredIPSettings struct {
+	AutoMitigation *bool `json:"autoMitigation"`
+}
+
+// GroupsOf returns the sorted names of the ipGroups the IP belongs to
+func (c *Config) GroupsOf(ip string) []string {
+	var groups []string
+	for group := range c.ipGroupNets {
+		if c.InGroup(group, ip) {
+			groups = append(groups, group)
+		}
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+// InGroup reports whether the IP belongs to the named group
+func (c *Config) InGroup(group, ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+
+	for _, network := range c.ipGroupNets[group] {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasGroup reports whether the named group is configured in ipGroups
+func (c *Config) HasGroup(group string) bool {
+	_, exists := c.ipGroupNets[group]
+	return exists
+}
+
+// parseAttackIDDisplayLength parses attackIdDisplayLength, a missing value or "full" shows the full ID
+func parseAttackIDDisplayLength(raw json.RawMessage) (int, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return 0, nil
+	}
+
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil {
+		if name != "full" {
+			return 0, fmt.Errorf("attackIdDisplayLength must be a positive number of characters or 'full'")
+		}
+		return 0, nil
+	}
+
+	var length int
+	if err := json.Unmarshal(raw, &length); err != nil || length <= 0 {
+		return 0, fmt.Errorf("attackIdDisplayLength must be a positive number of characters or 'full'")
+	}
+	return length, nil
+}
+
+// parseIPGroups parses the IP and CIDR entries of every group, single IPs match only themselves
+func parseIPGroups(groups map[string][]string) (map[string][]*net.IPNet, error) {
+	parsed := make(map[string][]*net.IPNet, len(groups))
+
+	for group, entries := range groups {
+		if strings.TrimSpace(group) == "" {
+			return nil, fmt.Errorf("ipGroups names must not be empty")
+		}
+		if len(entries) == 0 {
+			return nil, fmt.Errorf("ipGroups group %s must contain at least one IP or CIDR", group)
+		}
+
+		networks := make([]*net.IPNet, 0, len(entries))
+		for _, entry := range entries {
+			if !strings.Contains(entry, "/") {
+				ip := net.ParseIP(entry)
+				if ip == nil {
+					return nil, fmt.Errorf("ipGroups group %s contains invalid IP %q", group, entry)
+				}
+				bits := 8 * net.IPv6len
+				if ip.To4() != nil {
+					ip, bits = ip.To4(), 8*net.IPv4len
+				}
+				networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+				continue
+			}
+
+			_, network, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, fmt.Errorf("ipGroups group %s contains invalid CIDR %q", group, entry)
+			}
+			networks = append(networks, network)
+		}
+		parsed[group] = networks
+	}
+
+	return parsed, nil
+}
+
 func LoadConfig(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	var cfg Config
+	cfg := Config{
+		ReconcileOnStartup: true,
+		WarnOnIPRemoval:    true,
+		MaintenanceSummary: true,
+		RetainPeaks:        true,
+		RatePrecision:      2,
+		TargetPortsLimit:   3,
+		AuditLogMaxSizeMB:  100,
+		AuditLogBackups:    10,
+
+		DisableAfterFailures: 3,
+		NotifyOnDisabled:     true,
+	}
+	data, err = toJSON(path, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
 	err = json.Unmarshal(data, &cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
@@ -40,23 +369,201 @@ func LoadConfig(path string) (*Config, error) {
 	}
 
 	cfg.PollInterval = time.Duration(cfg.PollIntervalSeconds) * time.Second
+	cfg.MaxPollBackoff = time.Duration(cfg.MaxPollBackoffSeconds) * time.Second
+	cfg.RequestTimeout = time.Duration(cfg.RequestTimeoutSeconds) * time.Second
+	cfg.PaginationTimeout = time.Duration(cfg.PaginationTimeoutSeconds) * time.Second
+	cfg.UpdateInterval = time.Duration(cfg.UpdateIntervalSeconds) * time.Second
+	cfg.ReminderInterval = time.Duration(cfg.ReminderIntervalMinutes) * time.Minute
+	cfg.ShutdownTimeout = time.Duration(cfg.ShutdownTimeoutSeconds) * time.Second
+	cfg.LivenessGrace = time.Duration(cfg.LivenessGraceSeconds) * time.Second
+	cfg.Retention = time.Duration(cfg.RetentionHours) * time.Hour
+	cfg.DeliveryRetryDelay = time.Duration(cfg.DeliveryRetryDelaySeconds) * time.Second
+
+	for i := range cfg.Escalations {
+		cfg.Escalations[i].After = time.Duration(cfg.Escalations[i].AfterMinutes) * time.Minute
+	}
 
 	return &cfg, nil
 }
 
+const (
+	// defaultMinPollIntervalSeconds is the shortest accepted poll interval unless minPollIntervalSeconds is set
+	defaultMinPollIntervalSeconds = 10
+	// lowestMinPollIntervalSeconds is how far minPollIntervalSeconds may lower the floor
+	lowestMinPollIntervalSeconds = 5
+)
+
 func validateConfig(cfg *Config) error {
-	if cfg.APIKey == "" {
+	if cfg.APIKey == "" && len(cfg.Accounts) == 0 {
 		return fmt.Errorf("apiKey must be provided")
 	}
+	if err := validateAccounts(cfg); err != nil {
+		return err
+	}
 
-	if cfg.APIEndpoint == "" {
-		cfg.APIEndpoint = "https://api.neoprotect.net/v2"
+	if len(cfg.APIEndpoints) > 0 {
+		cfg.APIEndpoint = cfg.APIEndpoints[0]
+	} else {
+		if cfg.APIEndpoint == "" {
+			cfg.APIEndpoint = "https://api.neoprotect.net/v2"
+		}
+		cfg.APIEndpoints = []string{cfg.APIEndpoint}
 	}
 
 	if cfg.PollIntervalSeconds <= 0 {
 		cfg.PollIntervalSeconds = 60
 	}
 
+	if cfg.MinPollIntervalSeconds == 0 {
+		cfg.MinPollIntervalSeconds = defaultMinPollIntervalSeconds
+	} else if cfg.MinPollIntervalSeconds < lowestMinPollIntervalSeconds {
+		return fmt.Errorf("minPollIntervalSeconds must be at least %d", lowestMinPollIntervalSeconds)
+	}
+	if cfg.PollIntervalSeconds < cfg.MinPollIntervalSeconds {
+		return fmt.Errorf("pollIntervalSeconds must be at least %d, lower values risk being rate limited by the API (see minPollIntervalSeconds)", cfg.MinPollIntervalSeconds)
+	}
+
+	if cfg.MaxPollBackoffSeconds <= 0 {
+		cfg.MaxPollBackoffSeconds = 600
+	}
+	if cfg.MaxPollBackoffSeconds < cfg.PollIntervalSeconds {
+		cfg.MaxPollBackoffSeconds = cfg.PollIntervalSeconds
+	}
+
+	if cfg.RequestTimeoutSeconds <= 0 {
+		cfg.RequestTimeoutSeconds = 30
+	}
+
+	if cfg.ShutdownTimeoutSeconds <= 0 {
+		cfg.ShutdownTimeoutSeconds = 10
+	}
+
+	if cfg.LivenessGraceSeconds <= 0 {
+		cfg.LivenessGraceSeconds = 300
+	}
+
+	if cfg.PaginationTimeoutSeconds < 0 {
+		return fmt.Errorf("paginationTimeoutSeconds must not be negative")
+	}
+
+	if cfg.PaginationMode == "" {
+		cfg.PaginationMode = "strict"
+	} else if cfg.PaginationMode != "strict" && cfg.PaginationMode != "lenient" {
+		return fmt.Errorf("paginationMode must be either 'strict' or 'lenient'")
+	}
+
+	if cfg.SubsidingThresholdPercent <= 0 {
+		cfg.SubsidingThresholdPercent = 25
+	}
+
+	for _, rule := range cfg.Escalations {
+		if rule.AfterMinutes <= 0 {
+			return fmt.Errorf("escalation afterMinutes must be positive")
+		}
+	}
+	sort.Slice(cfg.Escalations, func(i, j int) bool {
+		return cfg.Escalations[i].AfterMinutes < cfg.Escalations[j].AfterMinutes
+	})
+
+	if cfg.QuietHours != nil {
+		if err := validateQuietHours(cfg.QuietHours); err != nil {
+			return err
+		}
+	}
+
+	if cfg.RetentionHours <= 0 {
+		cfg.RetentionHours = 24
+	}
+
+	if cfg.BackfillHours < 0 {
+		return fmt.Errorf("backfillHours must not be negative")
+	}
+	if cfg.BackfillHours > cfg.RetentionHours {
+		log.Printf("Warning: backfillHours (%d) exceeds retentionHours (%d), older attacks are pruned right after the backfill", cfg.BackfillHours, cfg.RetentionHours)
+	}
+
+	if cfg.StaleAfterPolls <= 0 {
+		cfg.StaleAfterPolls = 3
+	}
+
+	if cfg.DisableAfterFailures < 0 {
+		return fmt.Errorf("disableAfterFailures must not be negative")
+	}
+
+	if cfg.DeliveryRetryAttempts < 0 {
+		return fmt.Errorf("deliveryRetryAttempts must not be negative")
+	}
+	if cfg.DeliveryRetryDelaySeconds <= 0 {
+		cfg.DeliveryRetryDelaySeconds = 5
+	}
+
+	if cfg.AuditLogMaxSizeMB < 0 || cfg.AuditLogBackups < 0 {
+		return fmt.Errorf("auditLogMaxSizeMb and auditLogBackups must not be negative")
+	}
+
+	if cfg.TargetPortsLimit < 0 {
+		return fmt.Errorf("targetPortsLimit must not be negative")
+	}
+
+	if cfg.MaxConcurrentIPs < 0 {
+		return fmt.Errorf("maxConcurrentIps must not be negative")
+	} else if cfg.MaxConcurrentIPs == 0 {
+		cfg.MaxConcurrentIPs = 4
+	}
+
+	if cfg.RatePrecision < 0 || cfg.RatePrecision > 6 {
+		return fmt.Errorf("ratePrecision must be between 0 and 6")
+	}
+
+	if cfg.EmojiStyle == "" {
+		cfg.EmojiStyle = "emoji"
+	} else if cfg.EmojiStyle != "emoji" && cfg.EmojiStyle != "ascii" && cfg.EmojiStyle != "none" {
+		return fmt.Errorf("emojiStyle must be one of 'emoji', 'ascii' or 'none'")
+	}
+
+	switch cfg.NumberLocale {
+	case "":
+		cfg.NumberLocale = "en"
+	case "en", "de", "fr", "ch", "none":
+	default:
+		return fmt.Errorf("numberLocale must be one of 'en', 'de', 'fr', 'ch' or 'none'")
+	}
+
+	attackIDLength, err := parseAttackIDDisplayLength(cfg.AttackIDDisplayLength)
+	if err != nil {
+		return err
+	}
+	cfg.AttackIDLength = attackIDLength
+
+	if cfg.LinkCapacityBps < 0 {
+		return fmt.Errorf("linkCapacityBps must not be negative")
+	}
+	for ip, capacity := range cfg.LinkCapacityBpsByIP {
+		if capacity < 0 {
+			return fmt.Errorf("linkCapacityBpsByIp for %s must not be negative", ip)
+		}
+	}
+	if cfg.SaturationPercent == 0 {
+		cfg.SaturationPercent = 80
+	} else if cfg.SaturationPercent < 0 {
+		return fmt.Errorf("saturationPercent must be positive")
+	}
+
+	if cfg.PanelBaseURL == "" {
+		cfg.PanelBaseURL = "https://panel.neoprotect.net"
+	} else if u, err := url.Parse(cfg.PanelBaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("panelBaseUrl must be an absolute http(s) URL")
+	}
+	cfg.PanelBaseURL = strings.TrimSuffix(cfg.PanelBaseURL, "/")
+
+	if cfg.UpdateIntervalSeconds < 0 {
+		return fmt.Errorf("updateIntervalSeconds must not be negative")
+	}
+
+	if cfg.ReminderIntervalMinutes < 0 {
+		return fmt.Errorf("reminderIntervalMinutes must not be negative")
+	}
+
 	if cfg.MonitorMode == "" {
 		cfg.MonitorMode = "all"
 	} else if cfg.MonitorMode != "all" && cfg.MonitorMode != "specific" {
@@ -67,6 +574,45 @@ func validateConfig(cfg *Config) error {
 		return fmt.Errorf("at least one IP address must be provided in specificIPs when monitorMode is 'specific'")
 	}
 
+	ipGroupNets, err := parseIPGroups(cfg.IPGroups)
+	if err != nil {
+		return err
+	}
+	cfg.ipGroupNets = ipGroupNets
+
+	if cfg.APIRateLimitPerMinute < 0 {
+		return fmt.Errorf("apiRateLimitPerMinute must not be negative")
+	}
+	if cfg.APIRateLimitPerMinute > 0 {
+		// Both modes walk the bulk attack listing once per poll and filter it client-side. During an attack that is
+		// a page of attacks plus the empty page ending the walk, and correlating by source ASN adds a stats request
+		// for each of at least two active attacks. Further pages and the final snapshot of an ended attack (a listing
+		// of its IP and a stats request) add more, so the estimate is a lower bound
+		requestsPerPoll := 2.0
+		if cfg.CorrelateBySourceASN {
+			requestsPerPoll += 2
+		}
+		if perMinute := requestsPerPoll * 60 / float64(cfg.PollIntervalSeconds); perMinute > float64(cfg.APIRateLimitPerMinute) {
+			log.Printf("Warning: polling every %d seconds makes at least %.1f API requests per minute during an attack, exceeding apiRateLimitPerMinute (%d)", cfg.PollIntervalSeconds, perMinute, cfg.APIRateLimitPerMinute)
+		}
+	}
+
+	if cfg.InboundWebhookAddr != "" && cfg.InboundWebhookSecret == "" {
+		return fmt.Errorf("inboundWebhookSecret must be provided when inboundWebhookAddr is set")
+	}
+
+	if cfg.BlacklistFile == "" {
+		cfg.BlacklistFile = "blacklist.json"
+	}
+
+	if cfg.PeakRecordsFile == "" {
+		cfg.PeakRecordsFile = "peak_records.json"
+	}
+
+	if cfg.DeliveryLogFile == "" {
+		cfg.DeliveryLogFile = "delivery_log.json"
+	}
+
 	if cfg.IntegrationConfigs == nil {
 		cfg.IntegrationConfigs = make(map[string]json.RawMessage)
 	}
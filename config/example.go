@@ -0,0 +1,85 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// exampleConfig documents the schema by example. Keys starting with "_comment" are ignored when loading.
+const exampleConfig = `{
+  "_comment": "Replace the placeholders below. Only apiKey is required, everything else shows its default or an example.",
+  "apiKey": "YOUR_NEOPROTECT_API_KEY",
+  "apiEndpoint": "https://api.neoprotect.net/v2",
+  "pollIntervalSeconds": 60,
+  "updateIntervalSeconds": 0,
+  "retentionHours": 24,
+  "reconcileOnStartup": true,
+
+  "_comment_monitorMode": "'all' monitors every IP of the account, 'specific' only the IPs in specificIPs",
+  "monitorMode": "all",
+  "specificIPs": [],
+  "blacklistedIPs": [],
+
+  "_comment_enabledIntegrations": "Integrations listed here must have an entry in integrationConfigs (console works without one)",
+  "enabledIntegrations": ["console"],
+
+  "integrationConfigs": {
+    "console": {
+      "logPrefix": "NEOPROTECT",
+      "format": "text",
+      "colorEnabled": true
+    },
+    "discord": {
+      "_comment": "Discord channel webhook, see Server Settings > Integrations > Webhooks",
+      "webhookUrl": "https://discord.com/api/webhooks/YOUR/DISCORD/WEBHOOK",
+      "username": "NeoProtect Monitor",
+      "editMessages": true
+    },
+    "discord_bot": {
+      "_comment": "clientId and guildId are needed for slash commands. allowedRoles restricts commands to these role IDs.",
+      "token": "YOUR_DISCORD_BOT_TOKEN",
+      "clientId": "YOUR_DISCORD_CLIENT_ID",
+      "guildId": "YOUR_DISCORD_GUILD_ID",
+      "channelId": "YOUR_DISCORD_CHANNEL_ID",
+      "commandsEnabled": true,
+      "allowedRoles": []
+    },
+    "webhook": {
+      "url": "https://your-webhook-endpoint.example.com/notify",
+      "headers": {
+        "Authorization": "Bearer YOUR_TOKEN"
+      },
+      "timeout": 10
+    },
+    "ntfy": {
+      "_comment": "Push notifications to phones, token is only needed for protected topics",
+      "serverUrl": "https://ntfy.sh",
+      "topic": "YOUR_NTFY_TOPIC",
+      "token": ""
+    }
+  }
+}
+`
+
+// WriteExample writes a commented example configuration to path. An existing file is only replaced with force.
+func WriteExample(path string, force bool) error {
+	if !json.Valid([]byte(exampleConfig)) {
+		return errors.New("example configuration is not valid JSON")
+	}
+
+	if !force {
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("%s already exists, use -force to overwrite it", path)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to check config file: %w", err)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte(exampleConfig), 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// toJSON converts YAML and TOML configuration files to JSON, picked by the file extension, so every format is
+// decoded and validated by the same code. Files with any other extension are expected to be JSON already.
+func toJSON(path string, data []byte) ([]byte, error) {
+	var document map[string]interface{}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+	case ".toml":
+		if err := toml.Unmarshal(data, &document); err != nil {
+			return nil, fmt.Errorf("failed to parse TOML: %w", err)
+		}
+	default:
+		return data, nil
+	}
+
+	converted, err := json.Marshal(normalizeKeys(document))
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert config to JSON: %w", err)
+	}
+	return converted, nil
+}
+
+// normalizeKeys turns the maps YAML decodes for non-string keys, e.g. numbers used as keys, into maps with
+// string keys that encoding/json can marshal
+func normalizeKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeKeys(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted[fmt.Sprint(key)] = normalizeKeys(item)
+		}
+		return converted
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeKeys(item)
+		}
+		return v
+	case []map[string]interface{}:
+		// TOML decodes arrays of tables this way
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = normalizeKeys(item)
+		}
+		return items
+	default:
+		return value
+	}
+}
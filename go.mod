@@ -2,7 +2,11 @@ module neoprotect-notifier
 
 go 1.23.0
 
-require github.com/bwmarrin/discordgo v0.28.1
+require (
+	github.com/BurntSushi/toml v1.6.0
+	github.com/bwmarrin/discordgo v0.28.1
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/gorilla/websocket v1.4.2 // indirect
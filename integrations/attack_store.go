@@ -0,0 +1,164 @@
+package integrations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"neoprotect-notifier/neoprotect"
+)
+
+// AttackStore holds the attacks known to the monitor
+type AttackStore struct {
+	mu        sync.RWMutex
+	attacks   map[string]*neoprotect.Attack
+	retention time.Duration
+	// retainPeaks carries the highest peaks of the stored snapshot over to the next snapshot of an attack
+	retainPeaks bool
+}
+
+func NewAttackStore(retention time.Duration, retainPeaks bool) *AttackStore {
+	return &AttackStore{
+		attacks:     make(map[string]*neoprotect.Attack),
+		retention:   retention,
+		retainPeaks: retainPeaks,
+	}
+}
+
+func (s *AttackStore) Get(attackID string) (*neoprotect.Attack, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	attack, exists := s.attacks[attackID]
+	return attack, exists
+}
+
+func (s *AttackStore) Set(attack *neoprotect.Attack) {
+	if attack == nil || attack.ID == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if previous, exists := s.attacks[attack.ID]; exists && s.retainPeaks && previous != attack {
+		attack.ObservedPeakBPS = max(attack.ObservedPeakBPS, previous.RecordedPeakBPS())
+		attack.ObservedPeakPPS = max(attack.ObservedPeakPPS, previous.RecordedPeakPPS())
+	}
+	s.attacks[attack.ID] = attack
+}
+
+func (s *AttackStore) Delete(attackID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.attacks, attackID)
+}
+
+// All returns a snapshot of all known attacks
+func (s *AttackStore) All() []*neoprotect.Attack {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	attacks := make([]*neoprotect.Attack, 0, len(s.attacks))
+	for _, attack := range s.attacks {
+		attacks = append(attacks, attack)
+	}
+	return attacks
+}
+
+func (s *AttackStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.attacks)
+}
+
+// Prune removes attacks that ended before the retention window and returns their IDs
+func (s *AttackStore) Prune() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pruned []string
+	for id, attack := range s.attacks {
+		if s.isExpired(attack) {
+			delete(s.attacks, id)
+			pruned = append(pruned, id)
+		}
+	}
+	return pruned
+}
+
+// storedAttack is an attack as persisted, with the fields the monitor sets that the API representation leaves out
+type storedAttack struct {
+	*neoprotect.Attack
+	Account         string     `json:"account,omitempty"`
+	FirstObservedAt *time.Time `json:"firstObservedAt,omitempty"`
+	ObservedPeakBPS int64      `json:"observedPeakBps,omitempty"`
+	ObservedPeakPPS int64      `json:"observedPeakPps,omitempty"`
+}
+
+// Load adds the attacks persisted to path by Save, e.g. before a restart, and prunes the ones that ended before
+// the retention window. It returns the number of attacks kept. A missing file is not an error.
+func (s *AttackStore) Load(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read attack state file: %w", err)
+	}
+
+	var stored []storedAttack
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return 0, fmt.Errorf("failed to parse attack state file: %w", err)
+	}
+
+	loaded := 0
+	for _, entry := range stored {
+		if entry.Attack == nil || entry.Attack.ID == "" {
+			continue
+		}
+		loaded++
+		entry.Attack.Account = entry.Account
+		entry.Attack.FirstObservedAt = entry.FirstObservedAt
+		entry.Attack.ObservedPeakBPS = entry.ObservedPeakBPS
+		entry.Attack.ObservedPeakPPS = entry.ObservedPeakPPS
+		s.Set(entry.Attack)
+	}
+
+	pruned := s.Prune()
+	return loaded - len(pruned), nil
+}
+
+// Save persists all known attacks to path
+func (s *AttackStore) Save(path string) error {
+	s.mu.RLock()
+	stored := make([]storedAttack, 0, len(s.attacks))
+	for _, attack := range s.attacks {
+		stored = append(stored, storedAttack{
+			Attack:          attack,
+			Account:         attack.Account,
+			FirstObservedAt: attack.FirstObservedAt,
+			ObservedPeakBPS: attack.ObservedPeakBPS,
+			ObservedPeakPPS: attack.ObservedPeakPPS,
+		})
+	}
+	data, err := json.MarshalIndent(stored, "", "  ")
+	s.mu.RUnlock()
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal known attacks: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write attack state file: %w", err)
+	}
+
+	return nil
+}
+
+func (s *AttackStore) isExpired(attack *neoprotect.Attack) bool {
+	return attack.EndedAt != nil && time.Since(*attack.EndedAt) > s.retention
+}
@@ -0,0 +1,56 @@
+package integrations
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"neoprotect-notifier/neoprotect"
+)
+
+func TestLoadPrunesStaleAttacks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "attacks.json")
+	retention := 24 * time.Hour
+
+	started := time.Now().Add(-72 * time.Hour)
+	staleEnd := time.Now().Add(-48 * time.Hour)
+	recentEnd := time.Now().Add(-time.Hour)
+
+	previousRun := NewAttackStore(retention, false)
+	previousRun.Set(&neoprotect.Attack{ID: "stale", StartedAt: &started, EndedAt: &staleEnd})
+	previousRun.Set(&neoprotect.Attack{ID: "recent", StartedAt: &started, EndedAt: &recentEnd})
+	previousRun.Set(&neoprotect.Attack{ID: "active", StartedAt: &started, ObservedPeakBPS: 5000, Account: "acme"})
+	if err := previousRun.Save(path); err != nil {
+		t.Fatalf("Save() = %v", err)
+	}
+
+	store := NewAttackStore(retention, false)
+	kept, err := store.Load(path)
+	if err != nil {
+		t.Fatalf("Load() = %v", err)
+	}
+
+	if kept != 2 || store.Len() != 2 {
+		t.Fatalf("Load() kept %d attacks and the store holds %d, want 2", kept, store.Len())
+	}
+	if _, exists := store.Get("stale"); exists {
+		t.Fatal("the stale attack was not pruned on load")
+	}
+
+	active, exists := store.Get("active")
+	if !exists {
+		t.Fatal("the active attack was not loaded")
+	}
+	if active.ObservedPeakBPS != 5000 || active.Account != "acme" {
+		t.Fatalf("monitor fields were not restored: peak %d, account %q", active.ObservedPeakBPS, active.Account)
+	}
+}
+
+func TestLoadWithoutStateFile(t *testing.T) {
+	store := NewAttackStore(time.Hour, false)
+
+	kept, err := store.Load(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil || kept != 0 {
+		t.Fatalf("Load() = %d, %v, want 0, nil", kept, err)
+	}
+}
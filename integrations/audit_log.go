@@ -0,0 +1,146 @@
+package integrations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"neoprotect-notifier/neoprotect"
+)
+
+// AuditLog is an append-only record of the attack events the monitor observed and the notifications it sent,
+// one JSON line per entry. Unlike the operational log it is meant to be kept. The file is rotated to .1, .2, ...
+// once it would grow past maxBytes, keeping the given number of rotated files.
+type AuditLog struct {
+	mu       sync.Mutex
+	path     string
+	maxBytes int64
+	backups  int
+}
+
+// auditEntry is a single line of the audit log. Integration and Success are only set for notifications.
+type auditEntry struct {
+	Time        time.Time `json:"time"`
+	Action      string    `json:"action"`
+	Event       string    `json:"event"`
+	AttackID    string    `json:"attack_id"`
+	TargetIP    string    `json:"target_ip"`
+	Account     string    `json:"account,omitempty"`
+	Integration string    `json:"integration,omitempty"`
+	Success     *bool     `json:"success,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// NewAuditLog returns an audit log writing to path, or nil when path is empty. A maxBytes of 0 disables rotation.
+func NewAuditLog(path string, maxBytes int64, backups int) *AuditLog {
+	if path == "" {
+		return nil
+	}
+	return &AuditLog{
+		path:     path,
+		maxBytes: maxBytes,
+		backups:  backups,
+	}
+}
+
+// Observed records an attack event detected by the monitor, whether or not it was sent anywhere
+func (l *AuditLog) Observed(eventType AttackEventType, attack *neoprotect.Attack) {
+	if l == nil {
+		return
+	}
+
+	l.append(auditEntry{
+		Time:     time.Now(),
+		Action:   "observed",
+		Event:    string(eventType),
+		AttackID: attack.ID,
+		TargetIP: attack.DstAddressString,
+		Account:  attack.Account,
+	})
+}
+
+// Notified records the outcome of delivering an event to an integration, err is nil for a successful delivery
+func (l *AuditLog) Notified(integrationName string, event *AttackEvent, err error) {
+	if l == nil {
+		return
+	}
+
+	success := err == nil
+	entry := auditEntry{
+		Time:        time.Now(),
+		Action:      "notified",
+		Event:       string(event.Type),
+		AttackID:    event.Attack.ID,
+		TargetIP:    event.Attack.DstAddressString,
+		Account:     event.Attack.Account,
+		Integration: integrationName,
+		Success:     &success,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	l.append(entry)
+}
+
+func (l *AuditLog) append(entry auditEntry) {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("Warning: failed to marshal audit log entry: %v", err)
+		return
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.rotate(int64(len(line))); err != nil {
+		log.Printf("Warning: failed to rotate audit log: %v", err)
+	}
+
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Printf("Warning: failed to open audit log file: %v", err)
+		return
+	}
+	defer file.Close()
+
+	if _, err := file.Write(line); err != nil {
+		log.Printf("Warning: failed to append to audit log file: %v", err)
+	}
+}
+
+// rotate shifts the rotated files up by one and moves the current file to .1 if writing size more bytes
+// would exceed maxBytes. The caller must hold l.mu.
+func (l *AuditLog) rotate(size int64) error {
+	if l.maxBytes <= 0 {
+		return nil
+	}
+
+	info, err := os.Stat(l.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if info.Size() == 0 || info.Size()+size <= l.maxBytes {
+		return nil
+	}
+
+	if l.backups <= 0 {
+		return os.Remove(l.path)
+	}
+
+	for i := l.backups - 1; i > 0; i-- {
+		err := os.Rename(fmt.Sprintf("%s.%d", l.path, i), fmt.Sprintf("%s.%d", l.path, i+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return os.Rename(l.path, l.path+".1")
+}
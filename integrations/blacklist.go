@@ -0,0 +1,139 @@
+package integrations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+)
+
+// ErrBlacklistedInConfig is returned when removing an IP that is blacklisted in the config file
+var ErrBlacklistedInConfig = errors.New("IP is blacklisted in the config file")
+
+// ErrBlacklistFileUnloaded is returned when changing the runtime blacklist after its file failed to load, saving it
+// would overwrite the IPs in the file
+var ErrBlacklistFileUnloaded = errors.New("blacklist file could not be loaded")
+
+// Blacklist combines the IPs blacklisted in the config file with those added at runtime, which are persisted to disk
+type Blacklist struct {
+	mu      sync.RWMutex
+	path    string
+	static  map[string]bool
+	runtime map[string]bool
+	// loadErr is set when the file could not be loaded, the runtime blacklist is then not saved
+	loadErr error
+}
+
+func NewBlacklist(configured []string, path string) (*Blacklist, error) {
+	blacklist := &Blacklist{
+		path:    path,
+		static:  make(map[string]bool),
+		runtime: make(map[string]bool),
+	}
+
+	for _, ip := range configured {
+		blacklist.static[ip] = true
+	}
+
+	if path == "" {
+		return blacklist, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return blacklist, nil
+		}
+		blacklist.loadErr = fmt.Errorf("failed to read blacklist file: %w", err)
+		return blacklist, blacklist.loadErr
+	}
+
+	var ips []string
+	if err := json.Unmarshal(data, &ips); err != nil {
+		blacklist.loadErr = fmt.Errorf("failed to parse blacklist file: %w", err)
+		return blacklist, blacklist.loadErr
+	}
+
+	for _, ip := range ips {
+		blacklist.runtime[ip] = true
+	}
+
+	return blacklist, nil
+}
+
+func (b *Blacklist) Contains(ip string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.static[ip] || b.runtime[ip]
+}
+
+// Add blacklists the IP and returns false if it already was
+func (b *Blacklist) Add(ip string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.static[ip] || b.runtime[ip] {
+		return false, nil
+	}
+	if b.loadErr != nil {
+		return false, fmt.Errorf("%w, not overwriting it: %v", ErrBlacklistFileUnloaded, b.loadErr)
+	}
+
+	b.runtime[ip] = true
+	return true, b.save()
+}
+
+// Remove lifts a runtime blacklisting and returns false if the IP was not blacklisted
+func (b *Blacklist) Remove(ip string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.static[ip] {
+		return false, ErrBlacklistedInConfig
+	}
+
+	if !b.runtime[ip] {
+		return false, nil
+	}
+	if b.loadErr != nil {
+		return false, fmt.Errorf("%w, not overwriting it: %v", ErrBlacklistFileUnloaded, b.loadErr)
+	}
+
+	delete(b.runtime, ip)
+	return true, b.save()
+}
+
+// List returns the sorted IPs blacklisted in the config file and at runtime
+func (b *Blacklist) List() (configured []string, runtime []string) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return sortedKeys(b.static), sortedKeys(b.runtime)
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (b *Blacklist) save() error {
+	if b.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(sortedKeys(b.runtime), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal blacklist: %w", err)
+	}
+
+	if err := os.WriteFile(b.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write blacklist file: %w", err)
+	}
+
+	return nil
+}
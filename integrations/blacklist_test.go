@@ -0,0 +1,39 @@
+package integrations
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBlacklistRefusesToOverwriteUnparsableFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blacklist.json")
+	corrupt := []byte(`["192.0.2.1",`)
+	if err := os.WriteFile(path, corrupt, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	blacklist, err := NewBlacklist([]string{"192.0.2.9"}, path)
+	if err == nil {
+		t.Fatal("NewBlacklist() loaded an unparsable file without an error")
+	}
+	if !blacklist.Contains("192.0.2.9") {
+		t.Fatal("the configured blacklist is not used when the file fails to load")
+	}
+
+	if added, err := blacklist.Add("192.0.2.2"); added || !errors.Is(err, ErrBlacklistFileUnloaded) {
+		t.Fatalf("Add() = %v, %v, want ErrBlacklistFileUnloaded", added, err)
+	}
+	if blacklist.Contains("192.0.2.2") {
+		t.Fatal("Add() blacklisted the IP although it refused to save it")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(corrupt) {
+		t.Fatalf("blacklist file was overwritten with %q", data)
+	}
+}
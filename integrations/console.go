@@ -5,21 +5,36 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"neoprotect-notifier/neoprotect"
 )
 
+func init() {
+	RegisterIntegration("console", func() Integration { return &ConsoleIntegration{} })
+}
+
 type ConsoleIntegration struct {
-	logPrefix    string
-	formatJSON   bool
-	colorEnabled bool
+	logPrefix       string
+	format          string
+	colorEnabled    bool
+	suppressRepeats bool
+
+	// repeatMu guards the last logged message and how often it was repeated since
+	repeatMu    sync.Mutex
+	lastMessage string
+	repeats     int
 }
 
 type ConsoleConfig struct {
-	LogPrefix    string `json:"logPrefix"`
-	FormatJSON   bool   `json:"formatJson"`
-	ColorEnabled bool   `json:"colorEnabled"`
+	LogPrefix       string `json:"logPrefix"`
+	Format          string `json:"format"`
+	FormatJSON      bool   `json:"formatJson"`
+	ColorEnabled    bool   `json:"colorEnabled"`
+	SuppressRepeats bool   `json:"suppressRepeats"`
 }
 
 func (c *ConsoleIntegration) Name() string {
@@ -41,36 +56,144 @@ func (c *ConsoleIntegration) Initialize(rawConfig map[string]interface{}) error
 		config.LogPrefix = "NEOPROTECT"
 	}
 
+	if config.Format == "" {
+		config.Format = "text"
+		if config.FormatJSON {
+			config.Format = "json"
+		}
+	} else if config.Format != "text" && config.Format != "json" && config.Format != "logfmt" {
+		return fmt.Errorf("console format must be one of 'text', 'json' or 'logfmt'")
+	}
+
 	c.logPrefix = config.LogPrefix
-	c.formatJSON = config.FormatJSON
+	c.format = config.Format
 	c.colorEnabled = config.ColorEnabled
+	c.suppressRepeats = config.SuppressRepeats
 
 	return nil
 }
 
 func (c *ConsoleIntegration) NotifyNewAttack(ctx context.Context, attack *neoprotect.Attack) (string, error) {
-	message := c.formatAttack("NEW ATTACK", attack, nil, c.colorRed())
-	log.Println(message)
-	return "", nil
+	return c.NotifyEvent(ctx, NewAttackEvent(EventNewAttack, attack, nil), "")
 }
 
 func (c *ConsoleIntegration) NotifyAttackUpdate(ctx context.Context, attack *neoprotect.Attack, previous *neoprotect.Attack, messageID string) error {
-	message := c.formatAttack("ATTACK UPDATE", attack, previous, c.colorYellow())
-	log.Println(message)
-	return nil
+	_, err := c.NotifyEvent(ctx, NewAttackEvent(EventAttackUpdate, attack, previous), messageID)
+	return err
 }
 
 func (c *ConsoleIntegration) NotifyAttackEnded(ctx context.Context, attack *neoprotect.Attack, messageID string) error {
-	message := c.formatAttack("ATTACK ENDED", attack, nil, c.colorGreen())
-	log.Println(message)
+	_, err := c.NotifyEvent(ctx, NewAttackEvent(EventAttackEnded, attack, nil), messageID)
+	return err
+}
+
+func (c *ConsoleIntegration) NotifyEvent(ctx context.Context, event *AttackEvent, messageID string) (string, error) {
+	switch event.Type {
+	case EventNewAttack:
+		c.logAttack("NEW ATTACK", event, c.colorRed())
+	case EventAttackUpdate:
+		eventType, color := "ATTACK UPDATE", c.colorYellow()
+		if event.Attack.Subsiding {
+			eventType, color = "ATTACK SUBSIDING", c.colorBlue()
+		}
+		c.logAttack(eventType, event, color)
+	default:
+		c.logAttack("ATTACK ENDED", event, c.colorGreen())
+	}
+	return "", nil
+}
+
+func (c *ConsoleIntegration) NotifyWarning(ctx context.Context, title string, message string) error {
+	if c.repeated("WARNING: " + title + ": " + message) {
+		return nil
+	}
+
+	if c.format == "logfmt" {
+		log.Println(formatLogfmt([][2]string{
+			{"prefix", c.logPrefix},
+			{"event", "warning"},
+			{"title", title},
+			{"message", message},
+			{"timestamp", time.Now().Format(time.RFC3339)},
+		}))
+		return nil
+	}
+
+	if c.format == "json" {
+		output := map[string]interface{}{
+			"prefix":    c.logPrefix,
+			"event":     "WARNING",
+			"title":     title,
+			"message":   message,
+			"timestamp": time.Now().Format(time.RFC3339),
+		}
+
+		jsonBytes, err := json.MarshalIndent(output, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format warning: %w", err)
+		}
+
+		log.Printf("%s%s%s", c.colorYellow(), string(jsonBytes), c.colorReset())
+		return nil
+	}
+
+	log.Printf("%s[%s] WARNING: %s: %s%s", c.colorYellow(), c.logPrefix, title, message, c.colorReset())
+	return nil
+}
+
+// NotifyReminder logs that the attack is still ongoing
+func (c *ConsoleIntegration) NotifyReminder(ctx context.Context, attack *neoprotect.Attack, messageID string) error {
+	c.logAttack("STILL ONGOING", &AttackEvent{Attack: attack, Timestamp: time.Now()}, c.colorYellow())
 	return nil
 }
 
-func (c *ConsoleIntegration) formatAttack(eventType string, attack *neoprotect.Attack, previous *neoprotect.Attack, colorCode string) string {
-	if c.formatJSON {
-		return c.formatJSONOutput(eventType, attack, previous)
+// logAttack logs the attack notification unless it repeats the previous message
+func (c *ConsoleIntegration) logAttack(eventType string, event *AttackEvent, colorCode string) {
+	// The text rendering has no timestamp and serves as the key in every format
+	if c.repeated(c.formatTextOutput(eventType, event, "")) {
+		return
+	}
+
+	log.Println(c.formatAttack(eventType, event, colorCode))
+}
+
+// repeated reports whether suppressRepeats is enabled and the message equals the previous one, counting the
+// repeat. Once a different message arrives, the count of suppressed repeats is logged before it.
+func (c *ConsoleIntegration) repeated(message string) bool {
+	if !c.suppressRepeats {
+		return false
+	}
+
+	c.repeatMu.Lock()
+	defer c.repeatMu.Unlock()
+
+	if message == c.lastMessage {
+		c.repeats++
+		return true
+	}
+
+	if c.repeats > 0 {
+		log.Printf("[%s] Previous message repeated %dx", c.logPrefix, c.repeats)
+	}
+	c.lastMessage = message
+	c.repeats = 0
+	return false
+}
+
+func (c *ConsoleIntegration) formatAttack(eventType string, event *AttackEvent, colorCode string) string {
+	switch c.format {
+	case "json":
+		return c.formatJSONOutput(eventType, event)
+	case "logfmt":
+		return c.formatLogfmtOutput(eventType, event)
 	}
 
+	return c.formatTextOutput(eventType, event, colorCode)
+}
+
+func (c *ConsoleIntegration) formatTextOutput(eventType string, event *AttackEvent, colorCode string) string {
+	attack := event.Attack
+
 	var timeInfo string
 	if attack.StartedAt != nil {
 		timeInfo = fmt.Sprintf("started at %s", formatTimeToLocal(attack.StartedAt))
@@ -81,19 +204,23 @@ func (c *ConsoleIntegration) formatAttack(eventType string, attack *neoprotect.A
 		}
 	}
 
+	if observedLate(attack) {
+		timeInfo += fmt.Sprintf(", first observed at %s", formatTimeToLocal(attack.FirstObservedAt))
+	}
+
 	var diffInfo string
-	if previous != nil {
-		diff := attack.CalculateDiff(previous)
-		if diffBytes, err := json.Marshal(diff); err == nil {
+	if event.Diff.HasChanges() {
+		if diffBytes, err := json.Marshal(event.Diff); err == nil {
 			diffInfo = fmt.Sprintf(" Changes: %s", string(diffBytes))
 		}
 	}
 
-	attackIDShort := "unknown"
-	if len(attack.ID) >= 8 {
-		attackIDShort = attack.ID[:8]
-	} else if attack.ID != "" {
-		attackIDShort = attack.ID
+	if event.Severity == SeverityCritical {
+		diffInfo += " Severity: critical"
+	}
+
+	if len(event.Labels) > 0 {
+		diffInfo += fmt.Sprintf(" Labels: %s", strings.Join(event.Labels, ", "))
 	}
 
 	targetIP := attack.DstAddressString
@@ -101,23 +228,45 @@ func (c *ConsoleIntegration) formatAttack(eventType string, attack *neoprotect.A
 		targetIP = "unknown"
 	}
 
-	return fmt.Sprintf("%s[%s] %s: Attack %s on %s, %s, %d signatures (%s), peak: %s, %s%s%s",
+	if attack.NewRecordPeak {
+		diffInfo += " " + prefix("🏆") + "New record peak"
+	}
+
+	if note := saturationNote(attack); note != "" {
+		diffInfo += " " + prefix("⚠️") + note
+	}
+
+	if len(attack.ProtocolMix) > 0 {
+		diffInfo += fmt.Sprintf(" Protocols: %s", formatProtocolMix(attack.ProtocolMix))
+	}
+
+	if len(attack.TargetPorts) > 0 {
+		diffInfo += fmt.Sprintf(" Ports: %s", formatTargetPorts(attack.TargetPorts, attack.ProtocolMix))
+	}
+
+	if len(attack.CorrelatedIPs) > 0 {
+		diffInfo += fmt.Sprintf(" (likely same source as attack on %s)", strings.Join(attack.CorrelatedIPs, ", "))
+	}
+
+	return fmt.Sprintf("%s[%s] %s: Attack %s on %s, %s, %d signatures (%s), peak: %s, %s%s%s%s",
 		colorCode,
 		c.logPrefix,
 		eventType,
-		attackIDShort,
-		targetIP,
+		displayAttackID(attack.ID),
+		targetLabel(attack),
 		timeInfo,
 		len(attack.Signatures),
 		c.joinSignatureNames(attack),
-		formatBPS(attack.GetPeakBPS()),
-		formatPPS(attack.GetPeakPPS()),
+		formatBPS(displayedPeakBPS(attack)),
+		formatPPS(displayedPeakPPS(attack)),
+		samplingNote(attack.SampleRate),
 		diffInfo,
 		c.colorReset(),
 	)
 }
 
-func (c *ConsoleIntegration) formatJSONOutput(eventType string, attack *neoprotect.Attack, previous *neoprotect.Attack) string {
+func (c *ConsoleIntegration) formatJSONOutput(eventType string, event *AttackEvent) string {
+	attack := event.Attack
 	output := map[string]interface{}{
 		"prefix":     c.logPrefix,
 		"event":      eventType,
@@ -125,17 +274,46 @@ func (c *ConsoleIntegration) formatJSONOutput(eventType string, attack *neoprote
 		"target_ip":  attack.DstAddressString,
 		"started_at": formatTimeToLocal(attack.StartedAt),
 		"signatures": attack.GetSignatureNames(),
-		"peak_bps":   attack.GetPeakBPS(),
-		"peak_pps":   attack.GetPeakPPS(),
+		"peak_bps":   attack.RecordedPeakBPS(),
+		"peak_pps":   attack.RecordedPeakPPS(),
 		"timestamp":  time.Now().Format(time.RFC3339),
 	}
 
+	if attack.Account != "" {
+		output["account"] = attack.Account
+	}
+
+	if event.Severity != "" {
+		output["severity"] = string(event.Severity)
+	}
+
+	if len(event.Labels) > 0 {
+		output["labels"] = event.Labels
+	}
+
+	if attack.FirstObservedAt != nil {
+		output["first_observed_at"] = formatTimeToLocal(attack.FirstObservedAt)
+	}
+
+	if attack.NewRecordPeak {
+		output["new_record_peak"] = true
+	}
+
+	if percent, atRisk := saturationRisk(attack); atRisk {
+		output["saturation_risk"] = true
+		output["link_utilization_percent"] = percent
+	}
+
+	if len(attack.CorrelatedIPs) > 0 {
+		output["correlated_ips"] = attack.CorrelatedIPs
+	}
+
 	if attack.EndedAt != nil {
 		output["ended_at"] = formatTimeToLocal(attack.EndedAt)
 	}
 
-	if previous != nil {
-		output["changes"] = attack.CalculateDiff(previous)
+	if event.Diff != nil {
+		output["changes"] = event.Diff
 	}
 
 	if attack.EndedAt != nil {
@@ -150,6 +328,89 @@ func (c *ConsoleIntegration) formatJSONOutput(eventType string, attack *neoprote
 	return fmt.Sprintf("%s%s%s", c.colorCode(eventType), string(jsonBytes), c.colorReset())
 }
 
+// logfmtEvents maps the console event types to the short names used in logfmt output
+var logfmtEvents = map[string]string{
+	"NEW ATTACK":       "new",
+	"ATTACK UPDATE":    "update",
+	"ATTACK SUBSIDING": "subsiding",
+	"ATTACK ENDED":     "ended",
+	"STILL ONGOING":    "reminder",
+}
+
+func (c *ConsoleIntegration) formatLogfmtOutput(eventType string, event *AttackEvent) string {
+	attack := event.Attack
+	fields := [][2]string{
+		{"prefix", c.logPrefix},
+		{"event", logfmtEvents[eventType]},
+		{"attack_id", attack.ID},
+		{"ip", attack.DstAddressString},
+		{"account", attack.Account},
+		{"peak_bps", strconv.FormatInt(attack.RecordedPeakBPS(), 10)},
+		{"peak_pps", strconv.FormatInt(attack.RecordedPeakPPS(), 10)},
+		{"signatures", strings.Join(attack.GetSignatureNames(), ",")},
+	}
+
+	if event.Severity != "" {
+		fields = append(fields, [2]string{"severity", string(event.Severity)})
+	}
+
+	if len(event.Labels) > 0 {
+		fields = append(fields, [2]string{"labels", strings.Join(event.Labels, ",")})
+	}
+
+	if attack.StartedAt != nil {
+		fields = append(fields, [2]string{"started_at", attack.StartedAt.Format(time.RFC3339)})
+	}
+
+	if attack.EndedAt != nil {
+		fields = append(fields,
+			[2]string{"ended_at", attack.EndedAt.Format(time.RFC3339)},
+			[2]string{"duration_seconds", strconv.FormatInt(int64(attack.Duration().Seconds()), 10)})
+	}
+
+	if diff := event.Diff; diff != nil {
+		fields = append(fields,
+			[2]string{"bps_change", strconv.FormatInt(diff.BPSChange, 10)},
+			[2]string{"pps_change", strconv.FormatInt(diff.PPSChange, 10)})
+		if diff.AutoMitigation != nil {
+			fields = append(fields, [2]string{"auto_mitigation", strconv.FormatBool(*diff.AutoMitigation)})
+		}
+		if diff.VectorShift != nil {
+			fields = append(fields,
+				[2]string{"vector_shift_from", diff.VectorShift.From},
+				[2]string{"vector_shift_to", diff.VectorShift.To})
+		}
+	}
+
+	if attack.NewRecordPeak {
+		fields = append(fields, [2]string{"new_record_peak", "true"})
+	}
+
+	if percent, atRisk := saturationRisk(attack); atRisk {
+		fields = append(fields, [2]string{"saturation_risk", "true"},
+			[2]string{"link_utilization_percent", strconv.FormatFloat(percent, 'f', 0, 64)})
+	}
+
+	if len(attack.CorrelatedIPs) > 0 {
+		fields = append(fields, [2]string{"correlated_ips", strings.Join(attack.CorrelatedIPs, ",")})
+	}
+
+	return formatLogfmt(fields)
+}
+
+// formatLogfmt renders key=value pairs on one line, quoting values that contain spaces, quotes or equals signs
+func formatLogfmt(fields [][2]string) string {
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		value := field[1]
+		if value == "" || strings.ContainsAny(value, " =\"\t\n") {
+			value = strconv.Quote(value)
+		}
+		parts = append(parts, field[0]+"="+value)
+	}
+	return strings.Join(parts, " ")
+}
+
 func (c *ConsoleIntegration) joinSignatureNames(attack *neoprotect.Attack) string {
 	names := attack.GetSignatureNames()
 	if len(names) == 0 {
@@ -174,6 +435,8 @@ func (c *ConsoleIntegration) colorCode(eventType string) string {
 		return ColorRed
 	case "ATTACK UPDATE":
 		return ColorYellow
+	case "ATTACK SUBSIDING":
+		return ColorBlue
 	case "ATTACK ENDED":
 		return ColorGreen
 	default:
@@ -195,6 +458,13 @@ func (c *ConsoleIntegration) colorYellow() string {
 	return ""
 }
 
+func (c *ConsoleIntegration) colorBlue() string {
+	if c.colorEnabled {
+		return ColorBlue
+	}
+	return ""
+}
+
 func (c *ConsoleIntegration) colorGreen() string {
 	if c.colorEnabled {
 		return ColorGreen
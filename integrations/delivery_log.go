@@ -0,0 +1,130 @@
+package integrations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// DeliveryLog persists which one-shot notifications (new attack, attack ended) each integration
+// has delivered, so a restart never sends them again
+type DeliveryLog struct {
+	mu        sync.Mutex
+	path      string
+	retention time.Duration
+	// deliveries maps attack ID to "integration/event" to the time it was delivered
+	deliveries map[string]map[string]time.Time
+}
+
+func NewDeliveryLog(path string, retention time.Duration) (*DeliveryLog, error) {
+	deliveryLog := &DeliveryLog{
+		path:       path,
+		retention:  retention,
+		deliveries: make(map[string]map[string]time.Time),
+	}
+
+	if path == "" {
+		return deliveryLog, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return deliveryLog, nil
+		}
+		return deliveryLog, fmt.Errorf("failed to read delivery log file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &deliveryLog.deliveries); err != nil {
+		return deliveryLog, fmt.Errorf("failed to parse delivery log file: %w", err)
+	}
+
+	return deliveryLog, nil
+}
+
+func deliveryKey(integrationName string, eventType AttackEventType) string {
+	return integrationName + "/" + string(eventType)
+}
+
+// Delivered reports whether the integration already delivered the event for the attack
+func (l *DeliveryLog) Delivered(attackID, integrationName string, eventType AttackEventType) bool {
+	if l == nil {
+		return false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, delivered := l.deliveries[attackID][deliveryKey(integrationName, eventType)]
+	return delivered
+}
+
+func (l *DeliveryLog) Record(attackID, integrationName string, eventType AttackEventType) error {
+	if l == nil || attackID == "" {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.deliveries[attackID] == nil {
+		l.deliveries[attackID] = make(map[string]time.Time)
+	}
+	l.deliveries[attackID][deliveryKey(integrationName, eventType)] = time.Now()
+
+	return l.save()
+}
+
+// Forget drops the deliveries of an attack after it was cleaned up from the monitor.
+// Attacks whose last delivery is older than the retention are dropped as well, which covers
+// attacks that ended while the notifier was not running.
+func (l *DeliveryLog) Forget(attackID string) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, changed := l.deliveries[attackID]
+	delete(l.deliveries, attackID)
+
+	for attackID, events := range l.deliveries {
+		expired := true
+		for _, deliveredAt := range events {
+			if time.Since(deliveredAt) <= l.retention {
+				expired = false
+				break
+			}
+		}
+		if expired {
+			delete(l.deliveries, attackID)
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+	return l.save()
+}
+
+func (l *DeliveryLog) save() error {
+	if l.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(l.deliveries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal delivery log: %w", err)
+	}
+
+	if err := os.WriteFile(l.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write delivery log file: %w", err)
+	}
+
+	return nil
+}
@@ -4,34 +4,55 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"neoprotect-notifier/neoprotect"
 )
 
+func init() {
+	RegisterIntegration("discord", func() Integration { return &DiscordIntegration{} })
+}
+
 type DiscordIntegration struct {
-	webhookURL string
-	username   string
-	avatarURL  string
-	client     *http.Client
+	webhookURL              string
+	username                string
+	avatarURL               string
+	thumbnails              map[string]string
+	editMessages            bool
+	editInPlace             map[string]bool
+	sendEndedWithoutMessage bool
+	criticalAlert           *CriticalAlertConfig
+	compact                 bool
+	maxSignatures           int
+	client                  *http.Client
 }
 
 type DiscordConfig struct {
-	WebhookURL string `json:"webhookUrl"`
-	Username   string `json:"username"`
-	AvatarURL  string `json:"avatarUrl"`
-	Timeout    int    `json:"timeout"`
+	WebhookURL                string               `json:"webhookUrl"`
+	Username                  string               `json:"username"`
+	AvatarURL                 string               `json:"avatarUrl"`
+	Timeout                   int                  `json:"timeout"`
+	Thumbnails                map[string]string    `json:"thumbnails"`
+	EditMessages              bool                 `json:"editMessages"`
+	EditInPlace               map[string]bool      `json:"editInPlace"`
+	SendEndedWithoutMessageID bool                 `json:"sendEndedWithoutMessageId"`
+	CriticalAlert             *CriticalAlertConfig `json:"criticalAlert"`
+	Compact                   bool                 `json:"compact"`
+	MaxSignatures             int                  `json:"maxSignatures"`
 }
 
 type DiscordMessage struct {
 	Username  string         `json:"username,omitempty"`
 	AvatarURL string         `json:"avatar_url,omitempty"`
 	Content   string         `json:"content,omitempty"`
+	TTS       bool           `json:"tts,omitempty"`
 	Embeds    []DiscordEmbed `json:"embeds,omitempty"`
 }
 
@@ -116,21 +137,53 @@ func (d *DiscordIntegration) Initialize(rawConfig map[string]interface{}) error
 
 	d.username = config.Username
 	d.avatarURL = config.AvatarURL
+	d.thumbnails = config.Thumbnails
+	d.editMessages = config.EditMessages
+	d.editInPlace = config.EditInPlace
+	d.sendEndedWithoutMessage = config.SendEndedWithoutMessageID
+	d.criticalAlert = config.CriticalAlert
+	d.compact = config.Compact
+	d.maxSignatures = config.MaxSignatures
 	d.client = &http.Client{
 		Timeout: time.Duration(timeout) * time.Second,
 	}
 
+	if !config.EditMessages && rawConfig["editMessages"] == nil {
+		d.editMessages = true
+	}
+
+	if !d.editMessages {
+		log.Printf("Discord message editing disabled, every event will be posted as a new message")
+	}
+
 	log.Printf("Discord integration initialized successfully")
 	return nil
 }
 
 func (d *DiscordIntegration) NotifyNewAttack(ctx context.Context, attack *neoprotect.Attack) (string, error) {
-	embed := d.createAttackEmbed(attack, nil, DiscordColorRed, "`🔥` New DDoS Attack Detected")
+	return d.NotifyEvent(ctx, NewAttackEvent(EventNewAttack, attack, nil), "")
+}
 
-	message := &DiscordMessage{
-		Username:  d.username,
-		AvatarURL: d.avatarURL,
-		Embeds:    []DiscordEmbed{embed},
+func (d *DiscordIntegration) notifyNewAttack(ctx context.Context, event *AttackEvent) (string, error) {
+	attack := event.Attack
+
+	var message *DiscordMessage
+	if d.compact {
+		message = d.compactMessage("new", attack)
+	} else {
+		embed := d.createAttackEmbed(event, DiscordColorRed, codePrefix("🔥")+"New DDoS Attack Detected")
+		embed.Thumbnail = d.thumbnail(event)
+
+		message = &DiscordMessage{
+			Username:  d.username,
+			AvatarURL: d.avatarURL,
+			Embeds:    []DiscordEmbed{embed},
+		}
+	}
+
+	if d.criticalAlert.IsCritical(attack) {
+		message.Content = strings.TrimSpace(d.criticalAlert.Mention + " " + message.Content)
+		message.TTS = d.criticalAlert.TTS
 	}
 
 	messageID, err := d.sendDiscordMessage(ctx, message)
@@ -142,129 +195,279 @@ func (d *DiscordIntegration) NotifyNewAttack(ctx context.Context, attack *neopro
 }
 
 func (d *DiscordIntegration) NotifyAttackUpdate(ctx context.Context, attack *neoprotect.Attack, previous *neoprotect.Attack, messageID string) error {
-	embed := d.createAttackEmbed(attack, previous, DiscordColorYellow, "`📶` DDoS Attack Updated")
+	_, err := d.NotifyEvent(ctx, NewAttackEvent(EventAttackUpdate, attack, previous), messageID)
+	return err
+}
+
+// NotifyEvent renders the event and lets the manager track the message an update was reposted to when the
+// original message ID is unknown
+func (d *DiscordIntegration) NotifyEvent(ctx context.Context, event *AttackEvent, messageID string) (string, error) {
+	switch event.Type {
+	case EventAttackUpdate:
+		return d.notifyAttackUpdate(ctx, event, messageID)
+	case EventAttackEnded:
+		return "", d.notifyAttackEnded(ctx, event, messageID)
+	default:
+		return d.notifyNewAttack(ctx, event)
+	}
+}
+
+// notifyAttackUpdate edits the attack's message, or posts a new one, and returns the ID of the message holding the update
+func (d *DiscordIntegration) notifyAttackUpdate(ctx context.Context, event *AttackEvent, messageID string) (string, error) {
+	attack := event.Attack
+
+	color, title := DiscordColorYellow, codePrefix("📶")+"DDoS Attack Updated"
+	if attack.Subsiding {
+		color, title = DiscordColorBlue, codePrefix("📉")+"DDoS Attack Subsiding"
+	}
+
+	embed := d.createAttackEmbed(event, color, title)
+	embed.Thumbnail = d.thumbnail(event)
 
 	message := &DiscordMessage{
 		Username:  d.username,
 		AvatarURL: d.avatarURL,
 		Embeds:    []DiscordEmbed{embed},
 	}
+	if d.compact {
+		message = d.compactMessage("update", attack)
+	}
 
-	if messageID != "" {
-		return d.updateDiscordMessage(ctx, messageID, message)
+	if messageID != "" && d.editMessages {
+		if !editsInPlace(d.editInPlace, "update") {
+			// the attack's message stays tracked, so the end can still be edited into it
+			_, err := d.sendDiscordMessage(ctx, message)
+			return messageID, err
+		}
+		return messageID, d.updateDiscordMessage(ctx, messageID, message)
 	}
 
-	_, err := d.sendDiscordMessage(ctx, message)
-	return err
+	return d.sendDiscordMessage(ctx, message)
 }
 
 func (d *DiscordIntegration) NotifyAttackEnded(ctx context.Context, attack *neoprotect.Attack, messageID string) error {
-	if messageID == "" {
+	_, err := d.NotifyEvent(ctx, NewAttackEvent(EventAttackEnded, attack, nil), messageID)
+	return err
+}
+
+// notifyAttackEnded edits the end into the attack's message, or posts it as a new message when edits are off,
+// the message must be reposted or sendEndedWithoutMessageId allows it
+func (d *DiscordIntegration) notifyAttackEnded(ctx context.Context, event *AttackEvent, messageID string) error {
+	attack := event.Attack
+
+	postNew := !d.editMessages || !editsInPlace(d.editInPlace, "ended") || (messageID == "" && (event.Repost || d.sendEndedWithoutMessage))
+	if messageID == "" && !postNew {
 		log.Printf("No message ID available for attack %s, cannot update Discord webhook", attack.ID)
 		return nil
 	}
 
-	embed := d.createAttackEmbed(attack, nil, DiscordColorGreen, "`🚀` DDoS Attack Ended")
+	embed := d.createAttackEmbed(event, DiscordColorGreen, codePrefix("🚀")+"DDoS Attack Ended")
+	embed.Thumbnail = d.thumbnail(event)
 
 	message := &DiscordMessage{
 		Username:  d.username,
 		AvatarURL: d.avatarURL,
 		Embeds:    []DiscordEmbed{embed},
 	}
+	if d.compact {
+		message = d.compactMessage("ended", attack)
+	}
+
+	if postNew {
+		return d.postDiscordMessage(ctx, message)
+	}
 
 	return d.updateDiscordMessage(ctx, messageID, message)
 }
 
-func (d *DiscordIntegration) createAttackEmbed(attack *neoprotect.Attack, previous *neoprotect.Attack, color int, title string) DiscordEmbed {
+func (d *DiscordIntegration) NotifyWarning(ctx context.Context, title string, message string) error {
+	embed := DiscordEmbed{
+		Title:       codePrefix("⚠️") + title,
+		Description: message,
+		Color:       DiscordColorYellow,
+		Footer: &DiscordFooter{
+			Text:    "NeoProtect Monitor Bot",
+			IconURL: "https://cms.mscode.pl/uploads/icon_blue_84fa10dde8.png",
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	return d.postDiscordMessage(ctx, &DiscordMessage{
+		Username:  d.username,
+		AvatarURL: d.avatarURL,
+		Embeds:    []DiscordEmbed{embed},
+	})
+}
+
+// NotifyReminder posts a short message that the attack is still ongoing. Webhook messages cannot reply to
+// the original notification, so the reminder links to the attack in the panel instead.
+func (d *DiscordIntegration) NotifyReminder(ctx context.Context, attack *neoprotect.Attack, messageID string) error {
+	return d.postDiscordMessage(ctx, &DiscordMessage{
+		Username:  d.username,
+		AvatarURL: d.avatarURL,
+		Content:   compactAttackLine("reminder", attack) + fmt.Sprintf(" — attack `%s` · [Panel](%s)", displayAttackID(attack.ID), panelLink(attack.DstAddressString)),
+	})
+}
+
+// NotifyEscalation posts the escalation of a long-running attack with the on-call mention. Like reminders, webhook
+// messages cannot reply to the original notification.
+func (d *DiscordIntegration) NotifyEscalation(ctx context.Context, attack *neoprotect.Attack, messageID string, message string, mention string) error {
+	return d.postDiscordMessage(ctx, &DiscordMessage{
+		Username:  d.username,
+		AvatarURL: d.avatarURL,
+		Content:   escalationContent(attack, message, escalationMention(mention, d.criticalAlert)),
+	})
+}
+
+// escalationMention returns the mention of the escalation rule, or the critical alert mention when the rule has none
+func escalationMention(mention string, critical *CriticalAlertConfig) string {
+	if mention == "" && critical != nil {
+		return critical.Mention
+	}
+	return mention
+}
+
+// escalationContent is the Discord message content escalating a long-running attack
+func escalationContent(attack *neoprotect.Attack, message string, mention string) string {
+	content := boldPrefix("🚨") + "**Long-running attack**\n" + message +
+		fmt.Sprintf("\nAttack `%s` · [Panel](%s)", displayAttackID(attack.ID), panelLink(attack.DstAddressString))
+	return strings.TrimSpace(mention + " " + content)
+}
+
+func (d *DiscordIntegration) createAttackEmbed(event *AttackEvent, color int, title string) DiscordEmbed {
+	attack, previous := event.Attack, event.Previous
+
 	var description strings.Builder
 
 	if attack.StartedAt != nil {
 		description.WriteString("### Attack Timeline\n")
-		description.WriteString(fmt.Sprintf("**`🕒`** Started: %s\n", formatTimeToLocal(attack.StartedAt)))
+		description.WriteString(fmt.Sprintf(boldPrefix("🕒")+"Started: %s\n", formatTimeToLocal(attack.StartedAt)))
 
 		if attack.EndedAt != nil {
-			description.WriteString(fmt.Sprintf("**`🛑`** Ended: %s\n", formatTimeToLocal(attack.EndedAt)))
-			description.WriteString(fmt.Sprintf("**`⏱️`** Duration: %s\n", formatDurationReadable(attack.Duration())))
+			description.WriteString(fmt.Sprintf(boldPrefix("🛑")+"Ended: %s\n", formatTimeToLocal(attack.EndedAt)))
+			description.WriteString(fmt.Sprintf(boldPrefix("⏱️")+"Duration: %s\n", formatDurationReadable(attack.Duration())))
 		} else {
-			description.WriteString("**`⚠️`** Status: Active\n")
-			description.WriteString(fmt.Sprintf("**`⏱️`** Duration: %s\n", formatDurationReadable(attack.Duration())))
+			description.WriteString(boldPrefix("⚠️") + "Status: Active\n")
+			description.WriteString(fmt.Sprintf(boldPrefix("⏱️")+"Duration: %s\n", formatDurationReadable(attack.Duration())))
 		}
 	}
 
+	if observedLate(attack) {
+		if attack.StartedAt == nil {
+			description.WriteString("### Attack Timeline\n")
+		}
+		description.WriteString(fmt.Sprintf(boldPrefix("👁️")+"First observed by monitor: %s\n", formatTimeToLocal(attack.FirstObservedAt)))
+	}
+
 	description.WriteString("### Attack Details\n")
 	targetIP := attack.DstAddressString
 	if targetIP == "" {
 		targetIP = "unknown"
 	}
-	description.WriteString(fmt.Sprintf("**`🎯`** Target IP: `%s`\n", targetIP))
+	description.WriteString(fmt.Sprintf(boldPrefix("🎯")+"Target IP: `%s`\n", targetIP))
+	if attack.Account != "" {
+		description.WriteString(fmt.Sprintf(boldPrefix("🏢")+"Account: %s\n", attack.Account))
+	}
+	if len(event.Labels) > 0 {
+		description.WriteString(fmt.Sprintf(boldPrefix("🏷️")+"Labels: %s\n", strings.Join(event.Labels, ", ")))
+	}
+	if event.Severity == SeverityCritical {
+		description.WriteString(boldPrefix("🚨") + "Severity: Critical\n")
+	}
+
+	description.WriteString(fmt.Sprintf(boldPrefix("🔍")+"Attack ID: `%s`\n", displayAttackID(attack.ID)))
 
-	attackID := attack.ID
-	if attackID == "" {
-		attackID = "unknown"
+	panelLink := panelLink(targetIP)
+	description.WriteString(fmt.Sprintf(boldPrefix("🔗")+"[View in NeoProtect Panel](%s)\n", panelLink))
+
+	if len(attack.CorrelatedIPs) > 0 {
+		description.WriteString(fmt.Sprintf(boldPrefix("🧭")+"Likely same source as attack on %s\n", strings.Join(attack.CorrelatedIPs, ", ")))
 	}
-	description.WriteString(fmt.Sprintf("**`🔍`** Attack ID: `%s`\n", attackID))
 
-	panelLink := fmt.Sprintf("https://panel.neoprotect.net/network/ips/%s?tab=attacks", targetIP)
-	description.WriteString(fmt.Sprintf("**`🔗`** [View in NeoProtect Panel](%s)\n", panelLink))
+	note := samplingNote(attack.SampleRate)
+	trafficStats := fmt.Sprintf("**Peak Bandwidth:** %s%s\n**Peak Packet Rate:** %s%s",
+		formatBPS(displayedPeakBPS(attack)), note,
+		formatPPS(displayedPeakPPS(attack)), note)
+	if attack.NewRecordPeak {
+		trafficStats += "\n" + boldPrefix("🏆") + "New record peak for this IP"
+	}
+	if note := saturationNote(attack); note != "" {
+		trafficStats += "\n" + boldPrefix("⚠️") + "**" + note + "**"
+	}
+	if len(attack.ProtocolMix) > 0 {
+		trafficStats += fmt.Sprintf("\n**Protocol Mix:** %s", formatProtocolMix(attack.ProtocolMix))
+	}
+	if len(attack.TargetPorts) > 0 {
+		trafficStats += fmt.Sprintf("\n**Targeted Ports:** %s", formatTargetPorts(attack.TargetPorts, attack.ProtocolMix))
+	}
 
 	fields := []DiscordField{
 		{
-			Name: "**`📊`** Traffic Statistics",
-			Value: fmt.Sprintf("**Peak Bandwidth:** %s\n**Peak Packet Rate:** %s",
-				formatBPS(attack.GetPeakBPS()),
-				formatPPS(attack.GetPeakPPS())),
+			Name:   boldPrefix("📊") + "Traffic Statistics",
+			Value:  trafficStats,
 			Inline: false,
 		},
 		{
-			Name:   "**`🔎`** Attack Signatures",
+			Name:   boldPrefix("🔎") + "Attack Signatures",
 			Value:  d.formatSignatures(attack),
 			Inline: false,
 		},
 	}
 
 	if previous != nil {
-		diff := attack.CalculateDiff(previous)
-		if len(diff) > 0 {
+		if diff := event.Diff; diff.HasChanges() {
 			var changesBuilder strings.Builder
 
-			if bpsChange, ok := diff["bpsPeakChange"].(int64); ok {
-				var changeSymbol string
-				if bpsChange > 0 {
-					changeSymbol = "`📈`"
-				} else {
-					changeSymbol = "`📉`"
-				}
-				changesBuilder.WriteString(fmt.Sprintf("%s **Bandwidth:** %s → %s (%+d%%)\n",
-					changeSymbol,
-					formatBPS(previous.GetPeakBPS()),
-					formatBPS(attack.GetPeakBPS()),
-					calculatePercentageChange(previous.GetPeakBPS(), attack.GetPeakBPS())))
+			if diff.BPSChange != 0 {
+				changesBuilder.WriteString(fmt.Sprintf("%s**Bandwidth:** %s → %s (%+d%%)\n",
+					changeSymbol(diff.BPSChange),
+					formatBPS(displayedPeakBPS(previous)),
+					formatBPS(displayedPeakBPS(attack)),
+					calculatePercentageChange(displayedPeakBPS(previous), displayedPeakBPS(attack))))
+			}
+
+			if diff.PPSChange != 0 {
+				changesBuilder.WriteString(fmt.Sprintf("%s**Packet Rate:** %s → %s (%+d%%)\n",
+					changeSymbol(diff.PPSChange),
+					formatPPS(displayedPeakPPS(previous)),
+					formatPPS(displayedPeakPPS(attack)),
+					calculatePercentageChange(displayedPeakPPS(previous), displayedPeakPPS(attack))))
 			}
 
-			if ppsChange, ok := diff["ppsPeakChange"].(int64); ok {
-				var changeSymbol string
-				if ppsChange > 0 {
-					changeSymbol = "`📈`"
-				} else {
-					changeSymbol = "`📉`"
+			if len(diff.NewSignatures) > 0 {
+				changesBuilder.WriteString(boldPrefix("⚠️") + "New Attack Signatures:\n")
+				for _, sig := range diff.NewSignatures {
+					changesBuilder.WriteString(fmt.Sprintf("• `%s`\n", sig))
 				}
-				changesBuilder.WriteString(fmt.Sprintf("%s **Packet Rate:** %s → %s (%+d%%)\n",
-					changeSymbol,
-					formatPPS(previous.GetPeakPPS()),
-					formatPPS(attack.GetPeakPPS()),
-					calculatePercentageChange(previous.GetPeakPPS(), attack.GetPeakPPS())))
 			}
 
-			if newSigs, ok := diff["newSignatures"].([]string); ok && len(newSigs) > 0 {
-				changesBuilder.WriteString("**`⚠️`** New Attack Signatures:\n")
-				for _, sig := range newSigs {
+			if len(diff.EndedSignatures) > 0 {
+				changesBuilder.WriteString(boldPrefix("✅") + "Ended Attack Signatures:\n")
+				for _, sig := range diff.EndedSignatures {
 					changesBuilder.WriteString(fmt.Sprintf("• `%s`\n", sig))
 				}
 			}
 
+			if diff.AutoMitigation != nil {
+				state := "Off"
+				if *diff.AutoMitigation {
+					state = "On"
+				}
+				changesBuilder.WriteString(fmt.Sprintf("%s**Auto-Mitigation:** switched %s\n", codePrefix("🛡️"), state))
+			}
+
+			if diff.VectorShift != nil {
+				changesBuilder.WriteString(fmt.Sprintf("%s**Vector Shift:** `%s` → `%s`\n", codePrefix("🔀"), diff.VectorShift.From, diff.VectorShift.To))
+			}
+
+			if diff.SampleRateChange != 0 {
+				changesBuilder.WriteString(fmt.Sprintf("%s**Sample Rate:** %s → %s\n",
+					changeSymbol(diff.SampleRateChange), formatCount(previous.SampleRate), formatCount(diff.SampleRateCurrent)))
+			}
+
 			if changesBuilder.Len() > 0 {
 				fields = append(fields, DiscordField{
-					Name:   "**`📝`** Changes Detected",
+					Name:   boldPrefix("📝") + "Changes Detected",
 					Value:  changesBuilder.String(),
 					Inline: false,
 				})
@@ -295,33 +498,49 @@ func (d *DiscordIntegration) createAttackEmbed(attack *neoprotect.Attack, previo
 	return embed
 }
 
-func (d *DiscordIntegration) formatSignatures(attack *neoprotect.Attack) string {
-	names := attack.GetSignatureNames()
-	if len(names) == 0 {
-		return "No signatures detected"
+func (d *DiscordIntegration) compactMessage(event string, attack *neoprotect.Attack) *DiscordMessage {
+	return &DiscordMessage{
+		Username:  d.username,
+		AvatarURL: d.avatarURL,
+		Content:   compactAttackLine(event, attack),
 	}
+}
 
-	var result strings.Builder
-	for _, name := range names {
-		result.WriteString(fmt.Sprintf("• `%s`\n", name))
+func (d *DiscordIntegration) thumbnail(event *AttackEvent) *DiscordImage {
+	if url := thumbnailURL(d.thumbnails, event); url != "" {
+		return &DiscordImage{URL: url}
 	}
+	return nil
+}
 
-	return result.String()
+// thumbnailEventKeys are the thumbnails keys of the event types, used when no thumbnail is set for the severity
+var thumbnailEventKeys = map[AttackEventType]string{
+	EventNewAttack:    "new",
+	EventAttackUpdate: "update",
+	EventAttackEnded:  "ended",
 }
 
-func (d *DiscordIntegration) sendDiscordMessage(ctx context.Context, message *DiscordMessage) (string, error) {
-	if d.client == nil {
-		d.client = &http.Client{
-			Timeout: 10 * time.Second,
-		}
-		log.Printf("Warning: Discord integration HTTP client was nil, created a default one")
+// thumbnailURL returns the thumbnail configured for the severity of the event, so critical attacks stand out,
+// falling back to the one configured for its event type
+func thumbnailURL(thumbnails map[string]string, event *AttackEvent) string {
+	if url := thumbnails[string(event.Severity)]; url != "" {
+		return url
 	}
+	return thumbnails[thumbnailEventKeys[event.Type]]
+}
 
+func (d *DiscordIntegration) formatSignatures(attack *neoprotect.Attack) string {
+	return formatSignatureList(attack, d.maxSignatures)
+}
+
+func (d *DiscordIntegration) sendDiscordMessage(ctx context.Context, message *DiscordMessage) (string, error) {
 	webhookURL := d.webhookURL
-	if !strings.Contains(webhookURL, "?") {
-		webhookURL += "?wait=true"
-	} else {
-		webhookURL += "&wait=true"
+	if d.editMessages {
+		if !strings.Contains(webhookURL, "?") {
+			webhookURL += "?wait=true"
+		} else {
+			webhookURL += "&wait=true"
+		}
 	}
 
 	jsonMessage, err := json.Marshal(message)
@@ -329,65 +548,52 @@ func (d *DiscordIntegration) sendDiscordMessage(ctx context.Context, message *Di
 		return "", fmt.Errorf("failed to marshal Discord message: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewBuffer(jsonMessage))
-	if err != nil {
-		return "", fmt.Errorf("failed to create Discord request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := d.client.Do(req)
+	statusCode, bodyBytes, err := d.doDiscordRequest(ctx, http.MethodPost, webhookURL, jsonMessage)
 	if err != nil {
-		return "", fmt.Errorf("failed to send Discord request: %w", err)
+		return "", err
 	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			log.Printf("Error closing response body: %v", err)
-		}
-	}()
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return "", fmt.Errorf("discord request failed with status code %d and could not read response body: %v",
-				resp.StatusCode, err)
+	if statusCode < 200 || statusCode >= 300 {
+		if bodyBytes == nil {
+			return "", fmt.Errorf("discord request failed with status code %d and could not read response body", statusCode)
 		}
-		return "", fmt.Errorf("discord request failed with status code %d: %s", resp.StatusCode, string(body))
+		return "", fmt.Errorf("discord request failed with status code %d: %s", statusCode, string(bodyBytes))
 	}
 
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("Warning: Could not read Discord response body: %v", err)
+	if !d.editMessages {
 		return "", nil
 	}
 
+	if bodyBytes == nil {
+		return "", fmt.Errorf("%w: could not read Discord response body", ErrMessageIDUnknown)
+	}
+
 	if len(bodyBytes) == 0 {
-		log.Printf("Warning: Discord response body is empty")
-		return "", nil
+		return "", fmt.Errorf("%w: Discord response body is empty", ErrMessageIDUnknown)
 	}
 
 	var response DiscordResponse
 	if err := json.Unmarshal(bodyBytes, &response); err != nil {
-		log.Printf("Warning: Could not unmarshal Discord response: %v, body: %s", err, string(bodyBytes))
-		return "", nil
+		return "", fmt.Errorf("%w: could not unmarshal Discord response: %v, body: %s", ErrMessageIDUnknown, err, string(bodyBytes))
 	}
 
 	if response.ID == "" {
-		log.Printf("Warning: Discord response does not contain message ID, full response: %s", string(bodyBytes))
-		return "", nil
+		return "", fmt.Errorf("%w: Discord response does not contain message ID, full response: %s", ErrMessageIDUnknown, string(bodyBytes))
 	}
 
 	return response.ID, nil
 }
 
-func (d *DiscordIntegration) updateDiscordMessage(ctx context.Context, messageID string, message *DiscordMessage) error {
-	if d.client == nil {
-		d.client = &http.Client{
-			Timeout: 10 * time.Second,
-		}
-		log.Printf("Warning: Discord integration HTTP client was nil, created a default one")
+// postDiscordMessage sends a message that is never edited, so an unknown message ID is not an error
+func (d *DiscordIntegration) postDiscordMessage(ctx context.Context, message *DiscordMessage) error {
+	_, err := d.sendDiscordMessage(ctx, message)
+	if errors.Is(err, ErrMessageIDUnknown) {
+		return nil
 	}
+	return err
+}
 
+func (d *DiscordIntegration) updateDiscordMessage(ctx context.Context, messageID string, message *DiscordMessage) error {
 	updateURL := fmt.Sprintf("%s/messages/%s", d.webhookURL, messageID)
 
 	jsonMessage, err := json.Marshal(message)
@@ -395,31 +601,97 @@ func (d *DiscordIntegration) updateDiscordMessage(ctx context.Context, messageID
 		return fmt.Errorf("failed to marshal Discord message: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, updateURL, bytes.NewBuffer(jsonMessage))
+	statusCode, bodyBytes, err := d.doDiscordRequest(ctx, http.MethodPatch, updateURL, jsonMessage)
 	if err != nil {
-		return fmt.Errorf("failed to create Discord update request: %w", err)
+		return err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	if statusCode < 200 || statusCode >= 300 {
+		if bodyBytes == nil {
+			return fmt.Errorf("discord update request failed with status code %d and could not read response body", statusCode)
+		}
+		return fmt.Errorf("discord update request failed with status code %d: %s", statusCode, string(bodyBytes))
+	}
 
-	resp, err := d.client.Do(req)
+	return nil
+}
+
+// discordMaxAttempts bounds how often a rate-limited webhook request is sent
+const discordMaxAttempts = 4
+
+type discordRateLimitResponse struct {
+	RetryAfter float64 `json:"retry_after"`
+	Global     bool    `json:"global"`
+}
+
+// HealthCheck fetches the webhook, which fails once it was deleted or its token is wrong
+func (d *DiscordIntegration) HealthCheck(ctx context.Context) error {
+	statusCode, _, err := d.doDiscordRequest(ctx, http.MethodGet, d.webhookURL, nil)
 	if err != nil {
-		return fmt.Errorf("failed to send Discord update request: %w", err)
+		return err
 	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			log.Printf("Error closing response body: %v", err)
+	if statusCode != http.StatusOK {
+		return fmt.Errorf("discord webhook returned status %d", statusCode)
+	}
+	return nil
+}
+
+// doDiscordRequest sends a webhook request, retrying 429 responses after the delay Discord asks for.
+// The returned body is nil if it could not be read.
+func (d *DiscordIntegration) doDiscordRequest(ctx context.Context, method, url string, payload []byte) (int, []byte, error) {
+	if d.client == nil {
+		d.client = &http.Client{
+			Timeout: 10 * time.Second,
+		}
+		log.Printf("Warning: Discord integration HTTP client was nil, created a default one")
+	}
+
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(payload))
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to create Discord request: %w", err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := d.client.Do(req)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to send Discord request: %w", err)
 		}
-	}()
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, err := io.ReadAll(resp.Body)
 		if err != nil {
-			return fmt.Errorf("discord update request failed with status code %d and could not read response body: %v",
-				resp.StatusCode, err)
+			body = nil
 		}
-		return fmt.Errorf("discord update request failed with status code %d: %s", resp.StatusCode, string(body))
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Error closing response body: %v", err)
+		}
+
+		if resp.StatusCode != http.StatusTooManyRequests || attempt >= discordMaxAttempts {
+			return resp.StatusCode, body, nil
+		}
+
+		delay := discordRetryDelay(resp, body, attempt)
+		log.Printf("Discord webhook rate limited, retrying in %s (attempt %d/%d)", delay, attempt, discordMaxAttempts)
+
+		select {
+		case <-ctx.Done():
+			return 0, nil, fmt.Errorf("discord request cancelled while rate limited: %w", ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+}
+
+// discordRetryDelay reads retry_after from a 429 response, falling back to exponential backoff
+func discordRetryDelay(resp *http.Response, body []byte, attempt int) time.Duration {
+	var rateLimit discordRateLimitResponse
+	if body != nil && json.Unmarshal(body, &rateLimit) == nil && rateLimit.RetryAfter > 0 {
+		return time.Duration(rateLimit.RetryAfter * float64(time.Second))
 	}
 
-	return nil
+	if seconds, err := strconv.ParseFloat(resp.Header.Get("Retry-After"), 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+
+	return time.Duration(1<<(attempt-1)) * time.Second
 }
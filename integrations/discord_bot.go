@@ -1,52 +1,140 @@
 package integrations
 
 import (
+	"bytes"
 	"context"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"net"
 	"regexp"
+	"runtime/debug"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"neoprotect-notifier/config"
 	"neoprotect-notifier/neoprotect"
 )
 
+func init() {
+	RegisterIntegration("discord_bot", func() Integration { return &DiscordBotIntegration{} })
+}
+
 type DiscordBotIntegration struct {
 	token              string
 	clientID           string
 	guildID            string
 	channelID          string
+	statusChannelID    string
 	username           string
 	avatarURL          string
 	commandsEnabled    bool
-	attackCache        map[string]string
+	attackCache        map[string]cachedAttackMessage
 	messageMutex       sync.RWMutex
 	neoprotectAPI      *neoprotect.Client
+	monitorStatus      *MonitorStatus
+	resendSource       *resendSource
+	pollTrigger        PollTrigger
+	blacklist          *Blacklist
+	subscriptions      *Subscriptions
+	monitorConfig      *config.Config
 	dg                 *discordgo.Session
 	allowedRoles       []string
+	thumbnails         map[string]string
+	criticalAlert      *CriticalAlertConfig
+	compact            bool
+	maxSignatures      int
+	threadUpdates      bool
+	editInPlace        map[string]bool
+	historyCache       *historyCache
+	alertWebhook       *discordgo.Webhook
 	registeredCommands []*discordgo.ApplicationCommand
 }
 
+// cachedAttackMessage is the alert message posted for an attack and, with threadUpdates, the thread started from it
+type cachedAttackMessage struct {
+	messageID string
+	threadID  string
+}
+
+// attackThreadArchiveMinutes is how long an attack thread stays open without activity
+const attackThreadArchiveMinutes = 1440
+
+// resendSource is what the /resend command needs to re-issue notifications for known attacks
+type resendSource struct {
+	manager        *Manager
+	knownAttacks   *AttackStore
+	messageTracker *MessageTracker
+}
+
 type DiscordBotConfig struct {
-	Token           string   `json:"token"`
-	ClientID        string   `json:"clientId"`
-	GuildID         string   `json:"guildId"`
-	ChannelID       string   `json:"channelId"`
-	Username        string   `json:"username"`
-	AvatarURL       string   `json:"avatarUrl"`
-	CommandsEnabled bool     `json:"commandsEnabled"`
-	AllowedRoles    []string `json:"allowedRoles"`
+	Token               string               `json:"token"`
+	ClientID            string               `json:"clientId"`
+	GuildID             string               `json:"guildId"`
+	ChannelID           string               `json:"channelId"`
+	StatusChannelID     string               `json:"statusChannelId"`
+	Username            string               `json:"username"`
+	AvatarURL           string               `json:"avatarUrl"`
+	CommandsEnabled     bool                 `json:"commandsEnabled"`
+	AllowedRoles        []string             `json:"allowedRoles"`
+	Thumbnails          map[string]string    `json:"thumbnails"`
+	CriticalAlert       *CriticalAlertConfig `json:"criticalAlert"`
+	Compact             bool                 `json:"compact"`
+	MaxSignatures       int                  `json:"maxSignatures"`
+	ThreadUpdates       bool                 `json:"threadUpdates"`
+	EditInPlace         map[string]bool      `json:"editInPlace"`
+	HistoryCacheSeconds int                  `json:"historyCacheSeconds"`
+	ConnectAttempts     int                  `json:"connectAttempts"`
+	UseWebhook          bool                 `json:"useWebhook"`
+	SubscriptionsFile   string               `json:"subscriptionsFile"`
 }
 
+// defaultSubscriptionsFile stores the /subscribe subscriptions unless subscriptionsFile is set
+const defaultSubscriptionsFile = "subscriptions.json"
+
 func (d *DiscordBotIntegration) Name() string {
 	return "discord_bot"
 }
 
+const (
+	// defaultConnectAttempts is how often the gateway connection is tried at startup unless connectAttempts is set
+	defaultConnectAttempts = 5
+	// connectRetryDelay is the wait before the second attempt, it doubles up to maxConnectRetryDelay
+	connectRetryDelay    = 2 * time.Second
+	maxConnectRetryDelay = 30 * time.Second
+)
+
+// openSession opens the gateway connection, retrying with a doubling delay so a brief Discord outage at startup
+// does not disable the bot
+func openSession(dg *discordgo.Session, attempts int) error {
+	delay := connectRetryDelay
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = dg.Open(); err == nil {
+			return nil
+		}
+		if attempt == attempts {
+			break
+		}
+
+		log.Printf("Failed to connect to Discord (attempt %d/%d), retrying in %s: %v", attempt, attempts, delay, err)
+		time.Sleep(delay)
+
+		delay *= 2
+		if delay > maxConnectRetryDelay {
+			delay = maxConnectRetryDelay
+		}
+	}
+
+	return fmt.Errorf("giving up after %d attempt(s): %w", attempts, err)
+}
+
 func (d *DiscordBotIntegration) Initialize(rawConfig map[string]interface{}) error {
 	configBytes, err := json.Marshal(rawConfig)
 	if err != nil {
@@ -69,12 +157,38 @@ func (d *DiscordBotIntegration) Initialize(rawConfig map[string]interface{}) err
 	d.clientID = config.ClientID
 	d.guildID = config.GuildID
 	d.channelID = config.ChannelID
+	d.statusChannelID = config.StatusChannelID
+	if d.statusChannelID == "" {
+		d.statusChannelID = config.ChannelID
+	}
 	d.username = config.Username
+	d.avatarURL = config.AvatarURL
 	d.commandsEnabled = config.CommandsEnabled
-	d.attackCache = make(map[string]string)
+	d.attackCache = make(map[string]cachedAttackMessage)
 	d.allowedRoles = config.AllowedRoles
+	d.thumbnails = config.Thumbnails
+	d.criticalAlert = config.CriticalAlert
+	d.compact = config.Compact
+	d.maxSignatures = config.MaxSignatures
+	d.threadUpdates = config.ThreadUpdates
+	d.editInPlace = config.EditInPlace
 	d.registeredCommands = make([]*discordgo.ApplicationCommand, 0)
 
+	historyTTL := time.Duration(config.HistoryCacheSeconds) * time.Second
+	if rawConfig["historyCacheSeconds"] == nil {
+		historyTTL = defaultHistoryCacheTTL
+	}
+	d.historyCache = newHistoryCache(historyTTL)
+
+	subscriptionsFile := config.SubscriptionsFile
+	if subscriptionsFile == "" {
+		subscriptionsFile = defaultSubscriptionsFile
+	}
+	d.subscriptions, err = NewSubscriptions(subscriptionsFile)
+	if err != nil {
+		log.Printf("Warning: failed to load subscriptions, /subscribe is disabled until the file is fixed: %v", err)
+	}
+
 	if !config.CommandsEnabled && rawConfig["commandsEnabled"] == nil {
 		d.commandsEnabled = true
 	}
@@ -101,13 +215,34 @@ func (d *DiscordBotIntegration) Initialize(rawConfig map[string]interface{}) err
 	dg.AddHandler(d.handleReady)
 	dg.AddHandler(d.handleInteractionCreate)
 
-	err = dg.Open()
-	if err != nil {
+	connectAttempts := defaultConnectAttempts
+	if config.ConnectAttempts > 0 {
+		connectAttempts = config.ConnectAttempts
+	}
+
+	if err := openSession(dg, connectAttempts); err != nil {
 		return fmt.Errorf("error opening connection to Discord: %w", err)
 	}
 
 	d.dg = dg
 
+	if config.UseWebhook {
+		webhook, err := d.channelWebhook()
+		if err != nil {
+			log.Printf("Warning: Failed to set up a webhook in the alert channel, posting alerts as the bot: %v", err)
+		} else {
+			d.alertWebhook = webhook
+			log.Printf("Posting attack alerts through webhook %s", webhook.ID)
+		}
+	} else if d.username != "" && d.guildID != "" {
+		if err := d.dg.GuildMemberNickname(d.guildID, "@me", d.username); err != nil {
+			log.Printf("Warning: Failed to set the bot nickname to %q: %v", d.username, err)
+		}
+	}
+	if d.alertWebhook == nil && d.avatarURL != "" {
+		log.Printf("Warning: avatarUrl only applies to alerts posted through a webhook, set useWebhook to use it")
+	}
+
 	if d.commandsEnabled {
 		err = d.registerCommands()
 		if err != nil {
@@ -119,7 +254,7 @@ func (d *DiscordBotIntegration) Initialize(rawConfig map[string]interface{}) err
 		log.Printf("Skipping command registration - commands are disabled")
 	}
 
-	_, err = d.dg.ChannelMessageSend(d.channelID, "🤖 **NeoProtect Monitor Bot is online!**")
+	_, err = d.dg.ChannelMessageSend(d.statusChannelID, prefix("🤖")+"**NeoProtect Monitor Bot is online!**")
 	if err != nil {
 		log.Printf("Warning: Failed to send welcome message: %v", err)
 	}
@@ -154,6 +289,26 @@ func (d *DiscordBotIntegration) hasAllowedRole(i *discordgo.InteractionCreate) b
 	return false
 }
 
+func interactionUsername(i *discordgo.InteractionCreate) string {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User.Username
+	}
+	if i.User != nil {
+		return i.User.Username
+	}
+	return "unknown"
+}
+
+func interactionUserID(i *discordgo.InteractionCreate) string {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User.ID
+	}
+	if i.User != nil {
+		return i.User.ID
+	}
+	return ""
+}
+
 func (d *DiscordBotIntegration) handleReady(s *discordgo.Session, i *discordgo.Ready) {
 	log.Println("Discord bot is now ready!")
 
@@ -182,6 +337,10 @@ func (d *DiscordBotIntegration) registerCommands() error {
 				},
 			},
 		},
+		{
+			Name:        "active",
+			Description: "List all currently active attacks",
+		},
 		{
 			Name:        "stats",
 			Description: "Get detailed statistics about DDoS attacks",
@@ -192,6 +351,30 @@ func (d *DiscordBotIntegration) registerCommands() error {
 					Description: "IP address to get stats for (optional)",
 					Required:    false,
 				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "group",
+					Description: "Only include IPs of this ipGroups group (optional)",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:        "compare",
+			Description: "Compare two attacks side by side",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "id1",
+					Description: "ID of the first attack",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "id2",
+					Description: "ID of the second attack",
+					Required:    true,
+				},
 			},
 		},
 		{
@@ -206,6 +389,190 @@ func (d *DiscordBotIntegration) registerCommands() error {
 				},
 			},
 		},
+		{
+			Name:        "export-history",
+			Description: "Download the full attack history of an IP as a file",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "ip",
+					Description: "IP address to export the history of",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "format",
+					Description: "File format (default: csv)",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "CSV", Value: "csv"},
+						{Name: "JSON", Value: "json"},
+					},
+				},
+			},
+		},
+		{
+			Name:        "health",
+			Description: "Show the health of the NeoProtect monitor",
+		},
+		{
+			Name:        "resend",
+			Description: "Re-send the current notification for an attack to all integrations",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "id",
+					Description: "Attack ID",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:        "top",
+			Description: "Rank IPs by how often they were attacked",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "days",
+					Description: "Time window in days (default: 30)",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "sort",
+					Description: "Ranking criteria (default: count)",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Attack count", Value: "count"},
+						{Name: "Total attack time", Value: "duration"},
+						{Name: "Peak bandwidth", Value: "peak"},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "group",
+					Description: "Only include IPs of this ipGroups group (optional)",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:                     "maintenance",
+			Description:              "Suppress notifications during planned work",
+			DefaultMemberPermissions: &maintenancePermissions,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "on",
+					Description: "Suppress notifications until turned off or the duration has passed",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "minutes",
+							Description: "End maintenance automatically after this many minutes (optional)",
+							Required:    false,
+							MinValue:    &maintenanceMinMinutes,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "off",
+					Description: "Resume notifications and post a summary of the maintenance",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "status",
+					Description: "Show whether maintenance mode is active",
+				},
+			},
+		},
+		{
+			Name:                     "poll",
+			Description:              "Poll the NeoProtect API now instead of waiting for the next interval",
+			DefaultMemberPermissions: &pollPermissions,
+		},
+		{
+			Name:                     "raw",
+			Description:              "Show the raw JSON the NeoProtect API returned for an attack",
+			DefaultMemberPermissions: &rawPermissions,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "id",
+					Description: "Attack ID",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "ip",
+					Description: "IP address, shows its latest attack",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:        "subscribe",
+			Description: "Get a direct message when an IP is attacked, or list your subscriptions",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "ip",
+					Description: "IP address to subscribe to (optional, lists your subscriptions if omitted)",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:        "unsubscribe",
+			Description: "Stop getting direct messages about attacks on an IP",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "ip",
+					Description: "IP address to unsubscribe from",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:                     "blacklist",
+			Description:              "Manage IPs excluded from monitoring",
+			DefaultMemberPermissions: &blacklistPermissions,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "add",
+					Description: "Stop notifying about attacks on an IP",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "ip",
+							Description: "IP address to blacklist",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "remove",
+					Description: "Resume notifying about attacks on an IP",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "ip",
+							Description: "IP address to remove from the blacklist",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "List blacklisted IPs",
+				},
+			},
+		},
 	}
 
 	d.registeredCommands = make([]*discordgo.ApplicationCommand, 0)
@@ -238,11 +605,13 @@ func (d *DiscordBotIntegration) handleInteractionCreate(s *discordgo.Session, i
 
 	log.Printf("Received command: %s", i.ApplicationCommandData().Name)
 
+	defer d.recoverCommandPanic(s, i)
+
 	if !d.commandsEnabled {
 		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Content: "❌ Bot commands are currently disabled by the administrator.",
+				Content: prefix("❌") + "Bot commands are currently disabled by the administrator.",
 				Flags:   discordgo.MessageFlagsEphemeral,
 			},
 		})
@@ -253,11 +622,11 @@ func (d *DiscordBotIntegration) handleInteractionCreate(s *discordgo.Session, i
 	}
 
 	if !d.hasAllowedRole(i) {
-		log.Printf("User %s doesn't have any of the allowed roles to use commands", i.Member.User.Username)
+		log.Printf("User %s doesn't have any of the allowed roles to use commands", interactionUsername(i))
 		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Content: "❌ You don't have permission to use this command. Please contact an administrator.",
+				Content: prefix("❌") + "You don't have permission to use this command. Please contact an administrator.",
 				Flags:   discordgo.MessageFlagsEphemeral,
 			},
 		})
@@ -270,15 +639,37 @@ func (d *DiscordBotIntegration) handleInteractionCreate(s *discordgo.Session, i
 	switch i.ApplicationCommandData().Name {
 	case "attack":
 		d.handleAttackCommand(s, i)
+	case "active":
+		d.handleActiveCommand(s, i)
 	case "stats":
 		d.handleStatsCommand(s, i)
+	case "compare":
+		d.handleCompareCommand(s, i)
 	case "history":
 		d.handleHistoryCommand(s, i)
+	case "export-history":
+		d.handleExportHistoryCommand(s, i)
+	case "health":
+		d.handleHealthCommand(s, i)
+	case "top":
+		d.handleTopCommand(s, i)
+	case "resend":
+		d.handleResendCommand(s, i)
+	case "blacklist":
+		d.handleBlacklistCommand(s, i)
+	case "subscribe", "unsubscribe":
+		d.handleSubscribeCommand(s, i)
+	case "poll":
+		d.handlePollCommand(s, i)
+	case "raw":
+		d.handleRawCommand(s, i)
+	case "maintenance":
+		d.handleMaintenanceCommand(s, i)
 	default:
 		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Content: "Unknown command. Available commands: `/attack`, `/stats`, `/history`",
+				Content: "Unknown command. Available commands: `/attack`, `/active`, `/stats`, `/compare`, `/history`, `/export-history`, `/health`, `/top`, `/resend`, `/blacklist`, `/subscribe`, `/unsubscribe`, `/poll`, `/raw`, `/maintenance`",
 			},
 		})
 		if err != nil {
@@ -287,6 +678,22 @@ func (d *DiscordBotIntegration) handleInteractionCreate(s *discordgo.Session, i
 	}
 }
 
+func (d *DiscordBotIntegration) recoverCommandPanic(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	log.Printf("Recovered from panic in command %s: %v\n%s", i.ApplicationCommandData().Name, r, debug.Stack())
+
+	_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Content: prefix("❌") + "An internal error occurred while processing this command.",
+	})
+	if err != nil {
+		log.Printf("Error sending panic followup message: %v", err)
+	}
+}
+
 func (d *DiscordBotIntegration) handleAttackCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
@@ -299,7 +706,7 @@ func (d *DiscordBotIntegration) handleAttackCommand(s *discordgo.Session, i *dis
 
 	if d.neoprotectAPI == nil {
 		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-			Content: "⚠️ NeoProtect API client is not configured for this bot.",
+			Content: prefix("⚠️") + "NeoProtect API client is not configured for this bot.",
 		})
 		if err != nil {
 			return
@@ -321,13 +728,12 @@ func (d *DiscordBotIntegration) handleAttackCommand(s *discordgo.Session, i *dis
 	defer cancel()
 
 	var attack *neoprotect.Attack
-	var fetchErr error
 
 	if attackID == "" {
-		ipAddresses, err := d.neoprotectAPI.GetIPAddresses(ctx)
-		if err != nil {
+		active, err := d.neoprotectAPI.ListActiveAttacks(ctx)
+		if err != nil && len(active) == 0 {
 			_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-				Content: fmt.Sprintf("❌ Failed to fetch IP addresses: %v", err),
+				Content: fmt.Sprintf(prefix("❌")+"Failed to fetch active attacks: %v", err),
 			})
 			if err != nil {
 				return
@@ -335,25 +741,19 @@ func (d *DiscordBotIntegration) handleAttackCommand(s *discordgo.Session, i *dis
 			return
 		}
 
-		for _, ip := range ipAddresses {
-			attack, fetchErr = d.neoprotectAPI.GetActiveAttack(ctx, ip.IPv4)
-			if fetchErr == nil && attack != nil {
-				break
-			}
-		}
-
-		if attack == nil {
+		if len(active) == 0 {
 			_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-				Content: "✅ No active attacks found.",
+				Content: prefix("✅") + "No active attacks found.",
 			})
 			if err != nil {
 				return
 			}
 			return
 		}
+		attack = active[0]
 	} else {
 		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-			Content: "❌ Looking up attacks by ID is not currently supported. Please use `/history` to view recent attacks.",
+			Content: prefix("❌") + "Looking up attacks by ID is not currently supported. Please use `/history` to view recent attacks.",
 		})
 		if err != nil {
 			return
@@ -361,7 +761,18 @@ func (d *DiscordBotIntegration) handleAttackCommand(s *discordgo.Session, i *dis
 		return
 	}
 
-	embed := d.createDiscordgoEmbed(attack, nil, 0x3498DB, "DDoS Attack Details")
+	embed := d.createDiscordgoEmbed(NewAttackEvent(EventNewAttack, attack, nil), 0x3498DB, "DDoS Attack Details")
+
+	if timeline := formatVectorTimeline(attack); timeline != "" {
+		if len(timeline) > 1024 {
+			timeline = timeline[:strings.LastIndex(timeline[:1021], "\n")+1] + "…"
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   boldPrefix("🕒") + "Vector Timeline",
+			Value:  timeline,
+			Inline: false,
+		})
+	}
 
 	_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
 		Embeds: []*discordgo.MessageEmbed{embed},
@@ -371,7 +782,16 @@ func (d *DiscordBotIntegration) handleAttackCommand(s *discordgo.Session, i *dis
 	}
 }
 
-func (d *DiscordBotIntegration) handleHistoryCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+// embedDescriptionLimit is the most characters Discord accepts in an embed description
+const embedDescriptionLimit = 4096
+
+// activeListReserve keeps room in the /active description for the line counting the attacks left out
+const activeListReserve = 64
+
+// activeSignatureLimit caps how many signatures /active names per attack
+const activeSignatureLimit = 3
+
+func (d *DiscordBotIntegration) handleActiveCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
 	})
@@ -383,7 +803,7 @@ func (d *DiscordBotIntegration) handleHistoryCommand(s *discordgo.Session, i *di
 
 	if d.neoprotectAPI == nil {
 		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-			Content: "⚠️ NeoProtect API client is not configured for this bot.",
+			Content: prefix("⚠️") + "NeoProtect API client is not configured for this bot.",
 		})
 		if err != nil {
 			return
@@ -391,138 +811,195 @@ func (d *DiscordBotIntegration) handleHistoryCommand(s *discordgo.Session, i *di
 		return
 	}
 
-	options := i.ApplicationCommandData().Options
-
-	limit := 5
-	for _, opt := range options {
-		if opt.Name == "limit" {
-			limit = int(opt.IntValue())
-			if limit < 1 {
-				limit = 1
-			} else if limit > 20 {
-				limit = 20
-			}
-			break
-		}
-	}
-
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	ipAddresses, err := d.neoprotectAPI.GetIPAddresses(ctx)
-	if err != nil {
+	active, err := d.neoprotectAPI.ListActiveAttacks(ctx)
+	if err != nil && len(active) == 0 {
 		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-			Content: fmt.Sprintf("❌ Failed to fetch IP addresses: %v", err),
+			Content: fmt.Sprintf(prefix("❌")+"Failed to fetch active attacks: %v", err),
 		})
 		if err != nil {
 			return
 		}
 		return
 	}
+	partial := err != nil
 
-	var allAttacks []*neoprotect.Attack
-	for _, ip := range ipAddresses {
-		if ip == nil || ip.IPv4 == "" {
-			continue
-		}
-
-		maxPages := 5
-		for page := 0; page < maxPages; page++ {
-			attacks, err := d.neoprotectAPI.GetAttacks(ctx, ip.IPv4, page)
-			if err != nil {
-				log.Printf("Warning: Failed to fetch attacks for IP %s, page %d: %v", ip.IPv4, page, err)
-				break
-			}
-
-			if len(attacks) == 0 {
-				break
-			}
+	sort.Slice(active, func(i, j int) bool {
+		return displayedPeakBPS(active[i]) > displayedPeakBPS(active[j])
+	})
 
-			allAttacks = append(allAttacks, attacks...)
+	color := 0xFF0000
+	if len(active) == 0 {
+		color = 0x00FF00
+	}
 
-			if len(allAttacks) >= limit*3 {
-				break
-			}
-		}
+	footer := fmt.Sprintf("%d active attack(s)", len(active))
+	if partial {
+		footer += " · the listing could not be fetched completely"
+	}
 
-		if len(allAttacks) >= limit*2 {
-			break
-		}
+	embed := &discordgo.MessageEmbed{
+		Title:       "Active DDoS Attacks",
+		Description: activeAttacksDescription(active),
+		Color:       color,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text:    footer,
+			IconURL: "https://cms.mscode.pl/uploads/icon_blue_84fa10dde8.png",
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
 	}
 
-	sort.Slice(allAttacks, func(i, j int) bool {
-		if allAttacks[i].StartedAt == nil {
-			return false
-		}
-		if allAttacks[j].StartedAt == nil {
-			return true
-		}
-		return allAttacks[i].StartedAt.After(*allAttacks[j].StartedAt)
+	_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Embeds: []*discordgo.MessageEmbed{embed},
 	})
+	if err != nil {
+		log.Printf("Error sending followup message: %v", err)
+	}
+}
 
-	if len(allAttacks) > limit {
-		allAttacks = allAttacks[:limit]
+// activeAttacksDescription lists the attacks, which must be sorted by peak, for /active. Attacks are listed while
+// they fit into the embed description, the rest are only counted.
+func activeAttacksDescription(active []*neoprotect.Attack) string {
+	if len(active) == 0 {
+		return prefix("✅") + "No active attacks found."
 	}
 
 	var description strings.Builder
-	description.WriteString(fmt.Sprintf("## Recent Attack History\n\n"))
+	description.WriteString(fmt.Sprintf(boldPrefix("📊")+"Peaks: %s\n\n", aggregatePeaks(active).format()))
 
-	if len(allAttacks) == 0 {
-		description.WriteString("No attack history found.")
-	} else {
-		for i, attack := range allAttacks {
-			if attack == nil || attack.StartedAt == nil {
-				continue
-			}
+	for index, attack := range active {
+		entry := activeAttackEntry(attack)
+		if description.Len()+len(entry) > embedDescriptionLimit-activeListReserve {
+			description.WriteString(fmt.Sprintf("… and %d more\n", len(active)-index))
+			break
+		}
+		description.WriteString(entry)
+	}
 
-			status := "✅ Ended"
-			duration := "N/A"
-			panelLink := fmt.Sprintf("https://panel.neoprotect.net/network/ips/%s?tab=attacks", attack.DstAddressString)
+	return description.String()
+}
 
-			if attack.EndedAt != nil {
-				duration = formatDurationReadable(attack.Duration())
-			} else {
-				status = "`🚨` Active"
-				duration = fmt.Sprintf("%s (ongoing)", formatDurationReadable(attack.Duration()))
+// activeAttackEntry is the /active entry of a single attack
+func activeAttackEntry(attack *neoprotect.Attack) string {
+	duration := "unknown"
+	if attack.StartedAt != nil {
+		duration = formatDurationReadable(attack.Duration())
+	}
+
+	var entry strings.Builder
+	entry.WriteString(fmt.Sprintf("### %s\n", attack.DstAddressString))
+	entry.WriteString(fmt.Sprintf("**ID:** `%s` · [Panel](%s)\n", displayAttackID(attack.ID), panelLink(attack.DstAddressString)))
+	entry.WriteString(fmt.Sprintf("**Running for:** %s\n", duration))
+	entry.WriteString(fmt.Sprintf("**Peak:** %s / %s%s\n",
+		formatBPS(displayedPeakBPS(attack)),
+		formatPPS(displayedPeakPPS(attack)),
+		samplingNote(attack.SampleRate)))
+	if signatures := attack.GetSignatureNamesByPeak(); len(signatures) > 0 {
+		entry.WriteString("**Signatures:** ")
+		for j, sig := range signatures {
+			if j == activeSignatureLimit {
+				entry.WriteString(fmt.Sprintf(" and %d more", len(signatures)-activeSignatureLimit))
+				break
 			}
+			if j > 0 {
+				entry.WriteString(", ")
+			}
+			entry.WriteString(fmt.Sprintf("`%s`", sig))
+		}
+		entry.WriteString("\n")
+	}
+	return entry.String()
+}
 
-			description.WriteString(fmt.Sprintf("### %d. Attack on %s\n", i+1, attack.DstAddressString))
-			description.WriteString(fmt.Sprintf("**ID:** `%s`\n", attack.ID))
-			description.WriteString(fmt.Sprintf("**Started:** %s\n", formatTimeToLocal(attack.StartedAt)))
-			description.WriteString(fmt.Sprintf("**Status:** %s\n", status))
-			description.WriteString(fmt.Sprintf("**Duration:** %s\n", duration))
-			description.WriteString(fmt.Sprintf("**Peak:** %s / %s\n",
-				formatBPS(attack.GetPeakBPS()),
-				formatPPS(attack.GetPeakPPS())))
-			description.WriteString(fmt.Sprintf("**Panel:** [View Details](%s)\n", panelLink))
+// compareTopCountries is how many source countries /compare lists per attack
+const compareTopCountries = 3
 
-			signatures := attack.GetSignatureNames()
-			if len(signatures) > 0 {
-				description.WriteString("**Signatures:** ")
-				for j, sig := range signatures {
-					if j > 0 {
-						description.WriteString(", ")
-					}
-					description.WriteString(fmt.Sprintf("`%s`", sig))
-				}
-				description.WriteString("\n")
+func (d *DiscordBotIntegration) handleCompareCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+
+	if err != nil {
+		log.Printf("Error acknowledging interaction: %v", err)
+		return
+	}
+
+	if d.neoprotectAPI == nil {
+		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: prefix("⚠️") + "NeoProtect API client is not configured for this bot.",
+		})
+		if err != nil {
+			return
+		}
+		return
+	}
+
+	var firstID, secondID string
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "id1":
+			firstID = strings.TrimSpace(opt.StringValue())
+		case "id2":
+			secondID = strings.TrimSpace(opt.StringValue())
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var attacks [2]*neoprotect.Attack
+	for n, attackID := range []string{firstID, secondID} {
+		attack, err := d.lookupAttack(ctx, attackID)
+		if err != nil {
+			content := fmt.Sprintf(prefix("❌")+"Failed to look up attack `%s`: %v", attackID, err)
+			if errors.Is(err, neoprotect.ErrAttackNotFound) {
+				content = fmt.Sprintf(prefix("❌")+"Attack `%s` was not found.", attackID)
 			}
 
-			description.WriteString("\n")
+			_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+				Content: content,
+			})
+			if err != nil {
+				return
+			}
+			return
 		}
+		attacks[n] = attack
 	}
 
 	embed := &discordgo.MessageEmbed{
-		Title:       "NeoProtect Attack History",
-		Description: description.String(),
+		Title:       "Attack Comparison",
+		Description: d.formatComparisonSummary(attacks[0], attacks[1]),
 		Color:       0x3498DB,
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   boldPrefix("🔍") + "Attack A",
+				Value:  d.formatComparisonColumn(ctx, attacks[0]),
+				Inline: true,
+			},
+			{
+				Name:   boldPrefix("🔍") + "Attack B",
+				Value:  d.formatComparisonColumn(ctx, attacks[1]),
+				Inline: true,
+			},
+		},
 		Footer: &discordgo.MessageEmbedFooter{
-			Text:    fmt.Sprintf("Showing %d most recent attacks", len(allAttacks)),
+			Text:    "NeoProtect Monitor Bot",
 			IconURL: "https://cms.mscode.pl/uploads/icon_blue_84fa10dde8.png",
 		},
 		Timestamp: time.Now().Format(time.RFC3339),
 	}
 
+	if signatures := formatSignatureComparison(attacks[0], attacks[1]); signatures != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   boldPrefix("🔎") + "Signatures",
+			Value:  signatures,
+			Inline: false,
+		})
+	}
+
 	_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
 		Embeds: []*discordgo.MessageEmbed{embed},
 	})
@@ -531,7 +1008,123 @@ func (d *DiscordBotIntegration) handleHistoryCommand(s *discordgo.Session, i *di
 	}
 }
 
-func (d *DiscordBotIntegration) handleStatsCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+// lookupAttack finds an attack by ID, preferring the monitor's own attacks over walking the account's attack listing
+func (d *DiscordBotIntegration) lookupAttack(ctx context.Context, attackID string) (*neoprotect.Attack, error) {
+	if d.resendSource != nil {
+		if attack, exists := d.resendSource.knownAttacks.Get(attackID); exists {
+			return attack, nil
+		}
+	}
+	return d.neoprotectAPI.FindAttack(ctx, attackID)
+}
+
+// formatComparisonSummary states how the second attack compares to the first
+func (d *DiscordBotIntegration) formatComparisonSummary(first, second *neoprotect.Attack) string {
+	var summary strings.Builder
+
+	summary.WriteString(fmt.Sprintf("Attack B compared to attack A:\n"+
+		"**Peak Bandwidth:** %+d%%\n**Peak Packet Rate:** %+d%%\n",
+		calculatePercentageChange(first.GetPeakBPS(), second.GetPeakBPS()),
+		calculatePercentageChange(first.GetPeakPPS(), second.GetPeakPPS())))
+
+	if first.StartedAt != nil && second.StartedAt != nil {
+		firstDuration, secondDuration := first.Duration(), second.Duration()
+		comparison := "as long"
+		if secondDuration > firstDuration {
+			comparison = formatDurationReadable(secondDuration-firstDuration) + " longer"
+		} else if secondDuration < firstDuration {
+			comparison = formatDurationReadable(firstDuration-secondDuration) + " shorter"
+		}
+		summary.WriteString(fmt.Sprintf("**Duration:** %s\n", comparison))
+	}
+
+	return summary.String()
+}
+
+// formatComparisonColumn renders one attack of /compare, including its source stats when they are available
+func (d *DiscordBotIntegration) formatComparisonColumn(ctx context.Context, attack *neoprotect.Attack) string {
+	var column strings.Builder
+
+	column.WriteString(fmt.Sprintf("**ID:** `%s`\n**Target:** `%s`\n", displayAttackID(attack.ID), attack.DstAddressString))
+	if attack.StartedAt != nil {
+		column.WriteString(fmt.Sprintf("**Started:** %s\n", formatTimeToLocal(attack.StartedAt)))
+		duration := formatDurationReadable(attack.Duration())
+		if attack.IsActive() {
+			duration += " (ongoing)"
+		}
+		column.WriteString(fmt.Sprintf("**Duration:** %s\n", duration))
+	}
+	note := samplingNote(attack.SampleRate)
+	column.WriteString(fmt.Sprintf("**Peak Bandwidth:** %s%s\n**Peak Packet Rate:** %s%s\n",
+		formatBPS(displayedPeakBPS(attack)), note, formatPPS(displayedPeakPPS(attack)), note))
+	column.WriteString(fmt.Sprintf("**Signatures:** %d\n", len(attack.Signatures)))
+
+	stats, err := d.neoprotectAPI.GetAttackStats(ctx, attack.ID)
+	if err != nil {
+		log.Printf("Error fetching stats of attack %s for comparison: %v", attack.ID, err)
+		column.WriteString("*Source statistics unavailable*")
+		return column.String()
+	}
+
+	column.WriteString(fmt.Sprintf("**Source IPs:** %s\n**Source ASNs:** %s\n**Source Countries:** %s\n",
+		formatCount(stats.SourceIpsTotal), formatCount(stats.SourceAsnsTotal), formatCount(stats.SourceCountriesTotal)))
+
+	if countries, err := stats.TopSourceCountries(compareTopCountries); err == nil && len(countries) > 0 {
+		column.WriteString(fmt.Sprintf("**Top Countries:** %s\n", strings.Join(countries, ", ")))
+	}
+
+	return column.String()
+}
+
+// formatSignatureComparison lists the signatures both attacks share and those seen in only one of them
+func formatSignatureComparison(first, second *neoprotect.Attack) string {
+	firstNames := make(map[string]bool)
+	for _, name := range first.GetSignatureNames() {
+		firstNames[name] = true
+	}
+	secondNames := make(map[string]bool)
+	for _, name := range second.GetSignatureNames() {
+		secondNames[name] = true
+	}
+
+	var shared, onlyFirst, onlySecond []string
+	for name := range firstNames {
+		if secondNames[name] {
+			shared = append(shared, name)
+		} else {
+			onlyFirst = append(onlyFirst, name)
+		}
+	}
+	for name := range secondNames {
+		if !firstNames[name] {
+			onlySecond = append(onlySecond, name)
+		}
+	}
+
+	var builder strings.Builder
+	for _, group := range []struct {
+		label string
+		names []string
+	}{
+		{"Both", shared},
+		{"Only A", onlyFirst},
+		{"Only B", onlySecond},
+	} {
+		if len(group.names) == 0 {
+			continue
+		}
+		sort.Strings(group.names)
+		builder.WriteString(fmt.Sprintf("**%s:** %s\n", group.label, strings.Join(group.names, ", ")))
+	}
+
+	value := builder.String()
+	if len(value) > 1024 {
+		value = value[:1021] + "…"
+	}
+	return value
+}
+
+func (d *DiscordBotIntegration) handleHistoryCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
 	})
@@ -542,9 +1135,8 @@ func (d *DiscordBotIntegration) handleStatsCommand(s *discordgo.Session, i *disc
 	}
 
 	if d.neoprotectAPI == nil {
-		log.Printf("Error: NeoProtect API client is nil in handleStatsCommand")
 		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-			Content: "⚠️ NeoProtect API client is not available. Please check your configuration.",
+			Content: prefix("⚠️") + "NeoProtect API client is not configured for this bot.",
 		})
 		if err != nil {
 			return
@@ -554,10 +1146,15 @@ func (d *DiscordBotIntegration) handleStatsCommand(s *discordgo.Session, i *disc
 
 	options := i.ApplicationCommandData().Options
 
-	var targetIP string
+	limit := 5
 	for _, opt := range options {
-		if opt.Name == "ip" {
-			targetIP = opt.StringValue()
+		if opt.Name == "limit" {
+			limit = int(opt.IntValue())
+			if limit < 1 {
+				limit = 1
+			} else if limit > 20 {
+				limit = 20
+			}
 			break
 		}
 	}
@@ -568,7 +1165,7 @@ func (d *DiscordBotIntegration) handleStatsCommand(s *discordgo.Session, i *disc
 	ipAddresses, err := d.neoprotectAPI.GetIPAddresses(ctx)
 	if err != nil {
 		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-			Content: fmt.Sprintf("❌ Failed to fetch IP addresses: %v", err),
+			Content: fmt.Sprintf(prefix("❌")+"Failed to fetch IP addresses: %v", err),
 		})
 		if err != nil {
 			return
@@ -576,320 +1173,1845 @@ func (d *DiscordBotIntegration) handleStatsCommand(s *discordgo.Session, i *disc
 		return
 	}
 
-	if targetIP == "" {
-		var description strings.Builder
+	var allAttacks []*neoprotect.Attack
+	moreAvailable := false
+	for ipIndex, ip := range ipAddresses {
+		if ip == nil || ip.IPv4 == "" {
+			continue
+		}
 
-		for _, ip := range ipAddresses {
-			if ip == nil || ip.IPv4 == "" {
-				continue
+		maxPages := 5
+		exhausted := false
+		for page := 0; page < maxPages; page++ {
+			attacks, err := d.neoprotectAPI.GetAttacks(ctx, ip.IPv4, page)
+			if err != nil {
+				// The pages after a failed one were never seen, so more attacks may exist
+				log.Printf("Warning: Failed to fetch attacks for IP %s, page %d: %v", ip.IPv4, page, err)
+				break
 			}
 
-			var status string
-			panelLink := fmt.Sprintf("https://panel.neoprotect.net/network/ips/%s?tab=attacks", ip.IPv4)
+			if len(attacks) == 0 {
+				exhausted = true
+				break
+			}
 
-			attack, err := d.neoprotectAPI.GetActiveAttack(ctx, ip.IPv4)
-			if err != nil {
-				if errors.Is(err, neoprotect.ErrNoActiveAttack) {
-					status = "✅ No active attack"
-				} else {
-					status = fmt.Sprintf("❓ Error checking status: %v", err)
+			for _, attack := range attacks {
+				if attack != nil {
+					allAttacks = append(allAttacks, attack)
 				}
-			} else if attack != nil && attack.StartedAt != nil {
-				status = fmt.Sprintf("`🚨` Under attack since %s", formatTimeToLocal(attack.StartedAt))
-			} else {
-				status = "✅ No active attack"
 			}
 
-			description.WriteString(fmt.Sprintf("**IP:** `%s` | **Status:** %s | [View in Panel](%s)\n\n", ip.IPv4, status, panelLink))
-		}
-
-		if description.Len() == 0 {
-			description.WriteString("No IP addresses found in your account.")
-		}
-
-		embed := &discordgo.MessageEmbed{
-			Title:       "NeoProtect Protection Status",
-			Description: description.String(),
-			Color:       0x3498DB,
-			Footer: &discordgo.MessageEmbedFooter{
-				Text:    "Use /stats ip:<ip-address> for detailed statistics",
-				IconURL: "https://cms.mscode.pl/uploads/icon_blue_84fa10dde8.png",
-			},
-			Timestamp: time.Now().Format(time.RFC3339),
+			if len(allAttacks) >= limit*3 {
+				break
+			}
 		}
 
-		_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-			Embeds: []*discordgo.MessageEmbed{embed},
-		})
-		if err != nil {
-			log.Printf("Error sending followup message: %v", err)
+		if !exhausted {
+			moreAvailable = true
 		}
 
-		return
-	} else {
-		ipPattern := regexp.MustCompile(`^(\d{1,3}\.){3}\d{1,3}$`)
-		if !ipPattern.MatchString(targetIP) {
-			_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-				Content: "❌ Invalid IP address format. Please use dotted decimal notation (e.g., 192.168.1.1).",
-			})
-			if err != nil {
-				return
+		if len(allAttacks) >= limit*2 {
+			if ipIndex < len(ipAddresses)-1 {
+				moreAvailable = true
 			}
-			return
+			break
 		}
+	}
 
-		ipExists := false
-		for _, ip := range ipAddresses {
-			if ip != nil && ip.IPv4 == targetIP {
-				ipExists = true
-				break
-			}
-		}
+	totalFetched := len(allAttacks)
 
-		if !ipExists {
-			_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-				Content: fmt.Sprintf("❌ IP address `%s` was not found in your NeoProtect account.", targetIP),
-			})
-			if err != nil {
-				log.Printf("Error sending IP not found message: %v", err)
-			}
-			return
+	sort.Slice(allAttacks, func(i, j int) bool {
+		if allAttacks[i].StartedAt == nil {
+			return false
+		}
+		if allAttacks[j].StartedAt == nil {
+			return true
 		}
+		return allAttacks[i].StartedAt.After(*allAttacks[j].StartedAt)
+	})
 
-		var attack *neoprotect.Attack
-		notFoundError := false
+	if len(allAttacks) > limit {
+		allAttacks = allAttacks[:limit]
+	}
 
-		attack, err = d.neoprotectAPI.GetActiveAttack(ctx, targetIP)
-		if err != nil {
-			if errors.Is(err, neoprotect.ErrNoActiveAttack) {
-				notFoundError = true
-				log.Printf("No active attack for IP %s", targetIP)
-			} else if strings.Contains(err.Error(), "status code 404") {
-				_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-					Content: fmt.Sprintf("❌ IP address `%s` was not found in the NeoProtect system.", targetIP),
-				})
-				if err != nil {
-					log.Printf("Error sending IP not found message: %v", err)
-				}
-				return
-			} else {
-				_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-					Content: fmt.Sprintf("❌ Failed to check attack status: %v", err),
-				})
-				if err != nil {
-					return
-				}
-				return
-			}
-		}
+	var description strings.Builder
+	description.WriteString(fmt.Sprintf("## Recent Attack History\n\n"))
 
-		var attacks []*neoprotect.Attack
-		maxPages := 20
+	if len(allAttacks) == 0 {
+		description.WriteString("No attack history found.")
+	} else {
+		for i, attack := range allAttacks {
+			status := prefix("✅") + "Ended"
+			started := "unknown start"
+			duration := "unknown"
+			panelLink := panelLink(attack.DstAddressString)
 
-		for page := 0; page < maxPages; page++ {
-			pageAttacks, err := d.neoprotectAPI.GetAttacks(ctx, targetIP, page)
-			if err != nil {
-				if strings.Contains(err.Error(), "status code 404") {
-					log.Printf("Error: IP %s not found when fetching attack history", targetIP)
-					break
-				} else {
-					log.Printf("Error fetching attack history for IP %s, page %d: %v", targetIP, page, err)
-					break
-				}
+			if attack.StartedAt != nil {
+				started = formatTimeToLocal(attack.StartedAt)
+				duration = formatDurationReadable(attack.Duration())
 			}
 
-			if len(pageAttacks) == 0 {
-				break
+			if attack.EndedAt == nil {
+				status = codePrefix("🚨") + "Active"
+				duration = fmt.Sprintf("%s (ongoing)", duration)
 			}
 
-			attacks = append(attacks, pageAttacks...)
+			description.WriteString(fmt.Sprintf("### %d. Attack on %s\n", i+1, attack.DstAddressString))
+			description.WriteString(fmt.Sprintf("**ID:** `%s`\n", displayAttackID(attack.ID)))
+			description.WriteString(fmt.Sprintf("**Started:** %s\n", started))
+			description.WriteString(fmt.Sprintf("**Status:** %s\n", status))
+			description.WriteString(fmt.Sprintf("**Duration:** %s\n", duration))
+			description.WriteString(fmt.Sprintf("**Peak:** %s / %s%s\n",
+				formatBPS(displayedPeakBPS(attack)),
+				formatPPS(displayedPeakPPS(attack)),
+				samplingNote(attack.SampleRate)))
+			description.WriteString(fmt.Sprintf("**Panel:** [View Details](%s)\n", panelLink))
 
-			if len(attacks) >= 100 {
-				log.Printf("Collected 100 attack records for IP %s, stopping pagination", targetIP)
-				break
+			signatures := attack.GetSignatureNames()
+			if len(signatures) > 0 {
+				description.WriteString("**Signatures:** ")
+				for j, sig := range signatures {
+					if j > 0 {
+						description.WriteString(", ")
+					}
+					description.WriteString(fmt.Sprintf("`%s`", sig))
+				}
+				description.WriteString("\n")
 			}
-		}
-
-		panelLink := fmt.Sprintf("https://panel.neoprotect.net/network/ips/%s?tab=attacks", targetIP)
-
-		var description strings.Builder
-		description.WriteString(fmt.Sprintf("## Statistics for IP: `%s`\n\n", targetIP))
-		description.WriteString(fmt.Sprintf("**`🔗`** [View in NeoProtect Panel](%s)\n\n", panelLink))
-
-		if attack != nil && !notFoundError && attack.StartedAt != nil {
-			description.WriteString("**`🚨`** Current Status: Under Attack\n")
-			description.WriteString(fmt.Sprintf("**Attack Start:** %s\n", formatTimeToLocal(attack.StartedAt)))
-			description.WriteString(fmt.Sprintf("**Duration:** %s\n", formatDurationReadable(attack.Duration())))
-			description.WriteString(fmt.Sprintf("**Peak Bandwidth:** %s\n", formatBPS(attack.GetPeakBPS())))
-			description.WriteString(fmt.Sprintf("**Peak Packet Rate:** %s\n", formatPPS(attack.GetPeakPPS())))
-		} else {
-			description.WriteString("**`✅`** Current Status: No Active Attack\n")
-		}
 
-		attackCount := len(attacks)
-		totalMessage := fmt.Sprintf("%d (showing latest %d)", attackCount, attackCount)
-		if attackCount >= 100 {
-			totalMessage = fmt.Sprintf("%d+ (showing latest %d, see panel for full history)", attackCount, attackCount)
+			description.WriteString("\n")
 		}
+	}
 
-		description.WriteString(fmt.Sprintf("\n## Attack History\n\n"))
-		description.WriteString(fmt.Sprintf("**Total Attacks:** %s\n", totalMessage))
-
-		var totalDuration time.Duration
-		var peakBPS int64
-		var peakPPS int64
+	embed := &discordgo.MessageEmbed{
+		Title:       "NeoProtect Attack History",
+		Description: description.String(),
+		Color:       0x3498DB,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text:    historyFooterText(len(allAttacks), totalFetched, moreAvailable),
+			IconURL: "https://cms.mscode.pl/uploads/icon_blue_84fa10dde8.png",
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
 
-		for _, a := range attacks {
-			if a == nil {
+	_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Embeds: []*discordgo.MessageEmbed{embed},
+	})
+	if err != nil {
+		log.Printf("Error sending followup message: %v", err)
+	}
+}
+
+func historyFooterText(shown, total int, moreAvailable bool) string {
+	if moreAvailable {
+		return fmt.Sprintf("Showing %d of %d+ attacks · more exist, use a larger limit or the panel to see them", shown, total)
+	}
+	if shown < total {
+		return fmt.Sprintf("Showing %d of %d attacks · use a larger limit to see more", shown, total)
+	}
+	return fmt.Sprintf("Showing %d of %d attacks", shown, total)
+}
+
+// exportHistoryBudget bounds the time /export-history spends walking the history pages of an IP
+const exportHistoryBudget = 2 * time.Minute
+
+func (d *DiscordBotIntegration) handleExportHistoryCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+
+	if err != nil {
+		log.Printf("Error acknowledging interaction: %v", err)
+		return
+	}
+
+	if d.neoprotectAPI == nil {
+		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: prefix("⚠️") + "NeoProtect API client is not configured for this bot.",
+		})
+		if err != nil {
+			return
+		}
+		return
+	}
+
+	var targetIP string
+	format := "csv"
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "ip":
+			targetIP = strings.TrimSpace(opt.StringValue())
+		case "format":
+			format = opt.StringValue()
+		}
+	}
+
+	if net.ParseIP(targetIP) == nil {
+		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: fmt.Sprintf(prefix("❌")+"`%s` is not a valid IP address.", targetIP),
+		})
+		if err != nil {
+			log.Printf("Error sending followup message: %v", err)
+		}
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportHistoryBudget)
+	defer cancel()
+
+	attacks, complete := d.fullAttackHistory(ctx, targetIP)
+	if len(attacks) == 0 && !complete {
+		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: fmt.Sprintf(prefix("❌")+"Failed to fetch the attack history of %s.", targetIP),
+		})
+		if err != nil {
+			log.Printf("Error sending followup message: %v", err)
+		}
+		return
+	}
+
+	sort.Slice(attacks, func(i, j int) bool {
+		if attacks[i].StartedAt == nil {
+			return false
+		}
+		if attacks[j].StartedAt == nil {
+			return true
+		}
+		return attacks[i].StartedAt.After(*attacks[j].StartedAt)
+	})
+
+	var data []byte
+	contentType := "text/csv"
+	if format == "json" {
+		contentType = "application/json"
+		data, err = json.MarshalIndent(attacks, "", "  ")
+	} else {
+		format = "csv"
+		data, err = attackHistoryCSV(attacks)
+	}
+	if err != nil {
+		log.Printf("Error encoding attack history of %s: %v", targetIP, err)
+		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: prefix("❌") + "Failed to encode the attack history.",
+		})
+		if err != nil {
+			log.Printf("Error sending followup message: %v", err)
+		}
+		return
+	}
+
+	content := fmt.Sprintf("Attack history of %s: %d attack(s).", targetIP, len(attacks))
+	if !complete {
+		content += " The history could not be fetched completely, older attacks may be missing."
+	}
+
+	_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Content: content,
+		Files: []*discordgo.File{
+			{
+				Name:        fmt.Sprintf("attacks-%s.%s", strings.ReplaceAll(targetIP, ":", "_"), format),
+				ContentType: contentType,
+				Reader:      bytes.NewReader(data),
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("Error sending followup message: %v", err)
+	}
+}
+
+// fullAttackHistory walks the history pages of an IP, up to neoprotect.MaxAttackPages, until the pages run out or
+// ctx expires. complete is false when a page failed, the time ran out or the page limit was reached, the attacks
+// fetched before are returned either way, without attacks repeated across pages.
+func (d *DiscordBotIntegration) fullAttackHistory(ctx context.Context, targetIP string) (attacks []*neoprotect.Attack, complete bool) {
+	for page := 0; page <= neoprotect.MaxAttackPages; page++ {
+		pageAttacks, err := d.neoprotectAPI.GetAttacks(ctx, targetIP, page)
+		if err != nil {
+			log.Printf("Error fetching attack history for IP %s, page %d: %v", targetIP, page, err)
+			return neoprotect.DedupeAttacks(attacks), false
+		}
+
+		if len(pageAttacks) == 0 {
+			return neoprotect.DedupeAttacks(attacks), true
+		}
+
+		for _, attack := range pageAttacks {
+			if attack != nil {
+				attacks = append(attacks, attack)
+			}
+		}
+	}
+
+	log.Printf("Warning: Reached maximum page limit (%d) when fetching the attack history of IP %s", neoprotect.MaxAttackPages, targetIP)
+	return neoprotect.DedupeAttacks(attacks), false
+}
+
+// attackHistoryCSV encodes attacks as CSV with one row per attack
+func attackHistoryCSV(attacks []*neoprotect.Attack) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	if err := w.Write([]string{"id", "ip", "startedAt", "endedAt", "durationSeconds", "peakBps", "peakPps", "sampleRate", "signatures"}); err != nil {
+		return nil, err
+	}
+
+	for _, attack := range attacks {
+		var started, ended string
+		if attack.StartedAt != nil {
+			started = attack.StartedAt.UTC().Format(time.RFC3339)
+		}
+		if attack.EndedAt != nil {
+			ended = attack.EndedAt.UTC().Format(time.RFC3339)
+		}
+
+		record := []string{
+			attack.ID,
+			attack.DstAddressString,
+			started,
+			ended,
+			fmt.Sprintf("%.0f", attack.Duration().Seconds()),
+			fmt.Sprintf("%d", attack.GetPeakBPS()),
+			fmt.Sprintf("%d", attack.GetPeakPPS()),
+			fmt.Sprintf("%d", attack.SampleRate),
+			strings.Join(attack.GetSignatureNames(), ";"),
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (d *DiscordBotIntegration) handleStatsCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+
+	if err != nil {
+		log.Printf("Error acknowledging interaction: %v", err)
+		return
+	}
+
+	if d.neoprotectAPI == nil {
+		log.Printf("Error: NeoProtect API client is nil in handleStatsCommand")
+		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: prefix("⚠️") + "NeoProtect API client is not available. Please check your configuration.",
+		})
+		if err != nil {
+			return
+		}
+		return
+	}
+
+	options := i.ApplicationCommandData().Options
+
+	var targetIP, group string
+	for _, opt := range options {
+		switch opt.Name {
+		case "ip":
+			targetIP = opt.StringValue()
+		case "group":
+			group = opt.StringValue()
+		}
+	}
+
+	if !d.checkGroup(s, i, group) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if targetIP == "" {
+		ipAddresses, err := d.neoprotectAPI.GetIPAddresses(ctx)
+		if err != nil {
+			_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+				Content: fmt.Sprintf(prefix("❌")+"Failed to fetch IP addresses: %v", err),
+			})
+			if err != nil {
+				return
+			}
+			return
+		}
+
+		var description strings.Builder
+
+		for _, ip := range ipAddresses {
+			if ip == nil || ip.IPv4 == "" || !d.inGroup(group, ip.IPv4) {
 				continue
 			}
 
-			if a.StartedAt != nil && a.EndedAt != nil {
-				totalDuration += a.Duration()
+			var status string
+			panelLink := panelLink(ip.IPv4)
+
+			attack, err := d.neoprotectAPI.GetActiveAttack(ctx, ip.IPv4)
+			if err != nil {
+				if errors.Is(err, neoprotect.ErrNoActiveAttack) {
+					status = prefix("✅") + "No active attack"
+				} else {
+					status = fmt.Sprintf(prefix("❓")+"Error checking status: %v", err)
+				}
+			} else if attack != nil && attack.StartedAt != nil {
+				status = fmt.Sprintf(codePrefix("🚨")+"Under attack since %s", formatTimeToLocal(attack.StartedAt))
+			} else if attack != nil {
+				status = codePrefix("🚨") + "Under attack (unknown start)"
+			} else {
+				status = prefix("✅") + "No active attack"
+			}
+
+			description.WriteString(fmt.Sprintf("**IP:** `%s` | **Status:** %s | [View in Panel](%s)\n\n", ip.IPv4, status, panelLink))
+		}
+
+		title := "NeoProtect Protection Status"
+		if group != "" {
+			title += " · " + group
+		}
+
+		if description.Len() == 0 && group != "" {
+			description.WriteString(fmt.Sprintf("No IP addresses of group `%s` found in your account.", group))
+		} else if description.Len() == 0 {
+			description.WriteString("No IP addresses found in your account.")
+		}
+
+		embed := &discordgo.MessageEmbed{
+			Title:       title,
+			Description: description.String(),
+			Color:       0x3498DB,
+			Footer: &discordgo.MessageEmbedFooter{
+				Text:    "Use /stats ip:<ip-address> for detailed statistics",
+				IconURL: "https://cms.mscode.pl/uploads/icon_blue_84fa10dde8.png",
+			},
+			Timestamp: time.Now().Format(time.RFC3339),
+		}
+
+		_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Embeds: []*discordgo.MessageEmbed{embed},
+		})
+		if err != nil {
+			log.Printf("Error sending followup message: %v", err)
+		}
+
+		return
+	} else {
+		ipPattern := regexp.MustCompile(`^(\d{1,3}\.){3}\d{1,3}$`)
+		if !ipPattern.MatchString(targetIP) {
+			_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+				Content: prefix("❌") + "Invalid IP address format. Please use dotted decimal notation (e.g., 192.168.1.1).",
+			})
+			if err != nil {
+				return
+			}
+			return
+		}
+
+		ipAddress, err := d.neoprotectAPI.GetIPAddress(ctx, targetIP)
+		if errors.Is(err, neoprotect.ErrIPNotFound) {
+			_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+				Content: fmt.Sprintf(prefix("❌")+"IP address `%s` was not found in your NeoProtect account.", targetIP),
+			})
+			if err != nil {
+				log.Printf("Error sending IP not found message: %v", err)
+			}
+			return
+		} else if err != nil {
+			_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+				Content: fmt.Sprintf(prefix("❌")+"Failed to fetch IP address: %v", err),
+			})
+			if err != nil {
+				return
+			}
+			return
+		}
+
+		attack, err := d.neoprotectAPI.GetActiveAttack(ctx, targetIP)
+		if err != nil {
+			if errors.Is(err, neoprotect.ErrNoActiveAttack) {
+				log.Printf("No active attack for IP %s", targetIP)
+			} else if strings.Contains(err.Error(), "status code 404") {
+				_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+					Content: fmt.Sprintf(prefix("❌")+"IP address `%s` was not found in the NeoProtect system.", targetIP),
+				})
+				if err != nil {
+					log.Printf("Error sending IP not found message: %v", err)
+				}
+				return
+			} else {
+				_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+					Content: fmt.Sprintf(prefix("❌")+"Failed to check attack status: %v", err),
+				})
+				if err != nil {
+					return
+				}
+				return
+			}
+		} else if attack == nil || attack.ID == "" {
+			log.Printf("Active attack lookup for IP %s returned no data", targetIP)
+		}
+
+		var stats *neoprotect.AttackStats
+		if attack != nil && attack.ID != "" {
+			if stats, err = d.neoprotectAPI.GetAttackStats(ctx, attack.ID); err != nil {
+				log.Printf("Warning: Failed to fetch stats for attack %s: %v", attack.ID, err)
+			}
+		}
+
+		attacks := d.statsAttackHistory(ctx, targetIP)
+
+		panelLink := panelLink(targetIP)
+
+		var description strings.Builder
+		description.WriteString(fmt.Sprintf("## Statistics for IP: `%s`\n\n", targetIP))
+		description.WriteString(fmt.Sprintf(boldPrefix("🔗")+"[View in NeoProtect Panel](%s)\n\n", panelLink))
+
+		if ipAddress.Settings != nil {
+			autoMitigation := "Off"
+			if ipAddress.Settings.AutoMitigation {
+				autoMitigation = "On"
+			}
+			description.WriteString(fmt.Sprintf("**Auto-Mitigation:** %s\n\n", autoMitigation))
+		}
+
+		description.WriteString(d.statsAttackStatus(attack, stats))
+
+		attackCount := len(attacks)
+		totalMessage := fmt.Sprintf("%d (showing latest %d)", attackCount, attackCount)
+		if attackCount >= 100 {
+			totalMessage = fmt.Sprintf("%d+ (showing latest %d, see panel for full history)", attackCount, attackCount)
+		}
+
+		description.WriteString(fmt.Sprintf("\n## Attack History\n\n"))
+		description.WriteString(fmt.Sprintf("**Total Attacks:** %s\n", totalMessage))
+
+		var totalDuration time.Duration
+		var peakBPS, peakPPS int64
+		var peakBPSSampleRate, peakPPSSampleRate int64
+
+		for _, a := range attacks {
+			if a == nil {
+				continue
+			}
+
+			if a.StartedAt != nil && a.EndedAt != nil {
+				totalDuration += a.Duration()
+			}
+
+			if displayedPeakBPS(a) > peakBPS {
+				peakBPS = displayedPeakBPS(a)
+				peakBPSSampleRate = a.SampleRate
+			}
+
+			if displayedPeakPPS(a) > peakPPS {
+				peakPPS = displayedPeakPPS(a)
+				peakPPSSampleRate = a.SampleRate
+			}
+		}
+
+		description.WriteString(fmt.Sprintf("**Total Attack Time:** %s\n", formatDurationReadable(totalDuration)))
+		description.WriteString(fmt.Sprintf("**All-Time Peak Bandwidth:** %s%s\n", formatBPS(peakBPS), samplingNote(peakBPSSampleRate)))
+		description.WriteString(fmt.Sprintf("**All-Time Peak Packet Rate:** %s%s\n", formatPPS(peakPPS), samplingNote(peakPPSSampleRate)))
+
+		embed := &discordgo.MessageEmbed{
+			Title:       "NeoProtect IP Statistics",
+			Description: description.String(),
+			Color:       0x3498DB,
+			URL:         panelLink,
+			Footer: &discordgo.MessageEmbedFooter{
+				Text:    "Use /history for detailed attack history",
+				IconURL: "https://cms.mscode.pl/uploads/icon_blue_84fa10dde8.png",
+			},
+			Timestamp: time.Now().Format(time.RFC3339),
+		}
+
+		_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Embeds: []*discordgo.MessageEmbed{embed},
+		})
+		if err != nil {
+			log.Printf("Error sending followup message: %v", err)
+		}
+	}
+}
+
+// statsAttackStatus renders the current status of /stats from the result of GetActiveAttack, which may be nil or
+// empty when the IP is not under attack, and the stats of the attack, nil when they could not be fetched
+func (d *DiscordBotIntegration) statsAttackStatus(attack *neoprotect.Attack, stats *neoprotect.AttackStats) string {
+	if attack == nil || attack.ID == "" {
+		return boldPrefix("✅") + "Current Status: No Active Attack\n"
+	}
+
+	var description strings.Builder
+	description.WriteString(boldPrefix("🚨") + "Current Status: Under Attack\n")
+	description.WriteString(fmt.Sprintf("**Attack Start:** %s\n", formatTimeToLocal(attack.StartedAt)))
+	if attack.StartedAt != nil {
+		description.WriteString(fmt.Sprintf("**Duration:** %s\n", formatDurationReadable(attack.Duration())))
+	}
+	if len(attack.Signatures) == 0 {
+		description.WriteString("**Traffic:** No data available yet\n")
+	} else {
+		note := samplingNote(attack.SampleRate)
+		description.WriteString(fmt.Sprintf("**Peak Bandwidth:** %s%s\n", formatBPS(displayedPeakBPS(attack)), note))
+		description.WriteString(fmt.Sprintf("**Peak Packet Rate:** %s%s\n", formatPPS(displayedPeakPPS(attack)), note))
+	}
+
+	if stats == nil {
+		return description.String()
+	}
+
+	mix, err := stats.ProtocolMix(ProtocolMixLimit)
+	if err != nil {
+		log.Printf("Warning: Failed to read protocols for attack %s: %v", attack.ID, err)
+	} else if len(mix) > 0 {
+		description.WriteString(fmt.Sprintf("**Protocol Mix:** %s\n", formatProtocolMix(mix)))
+	}
+
+	if limit := d.targetPortsLimit(); limit > 0 {
+		if ports, err := stats.TopDestinationPorts(limit); err != nil {
+			log.Printf("Warning: Failed to read destination ports for attack %s: %v", attack.ID, err)
+		} else if len(ports) > 0 {
+			description.WriteString(fmt.Sprintf("**Targeted Ports:** %s\n", formatTargetPorts(ports, mix)))
+		}
+	}
+
+	if shares, err := stats.SourcePortShares(); err != nil {
+		log.Printf("Warning: Failed to read source ports for attack %s: %v", attack.ID, err)
+	} else if len(shares) > 0 {
+		description.WriteString(fmt.Sprintf("**Source Ports:** %s\n", formatSourcePorts(shares, sourcePortsLimit)))
+		description.WriteString(fmt.Sprintf("**Source Port Spread:** %s\n", sourcePortSpread(shares)))
+	}
+
+	return description.String()
+}
+
+// statsAttackHistory returns up to 100 recent attacks on the IP, reusing a history fetched within the cache TTL
+func (d *DiscordBotIntegration) statsAttackHistory(ctx context.Context, targetIP string) []*neoprotect.Attack {
+	if attacks, ok := d.historyCache.Get(targetIP); ok {
+		return attacks
+	}
+
+	var attacks []*neoprotect.Attack
+	maxPages := 20
+	complete := true
+
+	for page := 0; page < maxPages; page++ {
+		pageAttacks, err := d.neoprotectAPI.GetAttacks(ctx, targetIP, page)
+		if err != nil {
+			complete = false
+			if strings.Contains(err.Error(), "status code 404") {
+				log.Printf("Error: IP %s not found when fetching attack history", targetIP)
+				break
+			} else {
+				log.Printf("Error fetching attack history for IP %s, page %d: %v", targetIP, page, err)
+				break
+			}
+		}
+
+		if len(pageAttacks) == 0 {
+			break
+		}
+
+		attacks = append(attacks, pageAttacks...)
+
+		if len(attacks) >= 100 {
+			log.Printf("Collected 100 attack records for IP %s, stopping pagination", targetIP)
+			break
+		}
+	}
+
+	if complete {
+		d.historyCache.Set(targetIP, attacks)
+	}
+
+	return attacks
+}
+
+// activeAttacks returns the active attacks known to the monitor
+func (d *DiscordBotIntegration) activeAttacks() []*neoprotect.Attack {
+	if d.resendSource == nil {
+		return nil
+	}
+
+	var active []*neoprotect.Attack
+	for _, attack := range d.resendSource.knownAttacks.All() {
+		if attack.IsActive() {
+			active = append(active, attack)
+		}
+	}
+	return active
+}
+
+func (d *DiscordBotIntegration) handleHealthCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+
+	if err != nil {
+		log.Printf("Error acknowledging interaction: %v", err)
+		return
+	}
+
+	var description strings.Builder
+	color := 0x00FF00
+
+	description.WriteString("### Monitor\n")
+	if d.monitorStatus == nil {
+		description.WriteString(boldPrefix("❓") + "Monitor status is not available\n")
+		color = 0xFFFF00
+	} else {
+		status := d.monitorStatus.Snapshot()
+
+		description.WriteString(fmt.Sprintf(boldPrefix("⏱️")+"Uptime: %s\n", formatDurationReadable(time.Since(status.StartedAt))))
+
+		if status.LastSuccessfulPollAt.IsZero() {
+			description.WriteString(boldPrefix("⚠️") + "Last successful poll: never\n")
+			color = 0xFFFF00
+		} else {
+			description.WriteString(fmt.Sprintf(boldPrefix("✅")+"Last successful poll: %s (%s ago)\n",
+				formatTimeToLocal(&status.LastSuccessfulPollAt),
+				formatDurationReadable(time.Since(status.LastSuccessfulPollAt))))
+		}
+
+		if status.LastError != "" {
+			description.WriteString(fmt.Sprintf(boldPrefix("❌")+"Last poll error: %s\n", status.LastError))
+			color = 0xFFFF00
+		}
+
+		description.WriteString(fmt.Sprintf(boldPrefix("🚨")+"Active attacks: %d\n", status.ActiveAttacks))
+		if active := d.activeAttacks(); len(active) > 0 {
+			description.WriteString(fmt.Sprintf(boldPrefix("📊")+"Peaks: %s\n", aggregatePeaks(active).format()))
+		}
+
+		if len(status.Integrations) > 0 && d.resendSource == nil {
+			description.WriteString(fmt.Sprintf(boldPrefix("🧩")+"Integrations: %s\n", strings.Join(status.Integrations, ", ")))
+		}
+	}
+
+	if d.resendSource != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		results := d.resendSource.manager.HealthCheck(ctx)
+		cancel()
+
+		names := make([]string, 0, len(results))
+		for name := range results {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		description.WriteString("### Integrations\n")
+		for _, name := range names {
+			if err := results[name]; err != nil {
+				description.WriteString(fmt.Sprintf(boldPrefix("❌")+"%s: %v\n", name, err))
+				color = 0xFFFF00
+				continue
+			}
+			description.WriteString(fmt.Sprintf(boldPrefix("✅")+"%s\n", name))
+		}
+	}
+
+	description.WriteString("### NeoProtect API\n")
+	if d.neoprotectAPI == nil {
+		description.WriteString(boldPrefix("❓") + "API client is not configured\n")
+		color = 0xFF0000
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		start := time.Now()
+		ipAddresses, err := d.neoprotectAPI.GetIPAddresses(ctx)
+		if err != nil {
+			description.WriteString(fmt.Sprintf(boldPrefix("❌")+"Unreachable: %v\n", err))
+			color = 0xFF0000
+		} else {
+			description.WriteString(fmt.Sprintf(boldPrefix("✅")+"Reachable (%d IPs, %d ms)\n",
+				len(ipAddresses), time.Since(start).Milliseconds()))
+		}
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "NeoProtect Monitor Health",
+		Description: description.String(),
+		Color:       color,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text:    "NeoProtect Monitor Bot",
+			IconURL: "https://cms.mscode.pl/uploads/icon_blue_84fa10dde8.png",
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Embeds: []*discordgo.MessageEmbed{embed},
+	})
+	if err != nil {
+		log.Printf("Error sending followup message: %v", err)
+	}
+}
+
+// topHistoryPages bounds how much history /top walks per IP so it stays within the command time budget
+const topHistoryPages = 5
+
+func (d *DiscordBotIntegration) handleResendCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+
+	if err != nil {
+		log.Printf("Error acknowledging interaction: %v", err)
+		return
+	}
+
+	if d.resendSource == nil {
+		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: prefix("⚠️") + "Resending is not available until the monitor has started.",
+		})
+		if err != nil {
+			return
+		}
+		return
+	}
+
+	var attackID string
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Name == "id" {
+			attackID = strings.TrimSpace(opt.StringValue())
+			break
+		}
+	}
+
+	attack, exists := d.resendSource.knownAttacks.Get(attackID)
+	if !exists {
+		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: fmt.Sprintf(prefix("❌")+"Attack `%s` is not known to the monitor. Only attacks seen since startup can be re-sent.", attackID),
+		})
+		if err != nil {
+			return
+		}
+		return
+	}
+
+	log.Printf("User %s requested a resend of attack %s", interactionUsername(i), attackID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	content := fmt.Sprintf(prefix("✅")+"Re-sent the notification for attack `%s` to all integrations.", attackID)
+	if err := d.resendSource.manager.Resend(ctx, attack, d.resendSource.messageTracker); err != nil {
+		content = fmt.Sprintf(prefix("⚠️")+"Re-sent the notification for attack `%s`, but some integrations failed: %v", attackID, err)
+	}
+
+	_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Content: content,
+	})
+	if err != nil {
+		log.Printf("Error sending followup message: %v", err)
+	}
+}
+
+// maintenancePermissions hides /maintenance from members without the Manage Server permission by default
+var maintenancePermissions int64 = discordgo.PermissionManageServer
+
+// maintenanceMinMinutes is the shortest maintenance window /maintenance on accepts
+var maintenanceMinMinutes float64 = 1
+
+func (d *DiscordBotIntegration) handleMaintenanceCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+
+	if err != nil {
+		log.Printf("Error acknowledging interaction: %v", err)
+		return
+	}
+
+	if d.resendSource == nil {
+		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: prefix("⚠️") + "Maintenance mode is not available until the monitor has started.",
+		})
+		if err != nil {
+			log.Printf("Error sending followup message: %v", err)
+		}
+		return
+	}
+
+	manager := d.resendSource.manager
+	subcommand := i.ApplicationCommandData().Options[0]
+
+	var content string
+	switch subcommand.Name {
+	case "on":
+		var minutes int64
+		for _, opt := range subcommand.Options {
+			if opt.Name == "minutes" {
+				minutes = opt.IntValue()
+			}
+		}
+
+		manager.StartMaintenance(time.Duration(minutes) * time.Minute)
+		log.Printf("User %s turned maintenance mode on", interactionUsername(i))
+
+		content = prefix("🔧") + "Maintenance mode is on, notifications are suppressed until `/maintenance off`."
+		if minutes > 0 {
+			content = fmt.Sprintf(prefix("🔧")+"Maintenance mode is on, notifications are suppressed for %s.",
+				formatDurationReadable(time.Duration(minutes)*time.Minute))
+		}
+	case "off":
+		if manager.EndMaintenance() {
+			log.Printf("User %s turned maintenance mode off", interactionUsername(i))
+			content = prefix("✅") + "Maintenance mode is off, notifications are sent again."
+		} else {
+			content = prefix("ℹ️") + "Maintenance mode is not active."
+		}
+	default:
+		active, since, until := manager.MaintenanceStatus()
+		switch {
+		case !active:
+			content = prefix("ℹ️") + "Maintenance mode is not active."
+		case until.IsZero():
+			content = fmt.Sprintf(prefix("🔧")+"Maintenance mode is on since %s until turned off.", formatTimeToLocal(&since))
+		default:
+			content = fmt.Sprintf(prefix("🔧")+"Maintenance mode is on since %s until %s.", formatTimeToLocal(&since), formatTimeToLocal(&until))
+		}
+	}
+
+	_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Content: content,
+	})
+	if err != nil {
+		log.Printf("Error sending followup message: %v", err)
+	}
+}
+
+// rawPermissions hides /raw from members without the Manage Server permission by default
+var rawPermissions int64 = discordgo.PermissionManageServer
+
+// rawInlineLimit is the largest pretty-printed attack /raw shows in a code block, larger ones are attached as a file
+const rawInlineLimit = 1900
+
+func (d *DiscordBotIntegration) handleRawCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+
+	if err != nil {
+		log.Printf("Error acknowledging interaction: %v", err)
+		return
+	}
+
+	if d.neoprotectAPI == nil {
+		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: prefix("⚠️") + "NeoProtect API client is not configured for this bot.",
+		})
+		if err != nil {
+			return
+		}
+		return
+	}
+
+	var attackID, targetIP string
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "id":
+			attackID = strings.TrimSpace(opt.StringValue())
+		case "ip":
+			targetIP = strings.TrimSpace(opt.StringValue())
+		}
+	}
+
+	if (attackID == "") == (targetIP == "") {
+		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: prefix("❌") + "Please provide either an attack `id` or an `ip`.",
+		})
+		if err != nil {
+			log.Printf("Error sending followup message: %v", err)
+		}
+		return
+	}
+
+	if targetIP != "" && net.ParseIP(targetIP) == nil {
+		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: fmt.Sprintf(prefix("❌")+"`%s` is not a valid IP address.", targetIP),
+		})
+		if err != nil {
+			log.Printf("Error sending followup message: %v", err)
+		}
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var attack *neoprotect.Attack
+	var raw json.RawMessage
+	if attackID != "" {
+		attack, raw, err = d.neoprotectAPI.GetAttackRaw(ctx, attackID)
+	} else {
+		attack, raw, err = d.neoprotectAPI.GetLatestAttackRaw(ctx, targetIP)
+	}
+	if err != nil {
+		content := fmt.Sprintf(prefix("❌")+"Failed to fetch the attack: %v", err)
+		if errors.Is(err, neoprotect.ErrAttackNotFound) {
+			content = prefix("❌") + "No such attack found."
+		}
+		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: content,
+		})
+		if err != nil {
+			log.Printf("Error sending followup message: %v", err)
+		}
+		return
+	}
+
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, raw, "", "  "); err != nil {
+		pretty.Reset()
+		pretty.Write(raw)
+	}
+
+	params := &discordgo.WebhookParams{}
+	if pretty.Len() <= rawInlineLimit {
+		params.Content = fmt.Sprintf("Raw API data of attack `%s`:\n```json\n%s\n```", attack.ID, pretty.String())
+	} else {
+		params.Content = fmt.Sprintf("Raw API data of attack `%s`:", attack.ID)
+		params.Files = []*discordgo.File{
+			{
+				Name:        fmt.Sprintf("attack-%s.json", attack.ID),
+				ContentType: "application/json",
+				Reader:      bytes.NewReader(pretty.Bytes()),
+			},
+		}
+	}
+
+	_, err = s.FollowupMessageCreate(i.Interaction, true, params)
+	if err != nil {
+		log.Printf("Error sending followup message: %v", err)
+	}
+}
+
+// pollPermissions hides /poll from members without the Manage Server permission by default
+var pollPermissions int64 = discordgo.PermissionManageServer
+
+func (d *DiscordBotIntegration) handlePollCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+
+	if err != nil {
+		log.Printf("Error acknowledging interaction: %v", err)
+		return
+	}
+
+	if d.pollTrigger == nil {
+		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: prefix("⚠️") + "Polling is not available until the monitor has started.",
+		})
+		if err != nil {
+			log.Printf("Error sending followup message: %v", err)
+		}
+		return
+	}
+
+	log.Printf("User %s triggered a manual poll", interactionUsername(i))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	var content string
+	result, err := d.pollTrigger(ctx)
+	switch {
+	case err != nil:
+		content = fmt.Sprintf(prefix("❌")+"Failed to run the poll: %v", err)
+	case result.Error != "":
+		content = fmt.Sprintf(prefix("❌")+"The poll failed: %s", result.Error)
+	default:
+		content = fmt.Sprintf(prefix("✅")+"Poll finished: **%d** active attack(s), %d new, %d updated, %d ended.",
+			result.ActiveAttacks, result.New, result.Updated, result.Ended)
+	}
+
+	_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Content: content,
+	})
+	if err != nil {
+		log.Printf("Error sending followup message: %v", err)
+	}
+}
+
+// blacklistPermissions hides /blacklist from members without the Manage Server permission by default
+var blacklistPermissions int64 = discordgo.PermissionManageServer
+
+func (d *DiscordBotIntegration) handleBlacklistCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+
+	if err != nil {
+		log.Printf("Error acknowledging interaction: %v", err)
+		return
+	}
+
+	if d.blacklist == nil {
+		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: prefix("⚠️") + "The blacklist is not available until the monitor has started.",
+		})
+		if err != nil {
+			return
+		}
+		return
+	}
+
+	options := i.ApplicationCommandData().Options
+	if len(options) == 0 {
+		return
+	}
+	subcommand := options[0]
+
+	var ip string
+	for _, opt := range subcommand.Options {
+		if opt.Name == "ip" {
+			ip = strings.TrimSpace(opt.StringValue())
+			break
+		}
+	}
+	ip = canonicalIP(ip)
+
+	if subcommand.Name != "list" && net.ParseIP(ip) == nil {
+		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: fmt.Sprintf(prefix("❌")+"`%s` is not a valid IP address.", ip),
+		})
+		if err != nil {
+			return
+		}
+		return
+	}
+
+	var content string
+	switch subcommand.Name {
+	case "add":
+		added, err := d.blacklist.Add(ip)
+		switch {
+		case errors.Is(err, ErrBlacklistFileUnloaded):
+			content = fmt.Sprintf(prefix("❌")+"Could not blacklist `%s`: %v. Fix the file and restart to change the blacklist.", ip, err)
+		case err != nil:
+			content = fmt.Sprintf(prefix("⚠️")+"Blacklisted `%s` until restart, but failed to persist the blacklist: %v", ip, err)
+		case added:
+			content = fmt.Sprintf(prefix("✅")+"Blacklisted `%s`. Attacks on it are ignored from the next poll.", ip)
+		default:
+			content = fmt.Sprintf(prefix("❓")+"`%s` is already blacklisted.", ip)
+		}
+		if added {
+			log.Printf("User %s blacklisted IP %s", interactionUsername(i), ip)
+		}
+	case "remove":
+		removed, err := d.blacklist.Remove(ip)
+		switch {
+		case errors.Is(err, ErrBlacklistedInConfig):
+			content = fmt.Sprintf(prefix("❌")+"`%s` is blacklisted in the config file and can only be removed there.", ip)
+		case errors.Is(err, ErrBlacklistFileUnloaded):
+			content = fmt.Sprintf(prefix("❌")+"Could not remove `%s` from the blacklist: %v. Fix the file and restart to change the blacklist.", ip, err)
+		case err != nil:
+			content = fmt.Sprintf(prefix("⚠️")+"Removed `%s` from the blacklist until restart, but failed to persist the blacklist: %v", ip, err)
+		case removed:
+			content = fmt.Sprintf(prefix("✅")+"Removed `%s` from the blacklist. Attacks on it are reported from the next poll.", ip)
+		default:
+			content = fmt.Sprintf(prefix("❓")+"`%s` is not blacklisted.", ip)
+		}
+		if removed {
+			log.Printf("User %s removed IP %s from the blacklist", interactionUsername(i), ip)
+		}
+	default:
+		d.sendBlacklist(s, i)
+		return
+	}
+
+	_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Content: content,
+	})
+	if err != nil {
+		log.Printf("Error sending followup message: %v", err)
+	}
+}
+
+// handleSubscribeCommand handles /subscribe and /unsubscribe. The replies are only shown to the user.
+func (d *DiscordBotIntegration) handleSubscribeCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+
+	if err != nil {
+		log.Printf("Error acknowledging interaction: %v", err)
+		return
+	}
+
+	userID := interactionUserID(i)
+	if d.subscriptions == nil || userID == "" {
+		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: prefix("⚠️") + "Subscriptions are not available.",
+		})
+		if err != nil {
+			log.Printf("Error sending followup message: %v", err)
+		}
+		return
+	}
+
+	var ip string
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Name == "ip" {
+			ip = strings.TrimSpace(opt.StringValue())
+			break
+		}
+	}
+
+	command := i.ApplicationCommandData().Name
+	var content string
+	switch {
+	case command == "subscribe" && ip == "":
+		ips := d.subscriptions.ForUser(userID)
+		if len(ips) == 0 {
+			content = prefix("ℹ️") + "You are not subscribed to any IP. Use `/subscribe ip:<ip>` to get a direct message when it is attacked."
+		} else {
+			content = fmt.Sprintf(prefix("📬")+"You get direct messages about attacks on: `%s`", strings.Join(ips, "`, `"))
+		}
+	case net.ParseIP(ip) == nil:
+		content = fmt.Sprintf(prefix("❌")+"`%s` is not a valid IP address.", ip)
+	case command == "subscribe":
+		added, err := d.subscriptions.Add(ip, userID)
+		switch {
+		case err != nil:
+			content = fmt.Sprintf(prefix("⚠️")+"Subscribed to `%s` until restart, but failed to persist the subscriptions: %v", ip, err)
+		case added:
+			content = fmt.Sprintf(prefix("✅")+"Subscribed to `%s`. You get a direct message when it is attacked, make sure you accept direct messages from server members.", ip)
+		default:
+			content = fmt.Sprintf(prefix("❓")+"You are already subscribed to `%s`.", ip)
+		}
+		if added {
+			log.Printf("User %s subscribed to IP %s", interactionUsername(i), ip)
+		}
+	default:
+		removed, err := d.subscriptions.Remove(ip, userID)
+		switch {
+		case err != nil:
+			content = fmt.Sprintf(prefix("⚠️")+"Unsubscribed from `%s` until restart, but failed to persist the subscriptions: %v", ip, err)
+		case removed:
+			content = fmt.Sprintf(prefix("✅")+"Unsubscribed from `%s`.", ip)
+		default:
+			content = fmt.Sprintf(prefix("❓")+"You are not subscribed to `%s`.", ip)
+		}
+		if removed {
+			log.Printf("User %s unsubscribed from IP %s", interactionUsername(i), ip)
+		}
+	}
+
+	_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Content: content,
+	})
+	if err != nil {
+		log.Printf("Error sending followup message: %v", err)
+	}
+}
+
+// subscriberMessageTimeout bounds sending the direct messages about a new attack to its subscribers
+const subscriberMessageTimeout = 30 * time.Second
+
+// notifySubscribers sends a direct message about a new attack to every user subscribed to its IP
+func (d *DiscordBotIntegration) notifySubscribers(event *AttackEvent) {
+	attack := event.Attack
+	userIDs := d.subscriptions.Subscribers(attack.DstAddressString)
+	if len(userIDs) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), subscriberMessageTimeout)
+	defer cancel()
+
+	embed := d.createDiscordgoEmbed(event, 0xFF0000, codePrefix("🔥")+"New DDoS Attack Detected")
+	for _, userID := range userIDs {
+		channel, err := d.dg.UserChannelCreate(userID, discordgo.WithContext(ctx))
+		if err != nil {
+			log.Printf("Warning: Failed to open a direct message channel with subscriber %s: %v", userID, err)
+			continue
+		}
+
+		_, err = d.dg.ChannelMessageSendComplex(channel.ID, &discordgo.MessageSend{
+			Content: fmt.Sprintf("Attack on `%s`, which you subscribed to with `/subscribe`:", attack.DstAddressString),
+			Embeds:  []*discordgo.MessageEmbed{embed},
+		}, discordgo.WithContext(ctx))
+		if err != nil {
+			log.Printf("Warning: Failed to send a direct message to subscriber %s: %v", userID, err)
+		}
+	}
+}
+
+func (d *DiscordBotIntegration) sendBlacklist(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	configured, runtime := d.blacklist.List()
+
+	formatIPs := func(ips []string) string {
+		if len(ips) == 0 {
+			return "None"
+		}
+		var list strings.Builder
+		for _, ip := range ips {
+			list.WriteString(fmt.Sprintf("• `%s`\n", ip))
+		}
+		if list.Len() > 1024 {
+			return list.String()[:strings.LastIndex(list.String()[:1021], "\n")+1] + "…"
+		}
+		return list.String()
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "Blacklisted IPs",
+		Color: 0x3498DB,
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:  "Added with /blacklist",
+				Value: formatIPs(runtime),
+			},
+			{
+				Name:  "Config File",
+				Value: formatIPs(configured),
+			},
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text:    "NeoProtect Monitor Bot",
+			IconURL: "https://cms.mscode.pl/uploads/icon_blue_84fa10dde8.png",
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Embeds: []*discordgo.MessageEmbed{embed},
+	})
+	if err != nil {
+		log.Printf("Error sending followup message: %v", err)
+	}
+}
+
+type ipAttackSummary struct {
+	ip            string
+	count         int
+	totalDuration time.Duration
+	peakBPS       int64
+	// peakSampleRate is the sample rate of the attack with the peak bandwidth
+	peakSampleRate int64
+}
+
+// targetPortsLimit is how many targeted ports /stats shows, following targetPortsLimit of the monitor
+func (d *DiscordBotIntegration) targetPortsLimit() int {
+	if d.monitorConfig == nil {
+		return defaultTargetPortsLimit
+	}
+	return d.monitorConfig.TargetPortsLimit
+}
+
+// checkGroup answers the interaction with an error and returns false if the group option names no ipGroups group
+func (d *DiscordBotIntegration) checkGroup(s *discordgo.Session, i *discordgo.InteractionCreate, group string) bool {
+	if group == "" || (d.monitorConfig != nil && d.monitorConfig.HasGroup(group)) {
+		return true
+	}
+
+	_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Content: fmt.Sprintf(prefix("❌")+"Unknown group `%s`. Groups are configured in `ipGroups`.", group),
+	})
+	if err != nil {
+		log.Printf("Error sending unknown group message: %v", err)
+	}
+	return false
+}
+
+// inGroup reports whether the IP belongs to the group, every IP belongs to the empty group
+func (d *DiscordBotIntegration) inGroup(group, ip string) bool {
+	return group == "" || d.monitorConfig.InGroup(group, ip)
+}
+
+func (d *DiscordBotIntegration) handleTopCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+
+	if err != nil {
+		log.Printf("Error acknowledging interaction: %v", err)
+		return
+	}
+
+	if d.neoprotectAPI == nil {
+		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: prefix("⚠️") + "NeoProtect API client is not configured for this bot.",
+		})
+		if err != nil {
+			return
+		}
+		return
+	}
+
+	days := 30
+	sortBy := "count"
+	var group string
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "group":
+			group = opt.StringValue()
+		case "days":
+			days = int(opt.IntValue())
+			if days < 1 {
+				days = 1
+			} else if days > 365 {
+				days = 365
+			}
+		case "sort":
+			sortBy = opt.StringValue()
+		}
+	}
+
+	if !d.checkGroup(s, i, group) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	ipAddresses, err := d.neoprotectAPI.GetIPAddresses(ctx)
+	if err != nil {
+		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: fmt.Sprintf(prefix("❌")+"Failed to fetch IP addresses: %v", err),
+		})
+		if err != nil {
+			return
+		}
+		return
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	var summaries []*ipAttackSummary
+
+	for _, ip := range ipAddresses {
+		if ip == nil || ip.IPv4 == "" || !d.inGroup(group, ip.IPv4) {
+			continue
+		}
+
+		summary := &ipAttackSummary{ip: ip.IPv4}
+
+		for page := 0; page < topHistoryPages; page++ {
+			attacks, err := d.neoprotectAPI.GetAttacks(ctx, ip.IPv4, page)
+			if err != nil {
+				log.Printf("Warning: Failed to fetch attacks for IP %s, page %d: %v", ip.IPv4, page, err)
+				break
+			}
+
+			if len(attacks) == 0 {
+				break
 			}
 
-			if a.GetPeakBPS() > peakBPS {
-				peakBPS = a.GetPeakBPS()
-			}
+			for _, attack := range attacks {
+				// Attacks without a start time cannot be placed in the window, so they are not ranked
+				if attack == nil || attack.StartedAt == nil || attack.StartedAt.Before(since) {
+					continue
+				}
 
-			if a.GetPeakPPS() > peakPPS {
-				peakPPS = a.GetPeakPPS()
+				summary.count++
+				summary.totalDuration += attack.Duration()
+				if peak := displayedPeakBPS(attack); peak > summary.peakBPS {
+					summary.peakBPS = peak
+					summary.peakSampleRate = attack.SampleRate
+				}
 			}
 		}
 
-		description.WriteString(fmt.Sprintf("**Total Attack Time:** %s\n", formatDurationReadable(totalDuration)))
-		description.WriteString(fmt.Sprintf("**All-Time Peak Bandwidth:** %s\n", formatBPS(peakBPS)))
-		description.WriteString(fmt.Sprintf("**All-Time Peak Packet Rate:** %s\n", formatPPS(peakPPS)))
+		if summary.count > 0 {
+			summaries = append(summaries, summary)
+		}
 
-		embed := &discordgo.MessageEmbed{
-			Title:       "NeoProtect IP Statistics",
-			Description: description.String(),
-			Color:       0x3498DB,
-			URL:         panelLink,
-			Footer: &discordgo.MessageEmbedFooter{
-				Text:    "Use /history for detailed attack history",
-				IconURL: "https://cms.mscode.pl/uploads/icon_blue_84fa10dde8.png",
-			},
-			Timestamp: time.Now().Format(time.RFC3339),
+		if ctx.Err() != nil {
+			log.Printf("Warning: Time budget exhausted while building /top leaderboard")
+			break
 		}
+	}
 
-		_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-			Embeds: []*discordgo.MessageEmbed{embed},
-		})
-		if err != nil {
-			log.Printf("Error sending followup message: %v", err)
+	sort.Slice(summaries, func(a, b int) bool {
+		switch sortBy {
+		case "duration":
+			return summaries[a].totalDuration > summaries[b].totalDuration
+		case "peak":
+			return summaries[a].peakBPS > summaries[b].peakBPS
+		default:
+			return summaries[a].count > summaries[b].count
+		}
+	})
+
+	if len(summaries) > 10 {
+		summaries = summaries[:10]
+	}
+
+	var description strings.Builder
+	description.WriteString(fmt.Sprintf("## Most Targeted IPs (last %d days)\n\n", days))
+
+	if len(summaries) == 0 {
+		description.WriteString("No attacks found in this time window.")
+	} else {
+		for rank, summary := range summaries {
+			panelLink := panelLink(summary.ip)
+			description.WriteString(fmt.Sprintf("**%d.** `%s` — **%s** attacks, %s total, peak %s%s · [Panel](%s)\n",
+				rank+1,
+				summary.ip,
+				formatCount(int64(summary.count)),
+				formatDurationReadable(summary.totalDuration),
+				formatBPS(summary.peakBPS),
+				samplingNote(summary.peakSampleRate),
+				panelLink))
 		}
 	}
+
+	title := "NeoProtect Attack Leaderboard"
+	if group != "" {
+		title += " · " + group
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       title,
+		Description: description.String(),
+		Color:       0x3498DB,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text:    fmt.Sprintf("Sorted by %s · based on up to %d pages of history per IP", sortBy, topHistoryPages),
+			IconURL: "https://cms.mscode.pl/uploads/icon_blue_84fa10dde8.png",
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Embeds: []*discordgo.MessageEmbed{embed},
+	})
+	if err != nil {
+		log.Printf("Error sending followup message: %v", err)
+	}
 }
 
 func (d *DiscordBotIntegration) NotifyNewAttack(ctx context.Context, attack *neoprotect.Attack) (string, error) {
+	return d.NotifyEvent(ctx, NewAttackEvent(EventNewAttack, attack, nil), "")
+}
+
+// NotifyEvent renders the event, the manager calls it instead of the per-event methods
+func (d *DiscordBotIntegration) NotifyEvent(ctx context.Context, event *AttackEvent, messageID string) (string, error) {
+	switch event.Type {
+	case EventAttackUpdate:
+		return d.notifyAttackUpdate(ctx, event, messageID)
+	case EventAttackEnded:
+		return "", d.notifyAttackEnded(ctx, event, messageID)
+	default:
+		return d.notifyNewAttack(ctx, event)
+	}
+}
+
+func (d *DiscordBotIntegration) notifyNewAttack(ctx context.Context, event *AttackEvent) (string, error) {
 	if d.dg == nil {
 		return "", fmt.Errorf("discord session not initialized")
 	}
 
-	embed := d.createDiscordgoEmbed(attack, nil, 0xFF0000, "`🔥` New DDoS Attack Detected")
-	embeds := []*discordgo.MessageEmbed{embed}
+	attack := event.Attack
+	message := &discordgo.MessageSend{}
+	if d.compact {
+		message.Content = compactAttackLine("new", attack)
+	} else {
+		embed := d.createDiscordgoEmbed(event, 0xFF0000, codePrefix("🔥")+"New DDoS Attack Detected")
+		embed.Thumbnail = d.thumbnail(event)
+		message.Embeds = []*discordgo.MessageEmbed{embed}
+	}
 
-	msg, err := d.dg.ChannelMessageSendComplex(d.channelID, &discordgo.MessageSend{
-		Embeds: embeds,
-	})
+	if d.criticalAlert.IsCritical(attack) {
+		message.Content = strings.TrimSpace(d.criticalAlert.Mention + " " + message.Content)
+		message.TTS = d.criticalAlert.TTS
+	}
+
+	msg, err := d.sendAlert(ctx, message)
 	if err != nil {
 		return "", fmt.Errorf("failed to send Discord message: %w", err)
 	}
 
+	if d.subscriptions != nil {
+		go d.notifySubscribers(event)
+	}
+
+	if d.threadUpdates {
+		cached := cachedAttackMessage{messageID: msg.ID}
+
+		thread, err := d.dg.MessageThreadStart(d.channelID, msg.ID, fmt.Sprintf("Attack on %s", attack.DstAddressString), attackThreadArchiveMinutes, discordgo.WithContext(ctx))
+		if err != nil {
+			log.Printf("Warning: Failed to start thread for attack %s: %v", attack.ID, err)
+		} else {
+			cached.threadID = thread.ID
+		}
+
+		d.messageMutex.Lock()
+		d.attackCache[attack.ID] = cached
+		d.messageMutex.Unlock()
+	}
+
 	return msg.ID, nil
 }
 
+// alertWebhookName names the webhook created in the alert channel when useWebhook is set
+const alertWebhookName = "NeoProtect Monitor"
+
+// channelWebhook returns a webhook of the bot in the alert channel, creating one if there is none yet.
+// Creating it needs the Manage Webhooks permission.
+func (d *DiscordBotIntegration) channelWebhook() (*discordgo.Webhook, error) {
+	webhooks, err := d.dg.ChannelWebhooks(d.channelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list channel webhooks: %w", err)
+	}
+
+	for _, webhook := range webhooks {
+		if webhook.User != nil && webhook.User.ID == d.dg.State.User.ID && webhook.Token != "" {
+			return webhook, nil
+		}
+	}
+
+	webhook, err := d.dg.WebhookCreate(d.channelID, alertWebhookName, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create channel webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+// sendAlert posts an attack message to the alert channel. With useWebhook it goes through the channel webhook,
+// so the configured username and avatar are shown instead of the bot's.
+func (d *DiscordBotIntegration) sendAlert(ctx context.Context, message *discordgo.MessageSend) (*discordgo.Message, error) {
+	if d.alertWebhook == nil {
+		msg, err := d.dg.ChannelMessageSendComplex(d.channelID, message, discordgo.WithContext(ctx))
+		return msg, channelError(err)
+	}
+
+	msg, err := d.dg.WebhookExecute(d.alertWebhook.ID, d.alertWebhook.Token, true, &discordgo.WebhookParams{
+		Content:   message.Content,
+		Username:  d.username,
+		AvatarURL: d.avatarURL,
+		TTS:       message.TTS,
+		Embeds:    message.Embeds,
+	}, discordgo.WithContext(ctx))
+	return msg, channelError(err)
+}
+
+// permanentChannelErrors are the Discord error codes telling that the alert channel cannot be posted to anymore
+var permanentChannelErrors = map[int]bool{
+	discordgo.ErrCodeUnknownChannel:     true,
+	discordgo.ErrCodeUnknownGuild:       true,
+	discordgo.ErrCodeUnknownWebhook:     true,
+	discordgo.ErrCodeMissingAccess:      true,
+	discordgo.ErrCodeMissingPermissions: true,
+}
+
+// channelError marks errors of Discord rejecting the alert channel itself, e.g. because it was deleted or the bot
+// was removed from the server, with ErrDestinationGone
+func channelError(err error) error {
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) || restErr.Message == nil || !permanentChannelErrors[restErr.Message.Code] {
+		return err
+	}
+	return fmt.Errorf("%w: %v", ErrDestinationGone, err)
+}
+
+// editAlert edits an attack message in the alert channel, messages posted through the webhook can only be
+// edited through it
+func (d *DiscordBotIntegration) editAlert(ctx context.Context, edit *discordgo.MessageEdit) (*discordgo.Message, error) {
+	if d.alertWebhook == nil {
+		msg, err := d.dg.ChannelMessageEditComplex(edit, discordgo.WithContext(ctx))
+		return msg, channelError(err)
+	}
+
+	msg, err := d.dg.WebhookMessageEdit(d.alertWebhook.ID, d.alertWebhook.Token, edit.ID, &discordgo.WebhookEdit{
+		Content: edit.Content,
+		Embeds:  edit.Embeds,
+	}, discordgo.WithContext(ctx))
+	return msg, channelError(err)
+}
+
+// attackThread returns the thread started for the attack, if updates are posted to threads
+func (d *DiscordBotIntegration) attackThread(attackID string) (cachedAttackMessage, bool) {
+	if !d.threadUpdates {
+		return cachedAttackMessage{}, false
+	}
+
+	d.messageMutex.RLock()
+	cached, exists := d.attackCache[attackID]
+	d.messageMutex.RUnlock()
+
+	return cached, exists && cached.threadID != ""
+}
+
 func (d *DiscordBotIntegration) NotifyAttackUpdate(ctx context.Context, attack *neoprotect.Attack, previous *neoprotect.Attack, messageID string) error {
+	_, err := d.NotifyEvent(ctx, NewAttackEvent(EventAttackUpdate, attack, previous), messageID)
+	return err
+}
+
+func (d *DiscordBotIntegration) notifyAttackUpdate(ctx context.Context, event *AttackEvent, messageID string) (string, error) {
 	if d.dg == nil {
-		return fmt.Errorf("discord session not initialized")
+		return "", fmt.Errorf("discord session not initialized")
 	}
 
-	embed := d.createDiscordgoEmbed(attack, previous, 0xFFFF00, "`📶` DDoS Attack Updated")
+	attack := event.Attack
+
+	color, title := 0xFFFF00, codePrefix("📶")+"DDoS Attack Updated"
+	if attack.Subsiding {
+		color, title = 0x3498DB, codePrefix("📉")+"DDoS Attack Subsiding"
+	}
+
+	embed := d.createDiscordgoEmbed(event, color, title)
+	embed.Thumbnail = d.thumbnail(event)
 	embeds := []*discordgo.MessageEmbed{embed}
 
+	var content string
+	if d.compact {
+		content = compactAttackLine("update", attack)
+		embeds = []*discordgo.MessageEmbed{}
+	}
+
+	if cached, ok := d.attackThread(attack.ID); ok {
+		_, err := d.dg.ChannelMessageSendComplex(cached.threadID, &discordgo.MessageSend{
+			Content: content,
+			Embeds:  embeds,
+		}, discordgo.WithContext(ctx))
+		if err != nil {
+			return "", fmt.Errorf("failed to send Discord thread message: %w", err)
+		}
+		return messageID, nil
+	}
+
 	if messageID == "" {
 		d.messageMutex.RLock()
-		cachedID, exists := d.attackCache[attack.ID]
+		cached, exists := d.attackCache[attack.ID]
 		d.messageMutex.RUnlock()
 
 		if exists {
-			messageID = cachedID
+			messageID = cached.messageID
 		}
 	}
 
-	if messageID != "" {
-		_, err := d.dg.ChannelMessageEditComplex(&discordgo.MessageEdit{
+	if messageID != "" && editsInPlace(d.editInPlace, "update") {
+		edit := &discordgo.MessageEdit{
 			Channel: d.channelID,
 			ID:      messageID,
 			Embeds:  &embeds,
-		})
+		}
+		if d.compact {
+			edit.Content = &content
+		}
+
+		_, err := d.editAlert(ctx, edit)
 		if err != nil {
 			if strings.Contains(err.Error(), "Unknown Message") {
-				newEmbeds := []*discordgo.MessageEmbed{embed}
-				msg, err := d.dg.ChannelMessageSendComplex(d.channelID, &discordgo.MessageSend{
-					Embeds: newEmbeds,
+				msg, err := d.sendAlert(ctx, &discordgo.MessageSend{
+					Content: content,
+					Embeds:  embeds,
 				})
 				if err != nil {
-					return fmt.Errorf("failed to send new Discord message: %w", err)
+					return "", fmt.Errorf("failed to send new Discord message: %w", err)
 				}
 
 				d.messageMutex.Lock()
-				d.attackCache[attack.ID] = msg.ID
+				d.attackCache[attack.ID] = cachedAttackMessage{messageID: msg.ID}
 				d.messageMutex.Unlock()
-				return nil
+				return msg.ID, nil
 			}
-			return fmt.Errorf("failed to edit Discord message: %w", err)
+			return "", fmt.Errorf("failed to edit Discord message: %w", err)
 		}
-		return nil
+		return messageID, nil
 	}
 
-	newEmbeds := []*discordgo.MessageEmbed{embed}
-	msg, err := d.dg.ChannelMessageSendComplex(d.channelID, &discordgo.MessageSend{
-		Embeds: newEmbeds,
+	msg, err := d.sendAlert(ctx, &discordgo.MessageSend{
+		Content: content,
+		Embeds:  embeds,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to send Discord message: %w", err)
+		return "", fmt.Errorf("failed to send Discord message: %w", err)
 	}
 
-	if msg.ID != "" {
+	// An update posted next to a known message leaves that message tracked for the end
+	if msg.ID != "" && messageID == "" {
 		d.messageMutex.Lock()
-		d.attackCache[attack.ID] = msg.ID
+		d.attackCache[attack.ID] = cachedAttackMessage{messageID: msg.ID}
 		d.messageMutex.Unlock()
+		return msg.ID, nil
 	}
 
-	return nil
+	return messageID, nil
 }
 
 func (d *DiscordBotIntegration) NotifyAttackEnded(ctx context.Context, attack *neoprotect.Attack, messageID string) error {
+	_, err := d.NotifyEvent(ctx, NewAttackEvent(EventAttackEnded, attack, nil), messageID)
+	return err
+}
+
+func (d *DiscordBotIntegration) notifyAttackEnded(ctx context.Context, event *AttackEvent, messageID string) error {
 	if d.dg == nil {
 		return fmt.Errorf("discord session not initialized")
 	}
 
-	embed := d.createDiscordgoEmbed(attack, nil, 0x00FF00, "`🚀` DDoS Attack Ended")
+	attack := event.Attack
+	embed := d.createDiscordgoEmbed(event, 0x00FF00, codePrefix("🚀")+"DDoS Attack Ended")
+	embed.Thumbnail = d.thumbnail(event)
 	embeds := []*discordgo.MessageEmbed{embed}
 
+	var content string
+	if d.compact {
+		content = compactAttackLine("ended", attack)
+		embeds = []*discordgo.MessageEmbed{}
+	}
+
+	if cached, ok := d.attackThread(attack.ID); ok {
+		_, err := d.dg.ChannelMessageSendComplex(cached.threadID, &discordgo.MessageSend{
+			Content: content,
+			Embeds:  embeds,
+		}, discordgo.WithContext(ctx))
+		if err != nil {
+			return fmt.Errorf("failed to send Discord thread message: %w", err)
+		}
+
+		edit := &discordgo.MessageEdit{
+			Channel: d.channelID,
+			ID:      cached.messageID,
+			Embeds:  &embeds,
+		}
+		if d.compact {
+			edit.Content = &content
+		}
+		if _, err := d.editAlert(ctx, edit); err != nil {
+			log.Printf("Warning: Failed to mark attack message %s as ended: %v", cached.messageID, err)
+		}
+
+		d.messageMutex.Lock()
+		delete(d.attackCache, attack.ID)
+		d.messageMutex.Unlock()
+		return nil
+	}
+
 	if messageID == "" {
 		d.messageMutex.RLock()
-		cachedID, exists := d.attackCache[attack.ID]
+		cached, exists := d.attackCache[attack.ID]
 		d.messageMutex.RUnlock()
 
 		if exists {
-			messageID = cachedID
+			messageID = cached.messageID
 		}
 	}
 
-	if messageID != "" {
-		_, err := d.dg.ChannelMessageEditComplex(&discordgo.MessageEdit{
+	if messageID != "" && editsInPlace(d.editInPlace, "ended") {
+		edit := &discordgo.MessageEdit{
 			Channel: d.channelID,
 			ID:      messageID,
 			Embeds:  &embeds,
-		})
+		}
+		if d.compact {
+			edit.Content = &content
+		}
+
+		_, err := d.editAlert(ctx, edit)
 		if err != nil {
 			if strings.Contains(err.Error(), "Unknown Message") {
-				newEmbeds := []*discordgo.MessageEmbed{embed}
-				_, err := d.dg.ChannelMessageSendComplex(d.channelID, &discordgo.MessageSend{
-					Embeds: newEmbeds,
+				_, err := d.sendAlert(ctx, &discordgo.MessageSend{
+					Content: content,
+					Embeds:  embeds,
 				})
 				if err != nil {
 					return fmt.Errorf("failed to send new Discord message: %w", err)
@@ -905,107 +3027,268 @@ func (d *DiscordBotIntegration) NotifyAttackEnded(ctx context.Context, attack *n
 		return nil
 	}
 
-	newEmbeds := []*discordgo.MessageEmbed{embed}
-	_, err := d.dg.ChannelMessageSendComplex(d.channelID, &discordgo.MessageSend{
-		Embeds: newEmbeds,
+	_, err := d.sendAlert(ctx, &discordgo.MessageSend{
+		Content: content,
+		Embeds:  embeds,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to send Discord message: %w", err)
 	}
 
+	d.messageMutex.Lock()
+	delete(d.attackCache, attack.ID)
+	d.messageMutex.Unlock()
+	return nil
+}
+
+func (d *DiscordBotIntegration) NotifyWarning(ctx context.Context, title string, message string) error {
+	if d.dg == nil {
+		return fmt.Errorf("discord session not initialized")
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       codePrefix("⚠️") + title,
+		Description: message,
+		Color:       0xFFFF00,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text:    "NeoProtect Monitor Bot",
+			IconURL: "https://cms.mscode.pl/uploads/icon_blue_84fa10dde8.png",
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	_, err := d.dg.ChannelMessageSendComplex(d.statusChannelID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{embed},
+	}, discordgo.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to send Discord message: %w", err)
+	}
+
+	return nil
+}
+
+// HealthCheck reports whether the gateway session is connected
+func (d *DiscordBotIntegration) HealthCheck(ctx context.Context) error {
+	if d.dg == nil {
+		return fmt.Errorf("discord session not initialized")
+	}
+
+	d.dg.RLock()
+	ready := d.dg.DataReady
+	d.dg.RUnlock()
+
+	if !ready {
+		return fmt.Errorf("discord gateway is not connected")
+	}
+	return nil
+}
+
+// NotifyReminder posts a short message that the attack is still ongoing, as a reply to the attack notification
+// when its message is known
+func (d *DiscordBotIntegration) NotifyReminder(ctx context.Context, attack *neoprotect.Attack, messageID string) error {
+	if d.dg == nil {
+		return fmt.Errorf("discord session not initialized")
+	}
+
+	if messageID == "" {
+		d.messageMutex.RLock()
+		messageID = d.attackCache[attack.ID].messageID
+		d.messageMutex.RUnlock()
+	}
+
+	message := &discordgo.MessageSend{
+		Content: compactAttackLine("reminder", attack) + fmt.Sprintf(" — attack `%s` · [Panel](%s)", displayAttackID(attack.ID), panelLink(attack.DstAddressString)),
+	}
+	if messageID != "" {
+		message.Reference = &discordgo.MessageReference{
+			MessageID: messageID,
+			ChannelID: d.channelID,
+		}
+	}
+
+	_, err := d.dg.ChannelMessageSendComplex(d.channelID, message, discordgo.WithContext(ctx))
+	if err != nil && message.Reference != nil {
+		// The original message may have been deleted, post the reminder on its own
+		message.Reference = nil
+		_, err = d.dg.ChannelMessageSendComplex(d.channelID, message, discordgo.WithContext(ctx))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to send Discord message: %w", channelError(err))
+	}
+
+	return nil
+}
+
+// NotifyEscalation posts the escalation of a long-running attack with the on-call mention in the alert channel,
+// as a reply to the attack message when it is known
+func (d *DiscordBotIntegration) NotifyEscalation(ctx context.Context, attack *neoprotect.Attack, messageID string, message string, mention string) error {
+	if d.dg == nil {
+		return fmt.Errorf("discord session not initialized")
+	}
+
+	if messageID == "" {
+		d.messageMutex.RLock()
+		messageID = d.attackCache[attack.ID].messageID
+		d.messageMutex.RUnlock()
+	}
+
+	send := &discordgo.MessageSend{
+		Content: escalationContent(attack, message, escalationMention(mention, d.criticalAlert)),
+	}
+	if messageID != "" {
+		send.Reference = &discordgo.MessageReference{
+			MessageID: messageID,
+			ChannelID: d.channelID,
+		}
+	}
+
+	_, err := d.dg.ChannelMessageSendComplex(d.channelID, send, discordgo.WithContext(ctx))
+	if err != nil && send.Reference != nil {
+		// The original message may have been deleted, post the escalation on its own
+		send.Reference = nil
+		_, err = d.dg.ChannelMessageSendComplex(d.channelID, send, discordgo.WithContext(ctx))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to send Discord message: %w", channelError(err))
+	}
+
 	return nil
 }
 
-func (d *DiscordBotIntegration) createDiscordgoEmbed(attack *neoprotect.Attack, previous *neoprotect.Attack, color int, title string) *discordgo.MessageEmbed {
+func (d *DiscordBotIntegration) createDiscordgoEmbed(event *AttackEvent, color int, title string) *discordgo.MessageEmbed {
+	attack, previous := event.Attack, event.Previous
+
 	var description strings.Builder
 
 	if attack.StartedAt != nil {
 		description.WriteString("### Attack Timeline\n")
-		description.WriteString(fmt.Sprintf("**`🕒`** Started: %s\n", formatTimeToLocal(attack.StartedAt)))
+		description.WriteString(fmt.Sprintf(boldPrefix("🕒")+"Started: %s\n", formatTimeToLocal(attack.StartedAt)))
 
 		if attack.EndedAt != nil {
-			description.WriteString(fmt.Sprintf("**`🛑`** Ended: %s\n", formatTimeToLocal(attack.EndedAt)))
-			description.WriteString(fmt.Sprintf("**`⏱️`** Duration: %s\n", formatDurationReadable(attack.Duration())))
+			description.WriteString(fmt.Sprintf(boldPrefix("🛑")+"Ended: %s\n", formatTimeToLocal(attack.EndedAt)))
+			description.WriteString(fmt.Sprintf(boldPrefix("⏱️")+"Duration: %s\n", formatDurationReadable(attack.Duration())))
 		} else {
-			description.WriteString("**`⚠️`** Status: Active\n")
-			description.WriteString(fmt.Sprintf("**`⏱️`** Duration: %s\n", formatDurationReadable(attack.Duration())))
+			description.WriteString(boldPrefix("⚠️") + "Status: Active\n")
+			description.WriteString(fmt.Sprintf(boldPrefix("⏱️")+"Duration: %s\n", formatDurationReadable(attack.Duration())))
 		}
 	}
 
+	if observedLate(attack) {
+		if attack.StartedAt == nil {
+			description.WriteString("### Attack Timeline\n")
+		}
+		description.WriteString(fmt.Sprintf(boldPrefix("👁️")+"First observed by monitor: %s\n", formatTimeToLocal(attack.FirstObservedAt)))
+	}
+
 	description.WriteString("### Attack Details\n")
 	targetIP := attack.DstAddressString
 	if targetIP == "" {
 		targetIP = "unknown"
 	}
-	description.WriteString(fmt.Sprintf("**`🎯`** Target IP: `%s`\n", targetIP))
+	description.WriteString(fmt.Sprintf(boldPrefix("🎯")+"Target IP: `%s`\n", targetIP))
+	if attack.Account != "" {
+		description.WriteString(fmt.Sprintf(boldPrefix("🏢")+"Account: %s\n", attack.Account))
+	}
+	if len(event.Labels) > 0 {
+		description.WriteString(fmt.Sprintf(boldPrefix("🏷️")+"Labels: %s\n", strings.Join(event.Labels, ", ")))
+	}
+	if event.Severity == SeverityCritical {
+		description.WriteString(boldPrefix("🚨") + "Severity: Critical\n")
+	}
 
-	attackID := attack.ID
-	if attackID == "" {
-		attackID = "unknown"
+	description.WriteString(fmt.Sprintf(boldPrefix("🔍")+"Attack ID: `%s`\n", displayAttackID(attack.ID)))
+
+	panelLink := panelLink(targetIP)
+	description.WriteString(fmt.Sprintf(boldPrefix("🔗")+"[View in NeoProtect Panel](%s)\n", panelLink))
+
+	if len(attack.CorrelatedIPs) > 0 {
+		description.WriteString(fmt.Sprintf(boldPrefix("🧭")+"Likely same source as attack on %s\n", strings.Join(attack.CorrelatedIPs, ", ")))
 	}
-	description.WriteString(fmt.Sprintf("**`🔍`** Attack ID: `%s`\n", attackID))
 
-	panelLink := fmt.Sprintf("https://panel.neoprotect.net/network/ips/%s?tab=attacks", targetIP)
-	description.WriteString(fmt.Sprintf("**`🔗`** [View in NeoProtect Panel](%s)\n", panelLink))
+	note := samplingNote(attack.SampleRate)
+	trafficStats := fmt.Sprintf("**Peak Bandwidth:** %s%s\n**Peak Packet Rate:** %s%s",
+		formatBPS(displayedPeakBPS(attack)), note,
+		formatPPS(displayedPeakPPS(attack)), note)
+	if attack.NewRecordPeak {
+		trafficStats += "\n" + boldPrefix("🏆") + "New record peak for this IP"
+	}
+	if note := saturationNote(attack); note != "" {
+		trafficStats += "\n" + boldPrefix("⚠️") + "**" + note + "**"
+	}
+	if len(attack.ProtocolMix) > 0 {
+		trafficStats += fmt.Sprintf("\n**Protocol Mix:** %s", formatProtocolMix(attack.ProtocolMix))
+	}
+	if len(attack.TargetPorts) > 0 {
+		trafficStats += fmt.Sprintf("\n**Targeted Ports:** %s", formatTargetPorts(attack.TargetPorts, attack.ProtocolMix))
+	}
 
 	fields := []*discordgo.MessageEmbedField{
 		{
-			Name: "**`📊`** Traffic Statistics",
-			Value: fmt.Sprintf("**Peak Bandwidth:** %s\n**Peak Packet Rate:** %s",
-				formatBPS(attack.GetPeakBPS()),
-				formatPPS(attack.GetPeakPPS())),
+			Name:   boldPrefix("📊") + "Traffic Statistics",
+			Value:  trafficStats,
 			Inline: false,
 		},
 		{
-			Name:   "**`🔎`** Attack Signatures",
+			Name:   boldPrefix("🔎") + "Attack Signatures",
 			Value:  d.formatSignatures(attack),
 			Inline: false,
 		},
 	}
 
 	if previous != nil {
-		diff := attack.CalculateDiff(previous)
-		if len(diff) > 0 {
+		if diff := event.Diff; diff.HasChanges() {
 			var changesBuilder strings.Builder
 
-			if bpsChange, ok := diff["bpsPeakChange"].(int64); ok {
-				var changeSymbol string
-				if bpsChange > 0 {
-					changeSymbol = "`📈`"
-				} else {
-					changeSymbol = "`📉`"
-				}
-				changesBuilder.WriteString(fmt.Sprintf("%s **Bandwidth:** %s → %s (%+d%%)\n",
-					changeSymbol,
-					formatBPS(previous.GetPeakBPS()),
-					formatBPS(attack.GetPeakBPS()),
-					calculatePercentageChange(previous.GetPeakBPS(), attack.GetPeakBPS())))
+			if diff.BPSChange != 0 {
+				changesBuilder.WriteString(fmt.Sprintf("%s**Bandwidth:** %s → %s (%+d%%)\n",
+					changeSymbol(diff.BPSChange),
+					formatBPS(displayedPeakBPS(previous)),
+					formatBPS(displayedPeakBPS(attack)),
+					calculatePercentageChange(displayedPeakBPS(previous), displayedPeakBPS(attack))))
 			}
 
-			if ppsChange, ok := diff["ppsPeakChange"].(int64); ok {
-				var changeSymbol string
-				if ppsChange > 0 {
-					changeSymbol = "`📈`"
-				} else {
-					changeSymbol = "`📉`"
+			if diff.PPSChange != 0 {
+				changesBuilder.WriteString(fmt.Sprintf("%s**Packet Rate:** %s → %s (%+d%%)\n",
+					changeSymbol(diff.PPSChange),
+					formatPPS(displayedPeakPPS(previous)),
+					formatPPS(displayedPeakPPS(attack)),
+					calculatePercentageChange(displayedPeakPPS(previous), displayedPeakPPS(attack))))
+			}
+
+			if len(diff.NewSignatures) > 0 {
+				changesBuilder.WriteString(boldPrefix("⚠️") + "New Attack Signatures:\n")
+				for _, sig := range diff.NewSignatures {
+					changesBuilder.WriteString(fmt.Sprintf("• `%s`\n", sig))
 				}
-				changesBuilder.WriteString(fmt.Sprintf("%s **Packet Rate:** %s → %s (%+d%%)\n",
-					changeSymbol,
-					formatPPS(previous.GetPeakPPS()),
-					formatPPS(attack.GetPeakPPS()),
-					calculatePercentageChange(previous.GetPeakPPS(), attack.GetPeakPPS())))
 			}
 
-			if newSigs, ok := diff["newSignatures"].([]string); ok && len(newSigs) > 0 {
-				changesBuilder.WriteString("**`⚠️`** New Attack Signatures:\n")
-				for _, sig := range newSigs {
+			if len(diff.EndedSignatures) > 0 {
+				changesBuilder.WriteString(boldPrefix("✅") + "Ended Attack Signatures:\n")
+				for _, sig := range diff.EndedSignatures {
 					changesBuilder.WriteString(fmt.Sprintf("• `%s`\n", sig))
 				}
 			}
 
+			if diff.AutoMitigation != nil {
+				state := "Off"
+				if *diff.AutoMitigation {
+					state = "On"
+				}
+				changesBuilder.WriteString(fmt.Sprintf("%s**Auto-Mitigation:** switched %s\n", codePrefix("🛡️"), state))
+			}
+
+			if diff.VectorShift != nil {
+				changesBuilder.WriteString(fmt.Sprintf("%s**Vector Shift:** `%s` → `%s`\n", codePrefix("🔀"), diff.VectorShift.From, diff.VectorShift.To))
+			}
+
+			if diff.SampleRateChange != 0 {
+				changesBuilder.WriteString(fmt.Sprintf("%s**Sample Rate:** %s → %s\n",
+					changeSymbol(diff.SampleRateChange), formatCount(previous.SampleRate), formatCount(diff.SampleRateCurrent)))
+			}
+
 			if changesBuilder.Len() > 0 {
 				fields = append(fields, &discordgo.MessageEmbedField{
-					Name:   "**`📝`** Changes Detected",
+					Name:   boldPrefix("📝") + "Changes Detected",
 					Value:  changesBuilder.String(),
 					Inline: false,
 				})
@@ -1036,18 +3319,15 @@ func (d *DiscordBotIntegration) createDiscordgoEmbed(attack *neoprotect.Attack,
 	return embed
 }
 
-func (d *DiscordBotIntegration) formatSignatures(attack *neoprotect.Attack) string {
-	names := attack.GetSignatureNames()
-	if len(names) == 0 {
-		return "No signatures detected"
-	}
-
-	var result strings.Builder
-	for _, name := range names {
-		result.WriteString(fmt.Sprintf("• `%s`\n", name))
+func (d *DiscordBotIntegration) thumbnail(event *AttackEvent) *discordgo.MessageEmbedThumbnail {
+	if url := thumbnailURL(d.thumbnails, event); url != "" {
+		return &discordgo.MessageEmbedThumbnail{URL: url}
 	}
+	return nil
+}
 
-	return result.String()
+func (d *DiscordBotIntegration) formatSignatures(attack *neoprotect.Attack) string {
+	return formatSignatureList(attack, d.maxSignatures)
 }
 
 func (d *DiscordBotIntegration) Shutdown() {
@@ -0,0 +1,150 @@
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+	"neoprotect-notifier/neoprotect"
+)
+
+func TestCreateDiscordgoEmbedRendersAllChangeTypes(t *testing.T) {
+	d := &DiscordBotIntegration{maxSignatures: 10}
+	previous, current := changedAttackPair()
+
+	embed := d.createDiscordgoEmbed(NewAttackEvent(EventAttackUpdate, current, previous), 0, "Attack Updated")
+
+	var changes string
+	for _, field := range embed.Fields {
+		if strings.Contains(field.Name, "Changes Detected") {
+			changes = field.Value
+		}
+	}
+	if changes == "" {
+		t.Fatal("embed has no Changes Detected field")
+	}
+
+	for _, want := range expectedChangeLines {
+		if !strings.Contains(changes, want) {
+			t.Errorf("changes field is missing %q:\n%s", want, changes)
+		}
+	}
+}
+
+func TestActiveAttacksDescriptionFitsEmbedLimit(t *testing.T) {
+	var active []*neoprotect.Attack
+	for i := 0; i < 40; i++ {
+		attack := testAttack()
+		attack.ID = fmt.Sprintf("3f2b8c1e-7d4a-4e5b-9c6d-%012d", i)
+		attack.DstAddressString = fmt.Sprintf("2001:db8:85a3::8a2e:370:%d", i)
+		attack.Signatures = []neoprotect.AttackSignature{
+			{ID: "1", Name: "UDP Flood", StartedAt: attack.StartedAt, BPSPeak: 1_000_000_000},
+			{ID: "2", Name: "DNS Amplification", StartedAt: attack.StartedAt, BPSPeak: 900_000_000},
+			{ID: "3", Name: "NTP Amplification", StartedAt: attack.StartedAt, BPSPeak: 800_000_000},
+			{ID: "4", Name: "SYN Flood", StartedAt: attack.StartedAt, BPSPeak: 700_000_000},
+		}
+		active = append(active, attack)
+	}
+
+	description := activeAttacksDescription(active)
+
+	if length := utf8.RuneCountInString(description); length > embedDescriptionLimit {
+		t.Fatalf("description is %d characters, over the limit of %d", length, embedDescriptionLimit)
+	}
+	if !strings.Contains(description, "more\n") {
+		t.Fatal("description does not count the attacks left out")
+	}
+}
+
+func TestFullAttackHistoryStopsAtPageLimitAndDedupes(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		// Every page repeats the same attack, as a listing that never runs out would
+		fmt.Fprint(w, `[{"id": "attack-1", "dstAddressString": "192.0.2.1"}]`)
+	}))
+	defer server.Close()
+
+	client, err := neoprotect.NewClient("key", server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	d := &DiscordBotIntegration{neoprotectAPI: client}
+
+	attacks, complete := d.fullAttackHistory(context.Background(), "192.0.2.1")
+
+	if complete {
+		t.Error("history reported complete after hitting the page limit")
+	}
+	if len(attacks) != 1 {
+		t.Errorf("history has %d attacks, want the repeated attack once", len(attacks))
+	}
+	if got, want := requests.Load(), int32(neoprotect.MaxAttackPages+1); got != want {
+		t.Errorf("history fetched %d pages, want %d", got, want)
+	}
+}
+
+func TestStatsAttackStatusHandlesMissingAttackData(t *testing.T) {
+	d := &DiscordBotIntegration{}
+
+	// GetActiveAttack returning (nil, nil) or an empty attack means the IP is not under attack
+	for _, attack := range []*neoprotect.Attack{nil, {}} {
+		if got := d.statsAttackStatus(attack, nil); !strings.Contains(got, "No Active Attack") {
+			t.Errorf("statsAttackStatus(%v) = %q, want no active attack", attack, got)
+		}
+	}
+
+	got := d.statsAttackStatus(&neoprotect.Attack{ID: "attack-1"}, nil)
+	if !strings.Contains(got, "Under Attack") || !strings.Contains(got, "No data available yet") {
+		t.Errorf("statsAttackStatus() = %q, want an active attack without traffic data", got)
+	}
+	if strings.Contains(got, "Duration") {
+		t.Errorf("statsAttackStatus() = %q, shows a duration without a start", got)
+	}
+}
+
+// discordAPIFunc answers the requests of a discordgo session without a network
+type discordAPIFunc func(r *http.Request) *http.Response
+
+func (f discordAPIFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r), nil
+}
+
+func discordAPIResponse(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestUpdateRepostReturnsNewMessageID(t *testing.T) {
+	dg, err := discordgo.New("Bot token")
+	if err != nil {
+		t.Fatalf("discordgo.New() error = %v", err)
+	}
+	dg.Client = &http.Client{Transport: discordAPIFunc(func(r *http.Request) *http.Response {
+		// The tracked message was deleted, so editing it fails and the update is posted again
+		if r.Method == http.MethodPatch {
+			return discordAPIResponse(http.StatusNotFound, `{"message": "Unknown Message", "code": 10008}`)
+		}
+		return discordAPIResponse(http.StatusOK, `{"id": "reposted-message", "channel_id": "channel"}`)
+	})}
+	d := &DiscordBotIntegration{dg: dg, channelID: "channel", attackCache: make(map[string]cachedAttackMessage)}
+
+	event := NewAttackEvent(EventAttackUpdate, testAttack(), nil)
+	messageID, err := d.NotifyEvent(context.Background(), event, "deleted-message")
+	if err != nil {
+		t.Fatalf("NotifyEvent() error = %v", err)
+	}
+	if messageID != "reposted-message" {
+		t.Errorf("NotifyEvent() = %q, want the reposted message to be tracked", messageID)
+	}
+}
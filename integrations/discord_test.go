@@ -0,0 +1,140 @@
+package integrations
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"neoprotect-notifier/neoprotect"
+)
+
+// changedAttackPair returns two states of an attack that differ in every way the update embed reports
+func changedAttackPair() (previous, current *neoprotect.Attack) {
+	start := time.Now().Add(-10 * time.Minute)
+	end := time.Now()
+
+	previous = &neoprotect.Attack{
+		ID:               "attack-1",
+		DstAddressString: "192.0.2.1",
+		StartedAt:        &start,
+		SampleRate:       1000,
+		DstAddress:       &neoprotect.IPAddressModel{Settings: &neoprotect.IPSettings{AutoMitigation: false}},
+		Signatures: []neoprotect.AttackSignature{
+			{ID: "sig-1", Name: "UDP Flood", StartedAt: &start, BPSPeak: 1_000_000, PPSPeak: 1_000},
+			{ID: "sig-2", Name: "SYN Flood", StartedAt: &start, BPSPeak: 500_000, PPSPeak: 500},
+		},
+	}
+	current = &neoprotect.Attack{
+		ID:               "attack-1",
+		DstAddressString: "192.0.2.1",
+		StartedAt:        &start,
+		SampleRate:       2000,
+		DstAddress:       &neoprotect.IPAddressModel{Settings: &neoprotect.IPSettings{AutoMitigation: true}},
+		Signatures: []neoprotect.AttackSignature{
+			{ID: "sig-1", Name: "UDP Flood", StartedAt: &start, BPSPeak: 1_000_000, PPSPeak: 1_000},
+			{ID: "sig-2", Name: "SYN Flood", StartedAt: &start, EndedAt: &end, BPSPeak: 500_000, PPSPeak: 500},
+			{ID: "sig-3", Name: "DNS Amplification", StartedAt: &start, BPSPeak: 9_000_000, PPSPeak: 9_000},
+		},
+	}
+	return previous, current
+}
+
+// expectedChangeLines are the markers of every change type that changedAttackPair must render
+var expectedChangeLines = []string{
+	"**Bandwidth:**",
+	"**Packet Rate:**",
+	"New Attack Signatures:",
+	"`DNS Amplification`",
+	"Ended Attack Signatures:",
+	"`SYN Flood`",
+	"**Auto-Mitigation:** switched On",
+	"**Sample Rate:**",
+}
+
+func TestCreateAttackEmbedRendersAllChangeTypes(t *testing.T) {
+	d := &DiscordIntegration{maxSignatures: 10}
+	previous, current := changedAttackPair()
+
+	embed := d.createAttackEmbed(NewAttackEvent(EventAttackUpdate, current, previous), 0, "Attack Updated")
+
+	var changes string
+	for _, field := range embed.Fields {
+		if strings.Contains(field.Name, "Changes Detected") {
+			changes = field.Value
+		}
+	}
+	if changes == "" {
+		t.Fatal("embed has no Changes Detected field")
+	}
+
+	for _, want := range expectedChangeLines {
+		if !strings.Contains(changes, want) {
+			t.Errorf("changes field is missing %q:\n%s", want, changes)
+		}
+	}
+}
+
+func TestCreateAttackEmbedWithoutChanges(t *testing.T) {
+	d := &DiscordIntegration{maxSignatures: 10}
+	_, current := changedAttackPair()
+
+	embed := d.createAttackEmbed(NewAttackEvent(EventAttackUpdate, current, current), 0, "Attack Updated")
+
+	for _, field := range embed.Fields {
+		if strings.Contains(field.Name, "Changes Detected") {
+			t.Fatalf("unchanged attack rendered a Changes Detected field: %s", field.Value)
+		}
+	}
+}
+
+func TestEndOfAttackWithUnknownMessageIsPostedAgain(t *testing.T) {
+	var posts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			posts.Add(1)
+		}
+		// an empty body leaves the message ID of the new attack notification unknown
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	d := &DiscordIntegration{webhookURL: server.URL, editMessages: true, maxSignatures: 10, client: server.Client()}
+	m := newTestManager(d)
+	tracker := NewMessageTracker()
+	attack := testAttack()
+
+	if err := m.NotifyNewAttack(context.Background(), attack, tracker); err != nil {
+		t.Fatalf("NotifyNewAttack() = %v", err)
+	}
+	if !tracker.NeedsRepost(attack.ID, "discord") {
+		t.Fatal("attack is not marked as needing a repost")
+	}
+
+	ended := time.Now()
+	attack.EndedAt = &ended
+	if err := m.NotifyAttackEnded(context.Background(), attack, tracker); err != nil {
+		t.Fatalf("NotifyAttackEnded() = %v", err)
+	}
+
+	if got := posts.Load(); got != 2 {
+		t.Fatalf("posted %d messages, want the announcement and the end", got)
+	}
+}
+
+func TestThumbnailPrefersSeverity(t *testing.T) {
+	thumbnails := map[string]string{"critical": "critical.png", "new": "new.png"}
+
+	critical := &AttackEvent{Type: EventNewAttack, Severity: SeverityCritical}
+	if got := thumbnailURL(thumbnails, critical); got != "critical.png" {
+		t.Errorf("thumbnailURL(critical) = %q, want the critical thumbnail", got)
+	}
+
+	warning := &AttackEvent{Type: EventNewAttack, Severity: SeverityWarning}
+	if got := thumbnailURL(thumbnails, warning); got != "new.png" {
+		t.Errorf("thumbnailURL(warning) = %q, want the fallback of the event type", got)
+	}
+}
@@ -0,0 +1,87 @@
+package integrations
+
+// emojiStyle selects how decorative markers are rendered: "emoji", "ascii" or "none"
+var emojiStyle = "emoji"
+
+// asciiMarkers are the replacements used when emojiStyle is "ascii"
+var asciiMarkers = map[string]string{
+	"🔥":  "[!]",
+	"🚨":  "[!!]",
+	"⚠️": "[!]",
+	"📶":  "[~]",
+	"📉":  "[v]",
+	"📈":  "[^]",
+	"🚀":  "[END]",
+	"🕒":  "[T]",
+	"🛑":  "[X]",
+	"👁️": "[o]",
+	"🎯":  "[>]",
+	"🔍":  "[#]",
+	"🔎":  "[S]",
+	"🔗":  "[@]",
+	"🧭":  "[=]",
+	"🏆":  "[*]",
+	"📊":  "[%]",
+	"📝":  "[i]",
+	"✅":  "[OK]",
+	"❌":  "[X]",
+	"❓":  "[?]",
+	"🤖":  "[BOT]",
+	"🧩":  "[+]",
+	"🛡️": "[M]",
+	"🔧":  "[W]",
+	"ℹ️": "[i]",
+	"⏰":  "[R]",
+	"🏷️": "[L]",
+	"⏱️": "[D]",
+	"🏢":  "[A]",
+	"📬":  "[DM]",
+	"🔀":  "[<>]",
+}
+
+// SetEmojiStyle configures how decorative markers are rendered by all integrations
+func SetEmojiStyle(style string) {
+	switch style {
+	case "emoji", "ascii", "none":
+		emojiStyle = style
+	}
+}
+
+// marker returns the emoji in the configured style, or an empty string when markers are disabled
+func marker(emoji string) string {
+	switch emojiStyle {
+	case "none":
+		return ""
+	case "ascii":
+		if ascii, ok := asciiMarkers[emoji]; ok {
+			return ascii
+		}
+		return "*"
+	default:
+		return emoji
+	}
+}
+
+// prefix returns the marker followed by a space, e.g. "✅ "
+func prefix(emoji string) string {
+	if m := marker(emoji); m != "" {
+		return m + " "
+	}
+	return ""
+}
+
+// codePrefix returns the marker in inline code followed by a space, e.g. "`🔥` "
+func codePrefix(emoji string) string {
+	if m := marker(emoji); m != "" {
+		return "`" + m + "` "
+	}
+	return ""
+}
+
+// boldPrefix returns the marker in bold inline code followed by a space, e.g. "**`🕒`** "
+func boldPrefix(emoji string) string {
+	if m := marker(emoji); m != "" {
+		return "**`" + m + "`** "
+	}
+	return ""
+}
@@ -0,0 +1,17 @@
+package integrations
+
+import "testing"
+
+func TestASCIIMarkersCoverDurationAndChangeMarkers(t *testing.T) {
+	SetEmojiStyle("ascii")
+	defer SetEmojiStyle("emoji")
+
+	for _, emoji := range []string{"⏱️", "🏢", "📬", "🔀"} {
+		if got := marker(emoji); got == "*" {
+			t.Errorf("marker(%q) = %q, want a dedicated ASCII marker", emoji, got)
+		}
+	}
+	if got := boldPrefix("⏱️"); got != "**`[D]`** " {
+		t.Errorf("boldPrefix(⏱️) = %q, want %q", got, "**`[D]`** ")
+	}
+}
@@ -0,0 +1,80 @@
+package integrations
+
+import (
+	"context"
+	"time"
+
+	"neoprotect-notifier/neoprotect"
+)
+
+type AttackEventType string
+
+const (
+	EventNewAttack    AttackEventType = "new_attack"
+	EventAttackUpdate AttackEventType = "attack_update"
+	EventAttackEnded  AttackEventType = "attack_ended"
+)
+
+// Severity ranks how urgent a notification is, so integrations render the same event with the same urgency
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+// AttackEvent is the normalized data of a single notification, built once by the manager and shared by all integrations
+type AttackEvent struct {
+	Type     AttackEventType
+	Attack   *neoprotect.Attack
+	Previous *neoprotect.Attack
+	// Diff is set for updates; it is zero-valued rather than nil when there is no previous state
+	Diff     *neoprotect.AttackDiff
+	Severity Severity
+	// Labels are the ipGroups the target IP belongs to, set by the manager
+	Labels    []string
+	Timestamp time.Time
+	// Repost is set on an ended event for an integration whose message for the attack is unknown, so the end
+	// has to be posted as a new message
+	Repost bool
+}
+
+// EventNotifier is optionally implemented by integrations that render notifications from an AttackEvent.
+// The manager prefers it over the NotifyNewAttack/NotifyAttackUpdate/NotifyAttackEnded methods.
+type EventNotifier interface {
+	NotifyEvent(ctx context.Context, event *AttackEvent, messageID string) (string, error)
+}
+
+func NewAttackEvent(eventType AttackEventType, attack *neoprotect.Attack, previous *neoprotect.Attack) *AttackEvent {
+	event := &AttackEvent{
+		Type:      eventType,
+		Attack:    attack,
+		Previous:  previous,
+		Severity:  eventSeverity(eventType, attack),
+		Timestamp: time.Now(),
+	}
+
+	if eventType == EventAttackUpdate {
+		event.Diff = attack.CalculateDiff(previous)
+		if event.Diff == nil {
+			event.Diff = &neoprotect.AttackDiff{
+				NewSignatures:   []string{},
+				EndedSignatures: []string{},
+			}
+		}
+	}
+
+	return event
+}
+
+// eventSeverity rates ended attacks as info and attacks risking to saturate their link as critical
+func eventSeverity(eventType AttackEventType, attack *neoprotect.Attack) Severity {
+	if eventType == EventAttackEnded {
+		return SeverityInfo
+	}
+	if _, atRisk := saturationRisk(attack); atRisk {
+		return SeverityCritical
+	}
+	return SeverityWarning
+}
@@ -0,0 +1,35 @@
+package integrations
+
+import (
+	"testing"
+
+	"neoprotect-notifier/neoprotect"
+)
+
+func TestEventSeverity(t *testing.T) {
+	SetLinkCapacity(1_000_000_000, nil, 80)
+	defer SetLinkCapacity(0, nil, 80)
+
+	small := testAttack()
+	large := testAttack()
+	// 120 MB/s is 960 Mbps, 96% of the 1 Gbps link
+	large.Signatures = []neoprotect.AttackSignature{{ID: "sig-1", Name: "UDP Flood", StartedAt: large.StartedAt, BPSPeak: 120_000_000}}
+
+	tests := []struct {
+		name  string
+		event *AttackEvent
+		want  Severity
+	}{
+		{"active attack", NewAttackEvent(EventNewAttack, small, nil), SeverityWarning},
+		{"saturating attack", NewAttackEvent(EventAttackUpdate, large, small), SeverityCritical},
+		{"ended attack", NewAttackEvent(EventAttackEnded, large, nil), SeverityInfo},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.event.Severity != tt.want {
+				t.Fatalf("Severity = %q, want %q", tt.event.Severity, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package integrations
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"plugin"
+	"runtime/debug"
+
+	"neoprotect-notifier/neoprotect"
+)
+
+// AttackFilter decides whether an attack is announced. Returning false holds the attack back, it is offered
+// to the filter again on the next poll and announced as a new attack once the filter accepts it.
+type AttackFilter func(attack *neoprotect.Attack) bool
+
+type namedFilter struct {
+	name   string
+	filter AttackFilter
+}
+
+// LoadFilterPlugins loads the attack filters from the named plugin files in the integrations directory.
+// A filter plugin exports either a function or a variable named Filter of type func(*neoprotect.Attack) bool.
+func (m *Manager) LoadFilterPlugins(names []string) error {
+	for _, name := range names {
+		pluginPath := filepath.Join(m.directory, name+".so")
+
+		p, err := plugin.Open(pluginPath)
+		if err != nil {
+			return fmt.Errorf("failed to load filter plugin %s: %w", pluginPath, err)
+		}
+
+		sym, err := p.Lookup("Filter")
+		if err != nil {
+			return fmt.Errorf("failed to look up Filter symbol in %s: %w", pluginPath, err)
+		}
+
+		var filter AttackFilter
+		switch f := sym.(type) {
+		case func(*neoprotect.Attack) bool:
+			filter = f
+		case *func(*neoprotect.Attack) bool:
+			filter = *f
+		default:
+			return fmt.Errorf("filter symbol in %s is not a func(*neoprotect.Attack) bool", pluginPath)
+		}
+
+		m.mu.Lock()
+		m.filters = append(m.filters, namedFilter{name: name, filter: filter})
+		m.mu.Unlock()
+
+		log.Printf("Registered filter plugin: %s", name)
+	}
+
+	return nil
+}
+
+// ShouldNotify reports whether every registered filter accepts the attack
+func (m *Manager) ShouldNotify(attack *neoprotect.Attack) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, f := range m.filters {
+		if !safeFilter(f, attack) {
+			return false
+		}
+	}
+	return true
+}
+
+// safeFilter runs the filter and accepts the attack if the filter panics, so a broken filter never hides attacks
+func safeFilter(f namedFilter, attack *neoprotect.Attack) (accepted bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recovered from panic in filter plugin %s for attack %s on %s, notifying anyway: %v\n%s",
+				f.name, attack.ID, attack.DstAddressString, r, debug.Stack())
+			accepted = true
+		}
+	}()
+	return f.filter(attack)
+}
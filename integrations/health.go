@@ -0,0 +1,96 @@
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// HealthCheck checks every integration implementing HealthChecker concurrently and returns the result of each
+// loaded integration by name, nil for healthy ones. Integrations disabled because their destination is gone are
+// reported as unhealthy without checking them.
+func (m *Manager) HealthCheck(ctx context.Context) map[string]error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var mu sync.Mutex
+	results := make(map[string]error, len(m.integrations))
+	wg := sync.WaitGroup{}
+	disabled := m.DisabledIntegrations()
+
+	for name, integration := range m.integrations {
+		if reason, ok := disabled[name]; ok {
+			results[name] = fmt.Errorf("disabled, %s", reason)
+			continue
+		}
+
+		checker, ok := integration.(HealthChecker)
+		if !ok {
+			results[name] = nil
+			continue
+		}
+
+		wg.Add(1)
+		go func(name string, checker HealthChecker) {
+			defer wg.Done()
+
+			callCtx, cancel := m.integrationContext(ctx, name)
+			err := safeHealthCheck(callCtx, name, checker)
+			cancel()
+
+			mu.Lock()
+			results[name] = err
+			mu.Unlock()
+		}(name, checker)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// HealthCache runs the health checks of the manager at most once per ttl and serves the last results in between,
+// so frequent probes do not hit every integration's endpoint each time
+type HealthCache struct {
+	manager *Manager
+	ttl     time.Duration
+
+	mu        sync.Mutex
+	results   map[string]error
+	checkedAt time.Time
+}
+
+func NewHealthCache(manager *Manager, ttl time.Duration) *HealthCache {
+	return &HealthCache{manager: manager, ttl: ttl}
+}
+
+// Results returns the cached health check results, checking the integrations again once they are older than the ttl.
+// Concurrent callers wait for a single check.
+func (c *HealthCache) Results(ctx context.Context) map[string]error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.results == nil || time.Since(c.checkedAt) >= c.ttl {
+		c.results = c.manager.HealthCheck(ctx)
+		c.checkedAt = time.Now()
+	}
+	return c.results
+}
+
+// UnhealthyIntegrations returns the failed health checks of the results as "name: error", sorted by name
+func UnhealthyIntegrations(results map[string]error) []string {
+	var unhealthy []string
+	for name, err := range results {
+		if err != nil {
+			unhealthy = append(unhealthy, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	sort.Strings(unhealthy)
+	return unhealthy
+}
+
+func safeHealthCheck(ctx context.Context, name string, checker HealthChecker) (err error) {
+	defer recoverIntegrationPanic(name, "health check", nil, &err)
+	return checker.HealthCheck(ctx)
+}
@@ -0,0 +1,37 @@
+package integrations
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// checkedIntegration counts its health checks
+type checkedIntegration struct {
+	fakeIntegration
+	checks atomic.Int32
+}
+
+func (c *checkedIntegration) HealthCheck(ctx context.Context) error {
+	c.checks.Add(1)
+	return nil
+}
+
+func TestHealthCacheChecksOncePerTTL(t *testing.T) {
+	checked := &checkedIntegration{fakeIntegration: fakeIntegration{name: "checked"}}
+	cache := NewHealthCache(newTestManager(checked), time.Hour)
+
+	for i := 0; i < 3; i++ {
+		cache.Results(context.Background())
+	}
+	if got := checked.checks.Load(); got != 1 {
+		t.Fatalf("health checks = %d, want 1 within the ttl", got)
+	}
+
+	cache.checkedAt = time.Now().Add(-2 * time.Hour)
+	cache.Results(context.Background())
+	if got := checked.checks.Load(); got != 2 {
+		t.Fatalf("health checks = %d, want a new check once the ttl passed", got)
+	}
+}
@@ -0,0 +1,72 @@
+package integrations
+
+import (
+	"sync"
+	"time"
+
+	"neoprotect-notifier/neoprotect"
+)
+
+// defaultHistoryCacheTTL is how long a fetched attack history is reused by bot commands
+const defaultHistoryCacheTTL = time.Minute
+
+// historyCache keeps recently fetched attack histories per IP so repeated commands don't walk the API again
+type historyCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]historyCacheEntry
+}
+
+type historyCacheEntry struct {
+	attacks   []*neoprotect.Attack
+	fetchedAt time.Time
+}
+
+func newHistoryCache(ttl time.Duration) *historyCache {
+	return &historyCache{
+		ttl:     ttl,
+		entries: make(map[string]historyCacheEntry),
+	}
+}
+
+// Get returns the cached history of the IP if it is younger than the TTL
+func (c *historyCache) Get(ip string) ([]*neoprotect.Attack, bool) {
+	if c == nil || c.ttl <= 0 {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, exists := c.entries[ip]
+	if !exists {
+		return nil, false
+	}
+
+	if time.Since(entry.fetchedAt) > c.ttl {
+		delete(c.entries, ip)
+		return nil, false
+	}
+
+	return entry.attacks, true
+}
+
+func (c *historyCache) Set(ip string, attacks []*neoprotect.Attack) {
+	if c == nil || c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, entry := range c.entries {
+		if time.Since(entry.fetchedAt) > c.ttl {
+			delete(c.entries, key)
+		}
+	}
+
+	c.entries[ip] = historyCacheEntry{
+		attacks:   attacks,
+		fetchedAt: time.Now(),
+	}
+}
@@ -0,0 +1,169 @@
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maintenanceSummaryLimit caps how many attacks the maintenance summary lists
+const maintenanceSummaryLimit = 10
+
+// maintenanceWindow is an active maintenance mode and the notifications it suppressed
+type maintenanceWindow struct {
+	startedAt time.Time
+	// until is when the window ends by itself, zero until it is turned off
+	until      time.Time
+	timer      *time.Timer
+	suppressed map[AttackEventType]int
+	// attacks maps the IDs of attacks with suppressed notifications to their target IP
+	attacks map[string]string
+}
+
+// StartMaintenance suppresses new attack notifications, along with the later notifications of those attacks, and
+// warnings to every integration except the console until EndMaintenance is called or, with a positive duration,
+// the duration has passed. Attacks are still tracked.
+// Starting it while it is active keeps the suppressed notifications and only changes when it ends.
+func (m *Manager) StartMaintenance(duration time.Duration) {
+	m.maintenanceMu.Lock()
+	defer m.maintenanceMu.Unlock()
+
+	window := m.maintenance
+	if window == nil {
+		window = &maintenanceWindow{
+			startedAt:  time.Now(),
+			suppressed: make(map[AttackEventType]int),
+			attacks:    make(map[string]string),
+		}
+		m.maintenance = window
+	}
+
+	if window.timer != nil {
+		window.timer.Stop()
+		window.timer = nil
+	}
+
+	window.until = time.Time{}
+	if duration > 0 {
+		window.until = time.Now().Add(duration)
+		window.timer = time.AfterFunc(duration, func() {
+			m.endMaintenance(window)
+		})
+		log.Printf("Maintenance mode on until %s, notifications are suppressed", window.until.Format(time.RFC3339))
+		return
+	}
+
+	log.Printf("Maintenance mode on until turned off, notifications are suppressed")
+}
+
+// EndMaintenance ends maintenance mode and, unless maintenanceSummary is disabled, posts a summary of the
+// suppressed notifications. It reports whether maintenance mode was active.
+func (m *Manager) EndMaintenance() bool {
+	m.maintenanceMu.Lock()
+	window := m.maintenance
+	m.maintenanceMu.Unlock()
+
+	if window == nil {
+		return false
+	}
+	return m.endMaintenance(window)
+}
+
+// endMaintenance ends the window if it is still the active one
+func (m *Manager) endMaintenance(window *maintenanceWindow) bool {
+	m.maintenanceMu.Lock()
+	if m.maintenance != window {
+		m.maintenanceMu.Unlock()
+		return false
+	}
+	m.maintenance = nil
+	if window.timer != nil {
+		window.timer.Stop()
+	}
+	m.maintenanceMu.Unlock()
+
+	summary := window.summary()
+	log.Printf("Maintenance mode off. %s", summary)
+
+	if m.config != nil && !m.config.MaintenanceSummary {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := m.NotifyWarning(ctx, "Maintenance ended", summary); err != nil {
+		log.Printf("Error notifying integrations about the end of maintenance: %v", err)
+	}
+	return true
+}
+
+// MaintenanceStatus reports whether maintenance mode is active, since when and until when. A zero until means
+// it lasts until turned off.
+func (m *Manager) MaintenanceStatus() (active bool, since, until time.Time) {
+	m.maintenanceMu.Lock()
+	defer m.maintenanceMu.Unlock()
+
+	if m.maintenance == nil {
+		return false, time.Time{}, time.Time{}
+	}
+	return true, m.maintenance.startedAt, m.maintenance.until
+}
+
+// inMaintenance reports whether maintenance mode is active and, if so, counts the event as suppressed
+func (m *Manager) inMaintenance(event *AttackEvent) bool {
+	m.maintenanceMu.Lock()
+	defer m.maintenanceMu.Unlock()
+
+	if m.maintenance == nil {
+		return false
+	}
+
+	m.maintenance.suppressed[event.Type]++
+	m.maintenance.attacks[event.Attack.ID] = event.Attack.DstAddressString
+	log.Printf("Maintenance mode: only logging %s notification about attack %s on %s", eventDescriptions[event.Type], event.Attack.ID, event.Attack.DstAddressString)
+	return true
+}
+
+// maintenanceActive reports whether maintenance mode is active without counting anything
+func (m *Manager) maintenanceActive() bool {
+	m.maintenanceMu.Lock()
+	defer m.maintenanceMu.Unlock()
+	return m.maintenance != nil
+}
+
+// summary describes how long the window lasted and what it suppressed
+func (w *maintenanceWindow) summary() string {
+	duration := formatDurationReadable(time.Since(w.startedAt))
+	if len(w.attacks) == 0 {
+		return fmt.Sprintf("Maintenance mode was active for %s, no attack notifications were suppressed.", duration)
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Maintenance mode was active for %s and suppressed %d new attack, %d update and %d ended notification(s) about %d attack(s):",
+		duration, w.suppressed[EventNewAttack], w.suppressed[EventAttackUpdate], w.suppressed[EventAttackEnded], len(w.attacks)))
+
+	ids := make([]string, 0, len(w.attacks))
+	for id := range w.attacks {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(a, b int) bool {
+		if w.attacks[ids[a]] != w.attacks[ids[b]] {
+			return w.attacks[ids[a]] < w.attacks[ids[b]]
+		}
+		return ids[a] < ids[b]
+	})
+
+	for i, id := range ids {
+		if i == maintenanceSummaryLimit {
+			b.WriteString(fmt.Sprintf("\n... and %d more", len(ids)-maintenanceSummaryLimit))
+			break
+		}
+		b.WriteString(fmt.Sprintf("\n- %s (attack %s)", w.attacks[id], id))
+	}
+
+	return b.String()
+}
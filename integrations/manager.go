@@ -9,8 +9,11 @@ import (
 	"os"
 	"path/filepath"
 	"plugin"
+	"runtime/debug"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"neoprotect-notifier/config"
 	"neoprotect-notifier/neoprotect"
@@ -24,14 +27,115 @@ type Integration interface {
 	NotifyAttackEnded(ctx context.Context, attack *neoprotect.Attack, messageID string) error
 }
 
+// WarningNotifier is optionally implemented by integrations that can deliver monitor warnings
+type WarningNotifier interface {
+	NotifyWarning(ctx context.Context, title string, message string) error
+}
+
+// ReminderNotifier is optionally implemented by integrations that can post a reminder about a still active attack.
+// messageID is the message of the attack notification, empty when it is unknown.
+type ReminderNotifier interface {
+	NotifyReminder(ctx context.Context, attack *neoprotect.Attack, messageID string) error
+}
+
+// EscalationNotifier is optionally implemented by integrations that can escalate a long-running attack to on-call
+// in the attack channel. messageID is the message of the attack notification, empty when it is unknown, and mention
+// is the configured on-call ping, empty when none is configured. Integrations without it get a monitor warning.
+type EscalationNotifier interface {
+	NotifyEscalation(ctx context.Context, attack *neoprotect.Attack, messageID string, message string, mention string) error
+}
+
+// HealthChecker is optionally implemented by integrations that can check whether they are able to deliver
+// notifications, e.g. by reaching their endpoint. Integrations without it are assumed healthy once initialized.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// ErrMessageIDUnknown is returned by integrations that delivered a message but could not determine its ID
+var ErrMessageIDUnknown = errors.New("message was sent but its ID is unknown")
+
+// ErrDestinationGone is wrapped by integrations whose destination permanently rejects notifications, e.g. a deleted
+// Discord channel. Such deliveries are not retried, and the integration is disabled after repeated failures.
+var ErrDestinationGone = errors.New("notification destination is gone")
+
+// NotifyError is returned by the Manager's Notify methods when some integrations failed to deliver a notification.
+// It maps the names of the failed integrations to their errors; errors.Is and errors.As see all of them.
+type NotifyError struct {
+	Attempted int
+	Failures  map[string]error
+}
+
+// Integrations returns the sorted names of the failed integrations
+func (e *NotifyError) Integrations() []string {
+	names := make([]string, 0, len(e.Failures))
+	for name := range e.Failures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (e *NotifyError) Error() string {
+	failures := make([]string, 0, len(e.Failures))
+	for _, name := range e.Integrations() {
+		failures = append(failures, fmt.Sprintf("%s: %v", name, e.Failures[name]))
+	}
+	return fmt.Sprintf("%d of %d integration(s) failed: %s", len(e.Failures), e.Attempted, strings.Join(failures, "; "))
+}
+
+func (e *NotifyError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failures))
+	for _, name := range e.Integrations() {
+		errs = append(errs, e.Failures[name])
+	}
+	return errs
+}
+
+// notifyFailures collects the outcome of one notification sent to several integrations concurrently
+type notifyFailures struct {
+	mu        sync.Mutex
+	attempted int
+	failures  map[string]error
+}
+
+func (f *notifyFailures) attempt() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempted++
+}
+
+func (f *notifyFailures) add(name string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failures == nil {
+		f.failures = make(map[string]error)
+	}
+	f.failures[name] = err
+}
+
+// err returns a *NotifyError if any integration failed, nil otherwise
+func (f *notifyFailures) err() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.failures) == 0 {
+		return nil
+	}
+	return &NotifyError{Attempted: f.attempted, Failures: f.failures}
+}
+
 type MessageTracker struct {
 	mu         sync.RWMutex
 	messageIDs map[string]map[string]string
+	// needsRepost marks integrations whose message for an attack cannot be edited, so the next update is posted fresh
+	needsRepost map[string]map[string]bool
+	// deliveries, if set, remembers delivered one-shot notifications across restarts
+	deliveries *DeliveryLog
 }
 
 func NewMessageTracker() *MessageTracker {
 	return &MessageTracker{
-		messageIDs: make(map[string]map[string]string),
+		messageIDs:  make(map[string]map[string]string),
+		needsRepost: make(map[string]map[string]bool),
 	}
 }
 
@@ -47,6 +151,70 @@ func (m *MessageTracker) TrackMessage(attackID, integrationName, messageID strin
 		m.messageIDs[attackID] = make(map[string]string)
 	}
 	m.messageIDs[attackID][integrationName] = messageID
+	delete(m.needsRepost[attackID], integrationName)
+}
+
+// MarkNeedsRepost records that the integration's message for the attack is unknown and must be posted again
+func (m *MessageTracker) MarkNeedsRepost(attackID, integrationName string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.needsRepost[attackID] == nil {
+		m.needsRepost[attackID] = make(map[string]bool)
+	}
+	m.needsRepost[attackID][integrationName] = true
+	delete(m.messageIDs[attackID], integrationName)
+}
+
+func (m *MessageTracker) NeedsRepost(attackID, integrationName string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.needsRepost[attackID][integrationName]
+}
+
+// UseDeliveryLog makes the tracker remember which new attack and attack ended notifications were delivered
+func (m *MessageTracker) UseDeliveryLog(deliveries *DeliveryLog) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deliveries = deliveries
+}
+
+// Delivered reports whether the integration already delivered the event for the attack, e.g. before a restart
+func (m *MessageTracker) Delivered(attackID, integrationName string, eventType AttackEventType) bool {
+	if m == nil {
+		return false
+	}
+
+	m.mu.RLock()
+	deliveries := m.deliveries
+	m.mu.RUnlock()
+
+	if !deliveries.Delivered(attackID, integrationName, eventType) {
+		return false
+	}
+	log.Printf("Skipping %s notification of attack %s for integration %s, it was already delivered", eventType, attackID, integrationName)
+	return true
+}
+
+func (m *MessageTracker) RecordDelivery(attackID, integrationName string, eventType AttackEventType) {
+	if m == nil {
+		return
+	}
+
+	m.mu.RLock()
+	deliveries := m.deliveries
+	m.mu.RUnlock()
+
+	if err := deliveries.Record(attackID, integrationName, eventType); err != nil {
+		log.Printf("Warning: Failed to record delivery of attack %s to %s: %v", attackID, integrationName, err)
+	}
+}
+
+// HasMessages reports whether a message of any integration is tracked for the attack
+func (m *MessageTracker) HasMessages(attackID string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.messageIDs[attackID]) > 0
 }
 
 func (m *MessageTracker) GetMessageID(attackID, integrationName string) string {
@@ -59,59 +227,279 @@ func (m *MessageTracker) GetMessageID(attackID, integrationName string) string {
 	return ""
 }
 
+// Len returns the number of attacks with tracked messages
+func (m *MessageTracker) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.messageIDs)
+}
+
 func (m *MessageTracker) RemoveMessage(attackID string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	delete(m.messageIDs, attackID)
+	delete(m.needsRepost, attackID)
+
+	if err := m.deliveries.Forget(attackID); err != nil {
+		log.Printf("Warning: Failed to clean up delivery log: %v", err)
+	}
 }
 
+// defaultNotifyTimeout bounds a single integration call unless notifyTimeoutSeconds is configured for it
+const defaultNotifyTimeout = 10 * time.Second
+
 type Manager struct {
 	integrations map[string]Integration
+	timeouts     map[string]time.Duration
+	priorities   map[string]int
+	groups       map[string][]string
+	filters      []namedFilter
+	retries      *retryQueue
+	audit        *AuditLog
 	directory    string
 	config       *config.Config
 	mu           sync.RWMutex
+
+	errorsMu    sync.Mutex
+	errorCounts map[string]int64
+	// goneCounts counts consecutive ErrDestinationGone failures, disabled holds the integrations disabled by them
+	goneCounts map[string]int
+	disabled   map[string]string
+
+	maintenanceMu sync.Mutex
+	maintenance   *maintenanceWindow
+
+	// heldBack holds the IDs of attacks whose announcement was held back by quiet hours or maintenance mode.
+	// Their updates and end are held back too, while announced attacks keep getting theirs.
+	heldBackMu sync.Mutex
+	heldBack   map[string]bool
 }
 
+// recordError counts a failed notification call of the named integration
+func (m *Manager) recordError(name string) {
+	m.errorsMu.Lock()
+	defer m.errorsMu.Unlock()
+	m.errorCounts[name]++
+}
+
+// recordOutcome tracks consecutive ErrDestinationGone failures of the named integration and disables it once
+// disableAfterFailures is reached. Any other outcome resets the count.
+func (m *Manager) recordOutcome(name string, err error) {
+	if m.config == nil || m.config.DisableAfterFailures <= 0 {
+		return
+	}
+
+	m.errorsMu.Lock()
+	if !errors.Is(err, ErrDestinationGone) {
+		delete(m.goneCounts, name)
+		m.errorsMu.Unlock()
+		return
+	}
+
+	m.goneCounts[name]++
+	failures := m.goneCounts[name]
+	if failures < m.config.DisableAfterFailures || m.disabled[name] != "" {
+		m.errorsMu.Unlock()
+		return
+	}
+	m.disabled[name] = err.Error()
+	m.errorsMu.Unlock()
+
+	message := fmt.Sprintf("Integration %s was disabled after %d consecutive failures, its destination is gone: %v. "+
+		"Fix its configuration and restart the notifier to enable it again.", name, failures, err)
+	log.Printf("ERROR: %s", message)
+
+	if m.config.NotifyOnDisabled {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			if err := m.NotifyWarning(ctx, "Integration disabled", message); err != nil {
+				log.Printf("Error notifying integrations about disabled integration %s: %v", name, err)
+			}
+		}()
+	}
+}
+
+// isDisabled reports whether the named integration was disabled because its destination is gone
+func (m *Manager) isDisabled(name string) bool {
+	m.errorsMu.Lock()
+	defer m.errorsMu.Unlock()
+	return m.disabled[name] != ""
+}
+
+// DisabledIntegrations returns the integrations disabled because their destination is gone, with the last error
+func (m *Manager) DisabledIntegrations() map[string]string {
+	m.errorsMu.Lock()
+	defer m.errorsMu.Unlock()
+
+	disabled := make(map[string]string, len(m.disabled))
+	for name, reason := range m.disabled {
+		disabled[name] = reason
+	}
+	return disabled
+}
+
+// ErrorCounts returns the number of failed notification calls per integration since startup
+func (m *Manager) ErrorCounts() map[string]int64 {
+	m.errorsMu.Lock()
+	defer m.errorsMu.Unlock()
+
+	counts := make(map[string]int64, len(m.errorCounts))
+	for name, count := range m.errorCounts {
+		counts[name] = count
+	}
+	return counts
+}
+
+// InitializeIntegrations initializes every loaded integration. Integrations that fail are
+// logged and removed; an error is only returned if none of them could be initialized.
 func (m *Manager) InitializeIntegrations(cfg *config.Config) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	var errs []error
 	for name, integration := range m.integrations {
-		var rawConfig map[string]interface{}
+		options, err := initializeIntegration(name, integration, cfg)
+		if err != nil {
+			log.Printf("Error: %v, disabling integration", err)
+			delete(m.integrations, name)
+			errs = append(errs, err)
+			continue
+		}
+		m.timeouts[name] = options.timeout
+		m.priorities[name] = options.priority
+		m.groups[name] = options.groups
+	}
 
-		if name == "console" {
-			if configData, ok := cfg.IntegrationConfigs[name]; ok {
-				if err := json.Unmarshal(configData, &rawConfig); err != nil {
-					return fmt.Errorf("failed to unmarshal config for %s: %w", name, err)
-				}
-			} else {
-				rawConfig = make(map[string]interface{})
-				log.Printf("Using default configuration for console integration")
-			}
-		} else {
-			configData, ok := cfg.IntegrationConfigs[name]
-			if !ok {
-				return fmt.Errorf("no configuration found for %s integration", name)
-			}
+	if len(m.integrations) == 0 {
+		return fmt.Errorf("no integrations could be initialized: %w", errors.Join(errs...))
+	}
+
+	if len(errs) > 0 {
+		log.Printf("Warning: %d integration(s) failed to initialize, continuing with the remaining %d", len(errs), len(m.integrations))
+	}
+
+	return nil
+}
 
+// integrationOptions are the manager settings every integration accepts next to its own configuration
+type integrationOptions struct {
+	// timeout bounds a single notification call
+	timeout time.Duration
+	// priority orders notifications, integrations with a higher priority are notified first
+	priority int
+	// groups restricts attack notifications to IPs in these ipGroups, all IPs when empty
+	groups []string
+}
+
+// initializeIntegration initializes the integration and returns the manager settings from its configuration
+func initializeIntegration(name string, integration Integration, cfg *config.Config) (integrationOptions, error) {
+	options := integrationOptions{timeout: defaultNotifyTimeout}
+
+	var rawConfig map[string]interface{}
+
+	if integrationType(name) == "console" {
+		if configData, ok := cfg.IntegrationConfigs[name]; ok {
 			if err := json.Unmarshal(configData, &rawConfig); err != nil {
-				return fmt.Errorf("failed to unmarshal config for %s: %w", name, err)
+				return options, fmt.Errorf("failed to unmarshal config for %s: %w", name, err)
 			}
+		} else {
+			rawConfig = make(map[string]interface{})
+			log.Printf("Using default configuration for console integration")
+		}
+	} else {
+		configData, ok := cfg.IntegrationConfigs[name]
+		if !ok {
+			return options, fmt.Errorf("no configuration found for %s integration", name)
 		}
 
-		if err := integration.Initialize(rawConfig); err != nil {
-			return fmt.Errorf("failed to initialize %s integration: %w", name, err)
+		if err := json.Unmarshal(configData, &rawConfig); err != nil {
+			return options, fmt.Errorf("failed to unmarshal config for %s: %w", name, err)
 		}
 	}
 
-	return nil
+	if seconds, ok := rawConfig["notifyTimeoutSeconds"].(float64); ok {
+		if seconds <= 0 {
+			return options, fmt.Errorf("notifyTimeoutSeconds for %s integration must be positive", name)
+		}
+		options.timeout = time.Duration(seconds * float64(time.Second))
+	}
+
+	if priority, ok := rawConfig["notifyPriority"]; ok {
+		value, isNumber := priority.(float64)
+		if !isNumber || value != float64(int(value)) {
+			return options, fmt.Errorf("notifyPriority for %s integration must be a whole number", name)
+		}
+		options.priority = int(value)
+	}
+
+	if groups, ok := rawConfig["notifyGroups"]; ok {
+		list, isList := groups.([]interface{})
+		if !isList {
+			return options, fmt.Errorf("notifyGroups for %s integration must be a list of ipGroups names", name)
+		}
+		for _, group := range list {
+			groupName, isString := group.(string)
+			if !isString || !cfg.HasGroup(groupName) {
+				return options, fmt.Errorf("notifyGroups for %s integration contains %v, which is not a group in ipGroups", name, group)
+			}
+			options.groups = append(options.groups, groupName)
+		}
+	}
+
+	if err := integration.Initialize(rawConfig); err != nil {
+		return options, fmt.Errorf("failed to initialize %s integration: %w", name, err)
+	}
+
+	return options, nil
+}
+
+// integrationContext derives the context for a single call to the named integration, bounded by its timeout
+func (m *Manager) integrationContext(ctx context.Context, name string) (context.Context, context.CancelFunc) {
+	timeout, ok := m.timeouts[name]
+	if !ok {
+		timeout = defaultNotifyTimeout
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// priorityGroups returns the integration names grouped by descending priority. Integrations in the same group
+// are notified concurrently and a group only starts once the previous one has finished, so without any
+// configured priorities all integrations are notified at once. The caller must hold m.mu.
+func (m *Manager) priorityGroups() [][]string {
+	names := make([]string, 0, len(m.integrations))
+	for name := range m.integrations {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if m.priorities[names[i]] != m.priorities[names[j]] {
+			return m.priorities[names[i]] > m.priorities[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	var groups [][]string
+	for i, name := range names {
+		if i == 0 || m.priorities[name] != m.priorities[names[i-1]] {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], name)
+	}
+	return groups
 }
 
 // NewManager creates a new integration manager
 func NewManager(directory string, enabledIntegrations []string) (*Manager, error) {
 	manager := &Manager{
 		integrations: make(map[string]Integration),
+		timeouts:     make(map[string]time.Duration),
+		priorities:   make(map[string]int),
+		groups:       make(map[string][]string),
 		directory:    directory,
+		errorCounts:  make(map[string]int64),
+		goneCounts:   make(map[string]int),
+		disabled:     make(map[string]string),
+		heldBack:     make(map[string]bool),
 	}
 
 	if err := os.MkdirAll(directory, 0755); err != nil {
@@ -136,11 +524,26 @@ func NewManager(directory string, enabledIntegrations []string) (*Manager, error
 	return manager, nil
 }
 
+// SetConfig gives the manager and Discord bot integrations the monitor configuration for quiet hours and ipGroups
 func (m *Manager) SetConfig(cfg *config.Config) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	m.config = cfg
+
+	for _, integration := range m.integrations {
+		if discordBot, ok := integration.(*DiscordBotIntegration); ok {
+			discordBot.monitorConfig = cfg
+		}
+	}
 }
 
 func (m *Manager) listIntegrationNames() string {
+	return strings.Join(m.IntegrationNames(), ", ")
+}
+
+// IntegrationNames returns the sorted names of all loaded integrations
+func (m *Manager) IntegrationNames() []string {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
@@ -148,27 +551,38 @@ func (m *Manager) listIntegrationNames() string {
 	for name := range m.integrations {
 		names = append(names, name)
 	}
-	return strings.Join(names, ", ")
+	sort.Strings(names)
+	return names
 }
 
+// Built-in integrations can be enabled multiple times using an instance suffix, e.g. "webhook.1" and "webhook.2".
+// Each instance is configured under its full name in integrationConfigs. The types come from RegisterIntegration.
 func (m *Manager) loadBuiltInIntegrations(enabledIntegrations []string) error {
-	builtIns := map[string]Integration{
-		"webhook":     &WebhookIntegration{},
-		"console":     &ConsoleIntegration{},
-		"discord":     &DiscordIntegration{},
-		"discord_bot": &DiscordBotIntegration{},
-	}
+	for _, name := range enabledIntegrations {
+		factory, ok := registeredFactory(integrationType(name))
+		if !ok {
+			continue
+		}
 
-	for name, integration := range builtIns {
-		if isEnabled(name, enabledIntegrations) {
-			m.integrations[name] = integration
-			log.Printf("Registered built-in integration: %s", name)
+		if _, exists := m.integrations[name]; exists {
+			return fmt.Errorf("integration %s is enabled more than once", name)
 		}
+
+		m.integrations[name] = factory()
+		log.Printf("Registered built-in integration: %s", name)
 	}
 
 	return nil
 }
 
+// integrationType strips the instance suffix from an integration name
+func integrationType(name string) string {
+	if i := strings.Index(name, "."); i >= 0 {
+		return name[:i]
+	}
+	return name
+}
+
 // Loads integrations from plugin files in the specified directory
 func (m *Manager) loadPluginIntegrations(directory string, enabledIntegrations []string) error {
 	files, err := os.ReadDir(directory)
@@ -217,111 +631,477 @@ func (m *Manager) loadPluginIntegrations(directory string, enabledIntegrations [
 	return nil
 }
 
+// errNilAttack is returned by the notify paths instead of passing a nil attack on to the integrations
+var errNilAttack = errors.New("no attack to notify about")
+
 // NotifyNewAttack notifies all integrations about a new attack
 func (m *Manager) NotifyNewAttack(ctx context.Context, attack *neoprotect.Attack, messageTracker *MessageTracker) error {
+	return m.notifyNewAttack(ctx, attack, messageTracker, false)
+}
+
+// notifyNewAttack announces the attack. Unless resending, integrations that already delivered it are skipped
+// and quiet hours and maintenance mode apply.
+func (m *Manager) notifyNewAttack(ctx context.Context, attack *neoprotect.Attack, messageTracker *MessageTracker, resend bool) error {
+	if attack == nil {
+		return errNilAttack
+	}
+
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	var lastErr error
+	event := m.newEvent(EventNewAttack, attack, nil)
+	if !resend {
+		m.audit.Observed(event.Type, attack)
+	}
+	quiet := !resend && (m.inMaintenance(event) || m.quietFor(attack, event.Severity))
+	m.setHeldBack(attack.ID, quiet)
+
+	return m.dispatch(ctx, event, messageTracker, func(name string) bool {
+		if quiet && integrationType(name) != "console" {
+			return false
+		}
+		return resend || !messageTracker.Delivered(attack.ID, name, EventNewAttack)
+	})
+}
+
+// NotifyAttackUpdate Notifies all integrations about an attack update
+func (m *Manager) NotifyAttackUpdate(ctx context.Context, attack *neoprotect.Attack, previous *neoprotect.Attack, messageTracker *MessageTracker) error {
+	if attack == nil {
+		return errNilAttack
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	event := m.newEvent(EventAttackUpdate, attack, previous)
+	m.audit.Observed(event.Type, attack)
+	if !m.isHeldBack(attack.ID) {
+		return m.dispatch(ctx, event, messageTracker, func(string) bool { return true })
+	}
+
+	consoleOnly := func(name string) bool { return integrationType(name) == "console" }
+	if m.inMaintenance(event) || m.quietFor(attack, event.Severity) {
+		log.Printf("Only logging %s notification about attack %s on %s, its announcement is held back", eventDescriptions[event.Type], attack.ID, attack.DstAddressString)
+		return m.dispatch(ctx, event, messageTracker, consoleOnly)
+	}
+
+	// The attack turned critical or the quiet window closed, so the held back announcement goes out now. It shows
+	// the current state, only the console that logged the announcement before gets the update.
+	log.Printf("Announcing attack %s on %s, its announcement is no longer held back", attack.ID, attack.DstAddressString)
+	m.setHeldBack(attack.ID, false)
+	announcement := m.newEvent(EventNewAttack, attack, nil)
+	announceErr := m.dispatch(ctx, announcement, messageTracker, func(name string) bool {
+		return !consoleOnly(name) && !messageTracker.Delivered(attack.ID, name, EventNewAttack)
+	})
+	return errors.Join(announceErr, m.dispatch(ctx, event, messageTracker, consoleOnly))
+}
+
+// NotifyAttackEnded Notifies all integrations about an attack that has ended
+func (m *Manager) NotifyAttackEnded(ctx context.Context, attack *neoprotect.Attack, messageTracker *MessageTracker) error {
+	return m.notifyAttackEnded(ctx, attack, messageTracker, false)
+}
+
+func (m *Manager) notifyAttackEnded(ctx context.Context, attack *neoprotect.Attack, messageTracker *MessageTracker, resend bool) error {
+	if attack == nil {
+		return errNilAttack
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	event := m.newEvent(EventAttackEnded, attack, nil)
+	if !resend {
+		m.audit.Observed(event.Type, attack)
+	}
+	quiet := !resend && m.isHeldBack(attack.ID)
+	if quiet {
+		log.Printf("Only logging %s notification about attack %s on %s, its announcement was held back", eventDescriptions[event.Type], attack.ID, attack.DstAddressString)
+	}
+	m.setHeldBack(attack.ID, false)
+
+	return m.dispatch(ctx, event, messageTracker, func(name string) bool {
+		if quiet && integrationType(name) != "console" {
+			return false
+		}
+		return resend || !messageTracker.Delivered(attack.ID, name, EventAttackEnded)
+	})
+}
+
+// setHeldBack records whether the attack's announcement was held back, resending or ending it releases it
+func (m *Manager) setHeldBack(attackID string, heldBack bool) {
+	m.heldBackMu.Lock()
+	defer m.heldBackMu.Unlock()
+
+	if heldBack {
+		m.heldBack[attackID] = true
+	} else {
+		delete(m.heldBack, attackID)
+	}
+}
+
+// isHeldBack reports whether the announcement of the attack is held back. Quiet hours and maintenance mode only
+// hold back announcements, so messages of attacks announced before never go stale.
+func (m *Manager) isHeldBack(attackID string) bool {
+	m.heldBackMu.Lock()
+	defer m.heldBackMu.Unlock()
+	return m.heldBack[attackID]
+}
+
+// newEvent builds the event shared by all integrations, labelled with the ipGroups of the target IP
+func (m *Manager) newEvent(eventType AttackEventType, attack *neoprotect.Attack, previous *neoprotect.Attack) *AttackEvent {
+	event := NewAttackEvent(eventType, attack, previous)
+	if m.config != nil {
+		event.Labels = m.config.GroupsOf(attack.DstAddressString)
+	}
+	return event
+}
+
+// dispatch delivers the event to every integration accepted by include, in priority order. Failed deliveries
+// are handed to the retry queue, and integrations with deliveries for the attack still waiting in the queue
+// get the event queued behind them so it cannot overtake them. The caller must hold m.mu.
+func (m *Manager) dispatch(ctx context.Context, event *AttackEvent, messageTracker *MessageTracker, include func(name string) bool) error {
+	var failures notifyFailures
 	wg := sync.WaitGroup{}
 
-	type notifyResult struct {
-		IntegrationName string
-		MessageID       string
-		Error           error
+	for _, group := range m.priorityGroups() {
+		for _, name := range group {
+			if !m.routedTo(name, event.Attack) || !include(name) || m.isDisabled(name) {
+				continue
+			}
+
+			if m.retries.queueBehind(name, event, messageTracker) {
+				continue
+			}
+
+			failures.attempt()
+			wg.Add(1)
+			go func(name string, integration Integration) {
+				defer wg.Done()
+
+				err := m.deliver(ctx, name, integration, event, messageTracker)
+				m.recordOutcome(name, err)
+				if err == nil {
+					return
+				}
+
+				log.Printf("Error notifying integration %s about %s: %v", name, eventDescriptions[event.Type], err)
+				m.recordError(name)
+				failures.add(name, err)
+
+				if !errors.Is(err, ErrMessageIDUnknown) && !errors.Is(err, ErrDestinationGone) {
+					m.retries.retry(name, event, messageTracker, err)
+				}
+			}(name, m.integrations[name])
+		}
+		wg.Wait()
+	}
+
+	return failures.err()
+}
+
+// routedTo reports whether the integration receives notifications about the attack, integrations restricted with
+// notifyGroups only hear about attacks on IPs in those groups and accounts can restrict their attacks to some
+// integrations
+func (m *Manager) routedTo(name string, attack *neoprotect.Attack) bool {
+	if m.config != nil && !m.config.AccountRoutesTo(attack.Account, name) {
+		return false
 	}
 
-	results := make(chan notifyResult, len(m.integrations))
+	groups := m.groups[name]
+	if len(groups) == 0 || m.config == nil {
+		return true
+	}
 
-	for name, integration := range m.integrations {
-		wg.Add(1)
-		go func(name string, integration Integration) {
-			defer wg.Done()
+	for _, group := range groups {
+		if m.config.InGroup(group, attack.DstAddressString) {
+			return true
+		}
+	}
+	return false
+}
+
+// eventDescriptions name the events in log messages
+var eventDescriptions = map[AttackEventType]string{
+	EventNewAttack:    "new attack",
+	EventAttackUpdate: "attack update",
+	EventAttackEnded:  "attack end",
+}
+
+// deliver sends the event to a single integration and records the outcome in the message tracker and audit log
+func (m *Manager) deliver(ctx context.Context, name string, integration Integration, event *AttackEvent, messageTracker *MessageTracker) (err error) {
+	ctx, cancel := m.integrationContext(ctx, name)
+	defer cancel()
+	defer func() { m.audit.Notified(name, event, err) }()
+
+	attack := event.Attack
+
+	switch event.Type {
+	case EventNewAttack:
+		msgID, err := safeNotifyNewAttack(ctx, name, integration, event)
+		if errors.Is(err, ErrMessageIDUnknown) {
+			messageTracker.RecordDelivery(attack.ID, name, EventNewAttack)
+			log.Printf("Integration %s sent the new attack notification but its message ID is unknown, the next update will be posted as a new message: %v", name, err)
+			if messageTracker != nil {
+				messageTracker.MarkNeedsRepost(attack.ID, name)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		messageTracker.RecordDelivery(attack.ID, name, EventNewAttack)
+		if msgID != "" && messageTracker != nil {
+			messageTracker.TrackMessage(attack.ID, name, msgID)
+		}
+		return nil
+
+	case EventAttackUpdate:
+		var messageID string
+		if messageTracker != nil {
+			messageID = messageTracker.GetMessageID(attack.ID, name)
+			if messageTracker.NeedsRepost(attack.ID, name) {
+				log.Printf("Reposting attack %s for integration %s, its previous message cannot be edited", attack.ID, name)
+			}
+		}
 
-			msgID, err := integration.NotifyNewAttack(ctx, attack)
-			results <- notifyResult{
-				IntegrationName: name,
-				MessageID:       msgID,
-				Error:           err,
+		newMessageID, err := safeNotifyAttackUpdate(ctx, name, integration, event, messageID)
+		if messageTracker != nil {
+			if errors.Is(err, ErrMessageIDUnknown) {
+				messageTracker.MarkNeedsRepost(attack.ID, name)
+			} else if newMessageID != "" && newMessageID != messageID {
+				messageTracker.TrackMessage(attack.ID, name, newMessageID)
+			}
+		}
+		return err
+
+	default:
+		var messageID string
+		if messageTracker != nil {
+			messageID = messageTracker.GetMessageID(attack.ID, name)
+			if messageTracker.NeedsRepost(attack.ID, name) {
+				reposted := *event
+				reposted.Repost = true
+				event = &reposted
 			}
-		}(name, integration)
+		}
+
+		if err := safeNotifyAttackEnded(ctx, name, integration, event, messageID); err != nil {
+			return err
+		}
+		messageTracker.RecordDelivery(attack.ID, name, EventAttackEnded)
+		return nil
 	}
+}
 
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
+// Resend re-issues the current notification for a known attack through all integrations, even if it was delivered
+// before or quiet hours are active.
+// Active attacks are announced again as new attacks, ended attacks get their ended notification.
+func (m *Manager) Resend(ctx context.Context, attack *neoprotect.Attack, messageTracker *MessageTracker) error {
+	if attack == nil {
+		return errNilAttack
+	}
+	if attack.IsActive() {
+		return m.notifyNewAttack(ctx, attack, messageTracker, true)
+	}
+	return m.notifyAttackEnded(ctx, attack, messageTracker, true)
+}
+
+// quietFor reports whether quiet hours are active and the attack is neither of critical severity nor above the
+// critical thresholds of the quiet hours. Notifications about such attacks only go to the console integration.
+func (m *Manager) quietFor(attack *neoprotect.Attack, severity Severity) bool {
+	if m.config == nil || !m.config.QuietHours.Active(time.Now()) || severity == SeverityCritical {
+		return false
+	}
+
+	critical := &CriticalAlertConfig{
+		MinBandwidthMbps:  m.config.QuietHours.CriticalMinBandwidthMbps,
+		MinPacketRateKpps: m.config.QuietHours.CriticalMinPacketRateKpps,
+	}
+	if critical.IsCritical(attack) {
+		return false
+	}
+
+	log.Printf("Quiet hours: only logging notification about attack %s on %s, it is below the critical thresholds", attack.ID, attack.DstAddressString)
+	return true
+}
+
+// NotifyWarning notifies all integrations implementing WarningNotifier about a monitor warning
+func (m *Manager) NotifyWarning(ctx context.Context, title string, message string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	maintenance := m.maintenanceActive()
+	if maintenance {
+		log.Printf("Maintenance mode: only logging warning %q", title)
+	}
 
-	for result := range results {
-		if result.Error != nil {
-			log.Printf("Error notifying integration %s about new attack: %v", result.IntegrationName, result.Error)
-			lastErr = result.Error
+	var failures notifyFailures
+	for name, integration := range m.integrations {
+		notifier, ok := integration.(WarningNotifier)
+		if !ok || (maintenance && integrationType(name) != "console") || m.isDisabled(name) {
+			continue
 		}
 
-		if result.MessageID != "" && messageTracker != nil {
-			messageTracker.TrackMessage(attack.ID, result.IntegrationName, result.MessageID)
+		failures.attempt()
+		callCtx, cancel := m.integrationContext(ctx, name)
+		err := safeNotifyWarning(callCtx, name, notifier, title, message)
+		cancel()
+
+		if err != nil {
+			log.Printf("Error notifying integration %s about warning: %v", name, err)
+			m.recordError(name)
+			failures.add(name, err)
 		}
 	}
 
-	return lastErr
+	return failures.err()
 }
 
-// NotifyAttackUpdate Notifies all integrations about an attack update
-func (m *Manager) NotifyAttackUpdate(ctx context.Context, attack *neoprotect.Attack, previous *neoprotect.Attack, messageTracker *MessageTracker) error {
+// NotifyReminder notifies all integrations implementing ReminderNotifier that the attack is still ongoing.
+// Like other notifications, reminders only reach the console during maintenance and quiet hours.
+func (m *Manager) NotifyReminder(ctx context.Context, attack *neoprotect.Attack, messageTracker *MessageTracker) error {
+	if attack == nil {
+		return errNilAttack
+	}
+
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	var lastErr error
-	wg := sync.WaitGroup{}
+	quiet := m.maintenanceActive() || m.quietFor(attack, eventSeverity(EventAttackUpdate, attack))
 
+	var failures notifyFailures
 	for name, integration := range m.integrations {
-		wg.Add(1)
-		go func(name string, integration Integration) {
-			defer wg.Done()
+		notifier, ok := integration.(ReminderNotifier)
+		if !ok || !m.routedTo(name, attack) || (quiet && integrationType(name) != "console") || m.isDisabled(name) {
+			continue
+		}
 
-			var messageID string
-			if messageTracker != nil {
-				messageID = messageTracker.GetMessageID(attack.ID, name)
-			}
+		var messageID string
+		if messageTracker != nil {
+			messageID = messageTracker.GetMessageID(attack.ID, name)
+		}
 
-			if err := integration.NotifyAttackUpdate(ctx, attack, previous, messageID); err != nil {
-				log.Printf("Error notifying integration %s about attack update: %v", name, err)
-				lastErr = err
-			}
-		}(name, integration)
+		failures.attempt()
+		callCtx, cancel := m.integrationContext(ctx, name)
+		err := safeNotifyReminder(callCtx, name, notifier, attack, messageID)
+		cancel()
+		m.recordOutcome(name, err)
+
+		if err != nil {
+			log.Printf("Error notifying integration %s about ongoing attack reminder: %v", name, err)
+			m.recordError(name)
+			failures.add(name, err)
+		}
 	}
 
-	wg.Wait()
-	return lastErr
+	return failures.err()
 }
 
-// NotifyAttackEnded Notifies all integrations about an attack that has ended
-func (m *Manager) NotifyAttackEnded(ctx context.Context, attack *neoprotect.Attack, messageTracker *MessageTracker) error {
+// NotifyEscalation escalates the long-running attack to on-call. Integrations implementing EscalationNotifier post
+// it next to the attack notification, the others receive it as a monitor warning.
+func (m *Manager) NotifyEscalation(ctx context.Context, attack *neoprotect.Attack, messageTracker *MessageTracker, message string, mention string) error {
+	if attack == nil {
+		return errNilAttack
+	}
+
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	var lastErr error
-	wg := sync.WaitGroup{}
+	quiet := m.maintenanceActive() || m.quietFor(attack, eventSeverity(EventAttackUpdate, attack))
 
+	var failures notifyFailures
 	for name, integration := range m.integrations {
-		wg.Add(1)
-		go func(name string, integration Integration) {
-			defer wg.Done()
+		if !m.routedTo(name, attack) || (quiet && integrationType(name) != "console") || m.isDisabled(name) {
+			continue
+		}
 
-			var messageID string
-			if messageTracker != nil {
-				messageID = messageTracker.GetMessageID(attack.ID, name)
-			}
+		var messageID string
+		if messageTracker != nil {
+			messageID = messageTracker.GetMessageID(attack.ID, name)
+		}
 
-			if err := integration.NotifyAttackEnded(ctx, attack, messageID); err != nil {
-				log.Printf("Error notifying integration %s about attack end: %v", name, err)
-				lastErr = err
-			}
-		}(name, integration)
+		callCtx, cancel := m.integrationContext(ctx, name)
+		var err error
+		switch notifier := integration.(type) {
+		case EscalationNotifier:
+			failures.attempt()
+			err = safeNotifyEscalation(callCtx, name, notifier, attack, messageID, message, mention)
+		case WarningNotifier:
+			failures.attempt()
+			err = safeNotifyWarning(callCtx, name, notifier, "Long-running attack", message)
+		default:
+			cancel()
+			continue
+		}
+		cancel()
+		m.recordOutcome(name, err)
+
+		if err != nil {
+			log.Printf("Error notifying integration %s about attack escalation: %v", name, err)
+			m.recordError(name)
+			failures.add(name, err)
+		}
+	}
+
+	return failures.err()
+}
+
+func safeNotifyNewAttack(ctx context.Context, name string, integration Integration, event *AttackEvent) (msgID string, err error) {
+	defer recoverIntegrationPanic(name, "new attack", event.Attack, &err)
+	if notifier, ok := integration.(EventNotifier); ok {
+		return notifier.NotifyEvent(ctx, event, "")
+	}
+	return integration.NotifyNewAttack(ctx, event.Attack)
+}
+
+func safeNotifyAttackUpdate(ctx context.Context, name string, integration Integration, event *AttackEvent, messageID string) (msgID string, err error) {
+	defer recoverIntegrationPanic(name, "attack update", event.Attack, &err)
+	if notifier, ok := integration.(EventNotifier); ok {
+		return notifier.NotifyEvent(ctx, event, messageID)
+	}
+	return "", integration.NotifyAttackUpdate(ctx, event.Attack, event.Previous, messageID)
+}
+
+func safeNotifyAttackEnded(ctx context.Context, name string, integration Integration, event *AttackEvent, messageID string) (err error) {
+	defer recoverIntegrationPanic(name, "attack end", event.Attack, &err)
+	if notifier, ok := integration.(EventNotifier); ok {
+		_, err = notifier.NotifyEvent(ctx, event, messageID)
+		return err
+	}
+	return integration.NotifyAttackEnded(ctx, event.Attack, messageID)
+}
+
+func safeNotifyWarning(ctx context.Context, name string, notifier WarningNotifier, title string, message string) (err error) {
+	defer recoverIntegrationPanic(name, "warning", nil, &err)
+	return notifier.NotifyWarning(ctx, title, message)
+}
+
+func safeNotifyEscalation(ctx context.Context, name string, notifier EscalationNotifier, attack *neoprotect.Attack, messageID string, message string, mention string) (err error) {
+	defer recoverIntegrationPanic(name, "escalation", attack, &err)
+	return notifier.NotifyEscalation(ctx, attack, messageID, message, mention)
+}
+
+func safeNotifyReminder(ctx context.Context, name string, notifier ReminderNotifier, attack *neoprotect.Attack, messageID string) (err error) {
+	defer recoverIntegrationPanic(name, "reminder", attack, &err)
+	return notifier.NotifyReminder(ctx, attack, messageID)
+}
+
+// recoverIntegrationPanic must be deferred; it turns a panic in an integration into an error
+func recoverIntegrationPanic(name, event string, attack *neoprotect.Attack, err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	attackID, targetIP := "unknown", "unknown"
+	if attack != nil {
+		attackID, targetIP = attack.ID, attack.DstAddressString
 	}
 
-	wg.Wait()
-	return lastErr
+	log.Printf("Recovered from panic in integration %s while handling %s (attack %s on %s): %v\n%s",
+		name, event, attackID, targetIP, r, debug.Stack())
+	*err = fmt.Errorf("integration %s panicked: %v", name, r)
 }
 
 func isEnabled(name string, enabledIntegrations []string) bool {
@@ -333,15 +1113,59 @@ func isEnabled(name string, enabledIntegrations []string) bool {
 	return false
 }
 
-func (m *Manager) Shutdown() {
+// Shutdown shuts down all integrations concurrently, after the retry queue stopped and dead-lettered the deliveries
+// it still held. Integrations still shutting down when ctx is done are abandoned and reported in the returned error.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	if err := m.retries.wait(ctx); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
+	var mu sync.Mutex
+	pending := make(map[string]bool)
+	wg := sync.WaitGroup{}
+
 	for name, integration := range m.integrations {
-		if discordBot, ok := integration.(*DiscordBotIntegration); ok {
+		discordBot, ok := integration.(*DiscordBotIntegration)
+		if !ok {
+			continue
+		}
+
+		pending[name] = true
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+
 			log.Printf("Shutting down Discord bot integration: %s", name)
 			discordBot.Shutdown()
+
+			mu.Lock()
+			delete(pending, name)
+			mu.Unlock()
+		}(name)
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		mu.Lock()
+		defer mu.Unlock()
+
+		names := make([]string, 0, len(pending))
+		for name := range pending {
+			names = append(names, name)
 		}
+		sort.Strings(names)
+		return fmt.Errorf("integration(s) %s did not shut down in time", strings.Join(names, ", "))
 	}
 }
 
@@ -369,3 +1193,73 @@ func (m *Manager) SetAPIClient(client *neoprotect.Client) {
 		log.Printf("Set API client on %d Discord bot integration(s)", discordBotCount)
 	}
 }
+
+func (m *Manager) SetMonitorStatus(status *MonitorStatus) {
+	status.SetIntegrations(m.IntegrationNames())
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, integration := range m.integrations {
+		if discordBot, ok := integration.(*DiscordBotIntegration); ok {
+			discordBot.monitorStatus = status
+		}
+	}
+}
+
+// SetBlacklist gives Discord bot integrations access to the runtime blacklist for the /blacklist command
+func (m *Manager) SetBlacklist(blacklist *Blacklist) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, integration := range m.integrations {
+		if discordBot, ok := integration.(*DiscordBotIntegration); ok {
+			discordBot.blacklist = blacklist
+		}
+	}
+}
+
+// SetPollTrigger gives Discord bot integrations a way to run an immediate poll for the /poll command
+func (m *Manager) SetPollTrigger(trigger PollTrigger) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, integration := range m.integrations {
+		if discordBot, ok := integration.(*DiscordBotIntegration); ok {
+			discordBot.pollTrigger = trigger
+		}
+	}
+}
+
+// SetAuditLog records observed attack events and every delivery attempt in the audit log
+func (m *Manager) SetAuditLog(audit *AuditLog) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.audit = audit
+}
+
+// RecordObserved adds an attack event to the audit log that is not passed on to the integrations,
+// e.g. an update held back by updateIntervalSeconds
+func (m *Manager) RecordObserved(eventType AttackEventType, attack *neoprotect.Attack) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	m.audit.Observed(eventType, attack)
+}
+
+// SetResendSource gives Discord bot integrations access to the monitor's attacks for the /resend command
+func (m *Manager) SetResendSource(knownAttacks *AttackStore, messageTracker *MessageTracker) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	source := &resendSource{
+		manager:        m,
+		knownAttacks:   knownAttacks,
+		messageTracker: messageTracker,
+	}
+
+	for _, integration := range m.integrations {
+		if discordBot, ok := integration.(*DiscordBotIntegration); ok {
+			discordBot.resendSource = source
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package integrations
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"neoprotect-notifier/config"
+	"neoprotect-notifier/neoprotect"
+)
+
+// fakeIntegration records the attacks it was notified about and runs onNotify first, if set. Notifications fail
+// with err, if set.
+type fakeIntegration struct {
+	name     string
+	onNotify func(ctx context.Context)
+	err      error
+
+	mu       sync.Mutex
+	notified []string
+}
+
+func (f *fakeIntegration) Name() string                                { return f.name }
+func (f *fakeIntegration) Initialize(cfg map[string]interface{}) error { return nil }
+
+func (f *fakeIntegration) NotifyNewAttack(ctx context.Context, attack *neoprotect.Attack) (string, error) {
+	return "", f.notify(ctx, attack)
+}
+
+func (f *fakeIntegration) NotifyAttackUpdate(ctx context.Context, attack *neoprotect.Attack, previous *neoprotect.Attack, messageID string) error {
+	return f.notify(ctx, attack)
+}
+
+func (f *fakeIntegration) NotifyAttackEnded(ctx context.Context, attack *neoprotect.Attack, messageID string) error {
+	return f.notify(ctx, attack)
+}
+
+func (f *fakeIntegration) notify(ctx context.Context, attack *neoprotect.Attack) error {
+	if f.onNotify != nil {
+		f.onNotify(ctx)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.notified = append(f.notified, attack.ID)
+	if f.err != nil {
+		return f.err
+	}
+	return ctx.Err()
+}
+
+func (f *fakeIntegration) notifiedCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.notified)
+}
+
+// newTestManager builds a manager around the given integrations without loading the built-in ones
+func newTestManager(integrations ...Integration) *Manager {
+	m := &Manager{
+		integrations: make(map[string]Integration),
+		timeouts:     make(map[string]time.Duration),
+		priorities:   make(map[string]int),
+		groups:       make(map[string][]string),
+		errorCounts:  make(map[string]int64),
+		goneCounts:   make(map[string]int),
+		disabled:     make(map[string]string),
+		heldBack:     make(map[string]bool),
+	}
+	for _, integration := range integrations {
+		m.integrations[integration.Name()] = integration
+	}
+	return m
+}
+
+func testAttack() *neoprotect.Attack {
+	start := time.Now().Add(-time.Minute)
+	return &neoprotect.Attack{ID: "attack-1", DstAddressString: "192.0.2.1", StartedAt: &start}
+}
+
+func TestPanickingIntegrationDoesNotStopOthers(t *testing.T) {
+	panicking := &fakeIntegration{name: "panicking", onNotify: func(context.Context) { panic("broken integration") }}
+	healthy := &fakeIntegration{name: "healthy"}
+	m := newTestManager(panicking, healthy)
+
+	err := m.NotifyNewAttack(context.Background(), testAttack(), NewMessageTracker())
+	if err == nil {
+		t.Fatal("NotifyNewAttack() = nil, want the panic reported as an error")
+	}
+	if healthy.notifiedCount() != 1 {
+		t.Fatalf("healthy integration was notified %d times, want 1", healthy.notifiedCount())
+	}
+	if m.ErrorCounts()["panicking"] != 1 {
+		t.Fatalf("error count of panicking integration = %d, want 1", m.ErrorCounts()["panicking"])
+	}
+}
+
+func TestNilAttackDoesNotPanicNotifyPaths(t *testing.T) {
+	integration := &fakeIntegration{name: "fake"}
+	m := newTestManager(integration)
+	ctx := context.Background()
+	tracker := NewMessageTracker()
+
+	paths := map[string]func() error{
+		"NotifyNewAttack":    func() error { return m.NotifyNewAttack(ctx, nil, tracker) },
+		"NotifyAttackUpdate": func() error { return m.NotifyAttackUpdate(ctx, nil, testAttack(), tracker) },
+		"NotifyAttackEnded":  func() error { return m.NotifyAttackEnded(ctx, nil, tracker) },
+		"NotifyReminder":     func() error { return m.NotifyReminder(ctx, nil, tracker) },
+		"Resend":             func() error { return m.Resend(ctx, nil, tracker) },
+	}
+
+	for name, notify := range paths {
+		t.Run(name, func(t *testing.T) {
+			if err := notify(); err == nil {
+				t.Fatalf("%s(nil) = nil, want error", name)
+			}
+		})
+	}
+
+	if integration.notifiedCount() != 0 {
+		t.Fatalf("integration was notified %d times about a nil attack, want 0", integration.notifiedCount())
+	}
+}
+
+func TestSlowIntegrationDoesNotHoldUpOthers(t *testing.T) {
+	slow := &fakeIntegration{name: "slow", onNotify: func(ctx context.Context) { <-ctx.Done() }}
+	fast := &fakeIntegration{name: "fast"}
+	m := newTestManager(slow, fast)
+	m.timeouts["slow"] = 50 * time.Millisecond
+
+	started := time.Now()
+	err := m.NotifyNewAttack(context.Background(), testAttack(), NewMessageTracker())
+	elapsed := time.Since(started)
+
+	if err == nil {
+		t.Fatal("NotifyNewAttack() = nil, want the timeout of the slow integration reported")
+	}
+	if fast.notifiedCount() != 1 {
+		t.Fatalf("fast integration was notified %d times, want 1", fast.notifiedCount())
+	}
+	if elapsed > time.Second {
+		t.Fatalf("notifying took %s, want the slow integration cut off after its timeout", elapsed)
+	}
+}
+
+func TestMaintenanceOnlyHoldsBackAnnouncements(t *testing.T) {
+	integration := &fakeIntegration{name: "fake"}
+	m := newTestManager(integration)
+	m.config = &config.Config{}
+	ctx := context.Background()
+	tracker := NewMessageTracker()
+
+	announced := testAttack()
+	if err := m.NotifyNewAttack(ctx, announced, tracker); err != nil {
+		t.Fatalf("NotifyNewAttack() = %v", err)
+	}
+
+	m.StartMaintenance(0)
+	defer m.EndMaintenance()
+
+	heldBack := testAttack()
+	heldBack.ID = "attack-2"
+	steps := []func() error{
+		func() error { return m.NotifyNewAttack(ctx, heldBack, tracker) },
+		func() error { return m.NotifyAttackUpdate(ctx, heldBack, heldBack, tracker) },
+		func() error { return m.NotifyAttackEnded(ctx, heldBack, tracker) },
+		func() error { return m.NotifyAttackUpdate(ctx, announced, announced, tracker) },
+		func() error { return m.NotifyAttackEnded(ctx, announced, tracker) },
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("notification failed: %v", err)
+		}
+	}
+
+	want := []string{"attack-1", "attack-1", "attack-1"}
+	integration.mu.Lock()
+	defer integration.mu.Unlock()
+	if strings.Join(integration.notified, ",") != strings.Join(want, ",") {
+		t.Fatalf("notified about %v, want %v", integration.notified, want)
+	}
+}
+
+// escalatingIntegration records the escalations it receives
+type escalatingIntegration struct {
+	fakeIntegration
+	messageID, mention string
+}
+
+func (e *escalatingIntegration) NotifyEscalation(ctx context.Context, attack *neoprotect.Attack, messageID string, message string, mention string) error {
+	e.messageID, e.mention = messageID, mention
+	return e.notify(ctx, attack)
+}
+
+// warningIntegration records the titles of the warnings it receives
+type warningIntegration struct {
+	fakeIntegration
+	titles []string
+}
+
+func (w *warningIntegration) NotifyWarning(ctx context.Context, title string, message string) error {
+	w.titles = append(w.titles, title)
+	return nil
+}
+
+func TestEscalationRepliesWithMentionOrFallsBackToWarning(t *testing.T) {
+	escalating := &escalatingIntegration{fakeIntegration: fakeIntegration{name: "escalating"}}
+	warning := &warningIntegration{fakeIntegration: fakeIntegration{name: "warning"}}
+	m := newTestManager(escalating, warning)
+
+	attack := testAttack()
+	tracker := NewMessageTracker()
+	tracker.TrackMessage(attack.ID, "escalating", "message-1")
+
+	if err := m.NotifyEscalation(context.Background(), attack, tracker, "running for 30m", "<@&42>"); err != nil {
+		t.Fatalf("NotifyEscalation() error = %v", err)
+	}
+
+	if escalating.notifiedCount() != 1 || escalating.messageID != "message-1" || escalating.mention != "<@&42>" {
+		t.Fatalf("escalation = %d call(s) on message %q with mention %q, want 1 on message-1 with <@&42>",
+			escalating.notifiedCount(), escalating.messageID, escalating.mention)
+	}
+	if len(warning.titles) != 1 || warning.titles[0] != "Long-running attack" {
+		t.Fatalf("warning titles = %v, want one long-running attack warning", warning.titles)
+	}
+}
+
+func TestEscalationMentionFallsBackToCriticalAlert(t *testing.T) {
+	critical := &CriticalAlertConfig{Mention: "<@&7>"}
+	if got := escalationMention("", critical); got != "<@&7>" {
+		t.Fatalf("escalationMention() = %q, want the critical alert mention", got)
+	}
+	if got := escalationMention("<@&42>", critical); got != "<@&42>" {
+		t.Fatalf("escalationMention() = %q, want the rule mention", got)
+	}
+
+	content := escalationContent(testAttack(), "running for 30m", "<@&42>")
+	if !strings.HasPrefix(content, "<@&42> ") || !strings.Contains(content, "running for 30m") {
+		t.Fatalf("escalationContent() = %q, want the mention followed by the message", content)
+	}
+}
+
+func TestHeldBackAnnouncementGoesOutOnceMaintenanceEnds(t *testing.T) {
+	integration := &fakeIntegration{name: "fake"}
+	console := &fakeIntegration{name: "console"}
+	m := newTestManager(integration, console)
+	m.config = &config.Config{}
+	ctx := context.Background()
+	tracker := NewMessageTracker()
+	attack := testAttack()
+
+	m.StartMaintenance(0)
+	if err := m.NotifyNewAttack(ctx, attack, tracker); err != nil {
+		t.Fatalf("NotifyNewAttack() = %v", err)
+	}
+	if err := m.NotifyAttackUpdate(ctx, attack, attack, tracker); err != nil {
+		t.Fatalf("NotifyAttackUpdate() = %v", err)
+	}
+	if got := integration.notifiedCount(); got != 0 {
+		t.Fatalf("notified %d time(s) during maintenance, want none", got)
+	}
+
+	m.EndMaintenance()
+	for i := 0; i < 2; i++ {
+		if err := m.NotifyAttackUpdate(ctx, attack, attack, tracker); err != nil {
+			t.Fatalf("NotifyAttackUpdate() = %v", err)
+		}
+	}
+
+	// The first update after maintenance releases the announcement in its place, the second is a regular update
+	if got := integration.notifiedCount(); got != 2 {
+		t.Fatalf("notified %d time(s) after maintenance, want the announcement and one update", got)
+	}
+	// The console logged everything, without announcing the attack a second time
+	if got := console.notifiedCount(); got != 4 {
+		t.Fatalf("console notified %d time(s), want the announcement and three updates", got)
+	}
+}
+
+func TestQuietHoursNeverHoldBackCriticalSeverity(t *testing.T) {
+	m := newTestManager()
+	m.config = &config.Config{QuietHours: &config.QuietHours{
+		Location: time.UTC,
+		Windows:  []config.QuietWindow{{StartMinute: 0, EndMinute: 0}},
+	}}
+
+	if !m.quietFor(testAttack(), SeverityWarning) {
+		t.Fatal("quiet hours did not hold back an attack of warning severity")
+	}
+	if m.quietFor(testAttack(), SeverityCritical) {
+		t.Fatal("quiet hours held back an attack of critical severity")
+	}
+}
@@ -0,0 +1,258 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"neoprotect-notifier/neoprotect"
+)
+
+// ntfy message priorities, see https://docs.ntfy.sh/publish/#message-priority
+const (
+	ntfyPriorityLow     = "2"
+	ntfyPriorityDefault = "3"
+	ntfyPriorityHigh    = "4"
+	ntfyPriorityUrgent  = "5"
+)
+
+// ntfySeverityPriorities and ntfySeverityTags map the severity of an attack notification to its ntfy priority and
+// tag. Updates are sent one priority lower than new attacks of the same severity.
+var (
+	ntfySeverityPriorities = map[Severity]string{
+		SeverityCritical: ntfyPriorityUrgent,
+		SeverityWarning:  ntfyPriorityHigh,
+		SeverityInfo:     ntfyPriorityLow,
+	}
+	ntfySeverityTags = map[Severity]string{
+		SeverityCritical: "rotating_light",
+		SeverityWarning:  "warning",
+		SeverityInfo:     "white_check_mark",
+	}
+)
+
+func init() {
+	RegisterIntegration("ntfy", func() Integration { return &NtfyIntegration{} })
+}
+
+type NtfyIntegration struct {
+	serverURL   string
+	topic       string
+	token       string
+	username    string
+	password    string
+	sendUpdates bool
+	client      *http.Client
+}
+
+type NtfyConfig struct {
+	ServerURL   string `json:"serverUrl"`
+	Topic       string `json:"topic"`
+	Token       string `json:"token"`
+	Username    string `json:"username"`
+	Password    string `json:"password"`
+	Timeout     int    `json:"timeout"`
+	SendUpdates bool   `json:"sendUpdates"`
+}
+
+// ntfyMessage is a single push notification published to the topic
+type ntfyMessage struct {
+	title    string
+	body     string
+	priority string
+	tags     []string
+	click    string
+}
+
+func (n *NtfyIntegration) Name() string {
+	return "ntfy"
+}
+
+func (n *NtfyIntegration) Initialize(rawConfig map[string]interface{}) error {
+	configBytes, err := json.Marshal(rawConfig)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ntfy config: %w", err)
+	}
+
+	var config NtfyConfig
+	if err := json.Unmarshal(configBytes, &config); err != nil {
+		return fmt.Errorf("failed to unmarshal ntfy config: %w", err)
+	}
+
+	if config.Topic == "" {
+		return fmt.Errorf("ntfy topic is required")
+	}
+
+	if config.ServerURL == "" {
+		config.ServerURL = "https://ntfy.sh"
+	}
+
+	if config.Token != "" && config.Username != "" {
+		return fmt.Errorf("ntfy token and username/password are mutually exclusive")
+	}
+
+	timeout := 10
+	if config.Timeout > 0 {
+		timeout = config.Timeout
+	}
+
+	n.serverURL = strings.TrimSuffix(config.ServerURL, "/")
+	n.topic = config.Topic
+	n.token = config.Token
+	n.username = config.Username
+	n.password = config.Password
+	n.sendUpdates = config.SendUpdates
+	n.client = &http.Client{
+		Timeout: time.Duration(timeout) * time.Second,
+	}
+
+	return nil
+}
+
+func (n *NtfyIntegration) NotifyNewAttack(ctx context.Context, attack *neoprotect.Attack) (string, error) {
+	return n.NotifyEvent(ctx, NewAttackEvent(EventNewAttack, attack, nil), "")
+}
+
+func (n *NtfyIntegration) NotifyAttackUpdate(ctx context.Context, attack *neoprotect.Attack, previous *neoprotect.Attack, messageID string) error {
+	_, err := n.NotifyEvent(ctx, NewAttackEvent(EventAttackUpdate, attack, previous), messageID)
+	return err
+}
+
+func (n *NtfyIntegration) NotifyAttackEnded(ctx context.Context, attack *neoprotect.Attack, messageID string) error {
+	_, err := n.NotifyEvent(ctx, NewAttackEvent(EventAttackEnded, attack, nil), messageID)
+	return err
+}
+
+// NotifyEvent publishes the event with the priority and tag of its severity
+func (n *NtfyIntegration) NotifyEvent(ctx context.Context, event *AttackEvent, messageID string) (string, error) {
+	attack := event.Attack
+	msg := ntfyMessage{
+		title:    fmt.Sprintf("DDoS attack on %s", targetLabel(attack)),
+		body:     n.attackBody(attack),
+		priority: ntfySeverityPriorities[event.Severity],
+		tags:     []string{ntfySeverityTags[event.Severity]},
+		click:    panelLink(attack.DstAddressString),
+	}
+
+	switch event.Type {
+	case EventAttackUpdate:
+		if !n.sendUpdates {
+			return "", nil
+		}
+
+		msg.title, msg.tags = fmt.Sprintf("DDoS attack on %s updated", targetLabel(attack)), append(msg.tags, "chart_with_upwards_trend")
+		if attack.Subsiding {
+			msg.title, msg.tags[1] = fmt.Sprintf("DDoS attack on %s subsiding", targetLabel(attack)), "chart_with_downwards_trend"
+		}
+		msg.priority = ntfyPriorityDefault
+		if event.Severity == SeverityCritical {
+			msg.priority = ntfyPriorityHigh
+		}
+	case EventAttackEnded:
+		msg.title = fmt.Sprintf("DDoS attack on %s ended", targetLabel(attack))
+		msg.body += fmt.Sprintf("\nDuration: %s", formatDurationReadable(attack.Duration()))
+	}
+
+	return "", n.publish(ctx, msg)
+}
+
+// NotifyReminder publishes that the attack is still ongoing
+func (n *NtfyIntegration) NotifyReminder(ctx context.Context, attack *neoprotect.Attack, messageID string) error {
+	body := n.attackBody(attack) + fmt.Sprintf("\nDuration so far: %s", formatDurationReadable(attack.Duration()))
+
+	return n.publish(ctx, ntfyMessage{
+		title:    fmt.Sprintf("DDoS attack on %s still ongoing", targetLabel(attack)),
+		body:     body,
+		priority: ntfyPriorityDefault,
+		tags:     []string{"alarm_clock"},
+		click:    panelLink(attack.DstAddressString),
+	})
+}
+
+func (n *NtfyIntegration) NotifyWarning(ctx context.Context, title string, message string) error {
+	return n.publish(ctx, ntfyMessage{
+		title:    title,
+		body:     message,
+		priority: ntfyPriorityHigh,
+		tags:     []string{"warning"},
+	})
+}
+
+func (n *NtfyIntegration) attackBody(attack *neoprotect.Attack) string {
+	body := fmt.Sprintf("Peak: %s / %s%s", formatBPS(displayedPeakBPS(attack)), formatPPS(displayedPeakPPS(attack)), samplingNote(attack.SampleRate))
+
+	if note := saturationNote(attack); note != "" {
+		body += "\n" + prefix("⚠️") + note
+	}
+
+	if names := attack.GetSignatureNamesByPeak(); len(names) > 0 {
+		body += "\nSignatures: " + strings.Join(names, ", ")
+	}
+
+	return body
+}
+
+// HealthCheck queries the health endpoint of the ntfy server
+func (n *NtfyIntegration) HealthCheck(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, n.serverURL+"/v1/health", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create ntfy request: %w", err)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach ntfy server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var health struct {
+		Healthy bool `json:"healthy"`
+	}
+	if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&health) != nil || !health.Healthy {
+		return fmt.Errorf("ntfy server reports unhealthy (status %d)", resp.StatusCode)
+	}
+	return nil
+}
+
+func (n *NtfyIntegration) publish(ctx context.Context, msg ntfyMessage) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.serverURL+"/"+n.topic, strings.NewReader(msg.body))
+	if err != nil {
+		return fmt.Errorf("failed to create ntfy request: %w", err)
+	}
+
+	req.Header.Set("Title", msg.title)
+	req.Header.Set("Priority", msg.priority)
+	if len(msg.tags) > 0 {
+		req.Header.Set("Tags", strings.Join(msg.tags, ","))
+	}
+	if msg.click != "" {
+		req.Header.Set("Click", msg.click)
+	}
+
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	} else if n.username != "" {
+		req.SetBasicAuth(n.username, n.password)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send ntfy request: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			fmt.Println("Failed to close response body")
+		}
+	}(resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("ntfy request failed with status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package integrations
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNtfyPriorityFollowsSeverity(t *testing.T) {
+	var priority, tags string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		priority, tags = r.Header.Get("Priority"), r.Header.Get("Tags")
+	}))
+	defer server.Close()
+
+	n := &NtfyIntegration{serverURL: server.URL, topic: "alerts", sendUpdates: true, client: &http.Client{Timeout: time.Second}}
+
+	tests := []struct {
+		eventType    AttackEventType
+		severity     Severity
+		wantPriority string
+		wantTags     string
+	}{
+		{EventNewAttack, SeverityCritical, ntfyPriorityUrgent, "rotating_light"},
+		{EventNewAttack, SeverityWarning, ntfyPriorityHigh, "warning"},
+		{EventAttackUpdate, SeverityCritical, ntfyPriorityHigh, "rotating_light,chart_with_upwards_trend"},
+		{EventAttackUpdate, SeverityWarning, ntfyPriorityDefault, "warning,chart_with_upwards_trend"},
+		{EventAttackEnded, SeverityInfo, ntfyPriorityLow, "white_check_mark"},
+	}
+	for _, tt := range tests {
+		event := &AttackEvent{Type: tt.eventType, Severity: tt.severity, Attack: testAttack()}
+		if _, err := n.NotifyEvent(context.Background(), event, ""); err != nil {
+			t.Fatalf("NotifyEvent(%s, %s) error = %v", tt.eventType, tt.severity, err)
+		}
+		if priority != tt.wantPriority || tags != tt.wantTags {
+			t.Errorf("NotifyEvent(%s, %s) sent priority %s with tags %q, want %s with %q", tt.eventType, tt.severity, priority, tags, tt.wantPriority, tt.wantTags)
+		}
+	}
+}
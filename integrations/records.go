@@ -0,0 +1,119 @@
+package integrations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"neoprotect-notifier/neoprotect"
+)
+
+type PeakRecord struct {
+	BPS       int64     `json:"bps"`
+	PPS       int64     `json:"pps"`
+	AttackID  string    `json:"attackId"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// PeakRecordStore keeps the all-time peak BPS/PPS per IP and persists it to disk
+type PeakRecordStore struct {
+	mu      sync.Mutex
+	path    string
+	records map[string]*PeakRecord
+}
+
+func NewPeakRecordStore(path string) (*PeakRecordStore, error) {
+	store := &PeakRecordStore{
+		path:    path,
+		records: make(map[string]*PeakRecord),
+	}
+
+	if path == "" {
+		return store, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return store, nil
+		}
+		return store, fmt.Errorf("failed to read peak records file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &store.records); err != nil {
+		return store, fmt.Errorf("failed to parse peak records file: %w", err)
+	}
+
+	return store, nil
+}
+
+// Update records the attack's peaks and returns true if it beat a previously stored record
+func (s *PeakRecordStore) Update(attack *neoprotect.Attack) (bool, error) {
+	if attack == nil || attack.DstAddressString == "" {
+		return false, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bps := attack.RecordedPeakBPS()
+	pps := attack.RecordedPeakPPS()
+
+	record, exists := s.records[attack.DstAddressString]
+	if !exists {
+		s.records[attack.DstAddressString] = &PeakRecord{
+			BPS:       bps,
+			PPS:       pps,
+			AttackID:  attack.ID,
+			UpdatedAt: time.Now(),
+		}
+		return false, s.save()
+	}
+
+	if bps <= record.BPS && pps <= record.PPS {
+		return false, nil
+	}
+
+	exceeded := record.AttackID != attack.ID
+	if bps > record.BPS {
+		record.BPS = bps
+	}
+	if pps > record.PPS {
+		record.PPS = pps
+	}
+	record.AttackID = attack.ID
+	record.UpdatedAt = time.Now()
+
+	return exceeded, s.save()
+}
+
+func (s *PeakRecordStore) Get(ip string) (PeakRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, exists := s.records[ip]
+	if !exists {
+		return PeakRecord{}, false
+	}
+	return *record, true
+}
+
+func (s *PeakRecordStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal peak records: %w", err)
+	}
+
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write peak records file: %w", err)
+	}
+
+	return nil
+}
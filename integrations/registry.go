@@ -0,0 +1,38 @@
+package integrations
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]func() Integration)
+)
+
+// RegisterIntegration makes a built-in integration type available under name. It is meant to be called from
+// an init function of the file implementing the integration and panics if name is registered twice or contains
+// a dot, which separates the type from the instance suffix in enabledIntegrations.
+func RegisterIntegration(name string, factory func() Integration) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if name == "" || strings.Contains(name, ".") {
+		panic(fmt.Sprintf("integrations: invalid integration name %q", name))
+	}
+	if factory == nil {
+		panic(fmt.Sprintf("integrations: factory for %s is nil", name))
+	}
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("integrations: integration %s is registered twice", name))
+	}
+	registry[name] = factory
+}
+
+func registeredFactory(name string) (func() Integration, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	factory, ok := registry[name]
+	return factory, ok
+}
@@ -0,0 +1,282 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// maxRetryDelay caps the doubling delay between delivery retries
+const maxRetryDelay = 5 * time.Minute
+
+// deliveryJob is a delivery waiting in the retry queue
+type deliveryJob struct {
+	integration    string
+	event          *AttackEvent
+	messageTracker *MessageTracker
+	// retries counts the failed retries, zero for jobs that were queued behind a failed delivery and not tried yet
+	retries int
+	lastErr error
+}
+
+// retryQueue retries failed deliveries in the background. Deliveries are queued per integration and attack,
+// so the events of an attack always reach an integration in the order they happened.
+type retryQueue struct {
+	manager        *Manager
+	ctx            context.Context
+	attempts       int
+	delay          time.Duration
+	deadLetterFile string
+
+	mu      sync.Mutex
+	pending map[string][]*deliveryJob
+	workers sync.WaitGroup
+
+	deadLetterMu sync.Mutex
+}
+
+// EnableRetries retries failed deliveries up to attempts times in the background, waiting delay before the
+// first retry and doubling it after every further failure. Deliveries that still fail are appended to
+// deadLetterFile as JSON lines, or only logged when it is empty. Retries stop when ctx is done, the deliveries still
+// waiting are then dead-lettered as well.
+func (m *Manager) EnableRetries(ctx context.Context, attempts int, delay time.Duration, deadLetterFile string) {
+	if attempts <= 0 {
+		return
+	}
+
+	m.retries = &retryQueue{
+		manager:        m,
+		ctx:            ctx,
+		attempts:       attempts,
+		delay:          delay,
+		deadLetterFile: deadLetterFile,
+		pending:        make(map[string][]*deliveryJob),
+	}
+}
+
+func retryKey(integrationName, attackID string) string {
+	return integrationName + "/" + attackID
+}
+
+// queueBehind queues the event if the integration still has deliveries for the attack waiting to be retried,
+// so it is not delivered before them. It reports whether the event was queued.
+func (q *retryQueue) queueBehind(integrationName string, event *AttackEvent, messageTracker *MessageTracker) bool {
+	if q == nil {
+		return false
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	key := retryKey(integrationName, event.Attack.ID)
+	if len(q.pending[key]) == 0 {
+		return false
+	}
+
+	q.pending[key] = append(q.pending[key], &deliveryJob{
+		integration:    integrationName,
+		event:          event,
+		messageTracker: messageTracker,
+	})
+	return true
+}
+
+// retry queues a failed delivery for another attempt
+func (q *retryQueue) retry(integrationName string, event *AttackEvent, messageTracker *MessageTracker, err error) {
+	if q == nil {
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	key := retryKey(integrationName, event.Attack.ID)
+	q.pending[key] = append(q.pending[key], &deliveryJob{
+		integration:    integrationName,
+		event:          event,
+		messageTracker: messageTracker,
+		lastErr:        err,
+	})
+
+	if len(q.pending[key]) == 1 {
+		q.workers.Add(1)
+		go q.work(key)
+	}
+}
+
+// work delivers the queued jobs of one integration and attack in order until the queue is empty
+func (q *retryQueue) work(key string) {
+	defer q.workers.Done()
+
+	for {
+		q.mu.Lock()
+		job := q.pending[key][0]
+		q.mu.Unlock()
+
+		select {
+		case <-q.ctx.Done():
+			q.deadLetterPending(key)
+			return
+		case <-time.After(q.backoff(job)):
+		}
+
+		if err := q.attempt(job); err != nil {
+			job.retries++
+			job.lastErr = err
+			log.Printf("Retry %d/%d of %s for integration %s failed: %v", job.retries, q.attempts, eventDescriptions[job.event.Type], job.integration, err)
+
+			// A destination that is gone will not come back by retrying
+			if job.retries < q.attempts && !errors.Is(err, ErrDestinationGone) {
+				continue
+			}
+			q.deadLetter(job)
+		} else if job.retries > 0 || job.lastErr != nil {
+			log.Printf("Delivered %s for attack %s to integration %s after retrying", eventDescriptions[job.event.Type], job.event.Attack.ID, job.integration)
+		}
+
+		q.mu.Lock()
+		q.pending[key] = q.pending[key][1:]
+		if len(q.pending[key]) == 0 {
+			delete(q.pending, key)
+			q.mu.Unlock()
+			return
+		}
+		q.mu.Unlock()
+	}
+}
+
+// errRetryShutdown is recorded for queued deliveries that were not tried yet when the notifier shut down
+var errRetryShutdown = errors.New("notifier shut down before the delivery was retried")
+
+// deadLetterPending gives up on the queued jobs of one integration and attack, on shutdown
+func (q *retryQueue) deadLetterPending(key string) {
+	q.mu.Lock()
+	jobs := q.pending[key]
+	delete(q.pending, key)
+	q.mu.Unlock()
+
+	for _, job := range jobs {
+		if job.lastErr == nil {
+			job.lastErr = errRetryShutdown
+		}
+		q.deadLetter(job)
+	}
+}
+
+// wait waits until the retry workers stopped after ctx of the queue is done, or until ctx expires
+func (q *retryQueue) wait(ctx context.Context) error {
+	if q == nil {
+		return nil
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		q.workers.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("retry queue did not stop in time: %w", ctx.Err())
+	}
+}
+
+// backoff returns how long to wait before the next attempt of the job
+func (q *retryQueue) backoff(job *deliveryJob) time.Duration {
+	if job.lastErr == nil {
+		return 0
+	}
+
+	delay := q.delay
+	for i := 0; i < job.retries && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
+
+func (q *retryQueue) attempt(job *deliveryJob) error {
+	m := q.manager
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	integration, exists := m.integrations[job.integration]
+	if !exists {
+		return fmt.Errorf("integration %s is no longer loaded", job.integration)
+	}
+	if m.isDisabled(job.integration) {
+		return fmt.Errorf("integration %s is disabled: %w", job.integration, ErrDestinationGone)
+	}
+
+	err := m.deliver(q.ctx, job.integration, integration, job.event, job.messageTracker)
+	m.recordOutcome(job.integration, err)
+	if err != nil {
+		m.recordError(job.integration)
+	}
+	return err
+}
+
+// deadLetterEntry is a delivery given up on, one JSON line per entry in the dead letter file
+type deadLetterEntry struct {
+	Time        time.Time `json:"time"`
+	Integration string    `json:"integration"`
+	Event       string    `json:"event"`
+	AttackID    string    `json:"attack_id"`
+	TargetIP    string    `json:"target_ip"`
+	Attempts    int       `json:"attempts"`
+	Error       string    `json:"error"`
+}
+
+// deadLetter records a delivery that failed all of its retries
+func (q *retryQueue) deadLetter(job *deliveryJob) {
+	log.Printf("Giving up on %s for attack %s to integration %s after %d retries: %v", eventDescriptions[job.event.Type], job.event.Attack.ID, job.integration, job.retries, job.lastErr)
+
+	if q.deadLetterFile == "" {
+		return
+	}
+
+	entry := deadLetterEntry{
+		Time:        time.Now(),
+		Integration: job.integration,
+		Event:       string(job.event.Type),
+		AttackID:    job.event.Attack.ID,
+		TargetIP:    job.event.Attack.DstAddressString,
+		Attempts:    job.retries + 1,
+		Error:       job.lastErr.Error(),
+	}
+
+	if err := q.appendDeadLetter(entry); err != nil {
+		log.Printf("Warning: failed to write dead letter file: %v", err)
+	}
+}
+
+func (q *retryQueue) appendDeadLetter(entry deadLetterEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal dead letter entry: %w", err)
+	}
+
+	q.deadLetterMu.Lock()
+	defer q.deadLetterMu.Unlock()
+
+	file, err := os.OpenFile(q.deadLetterFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open dead letter file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to append to dead letter file: %w", err)
+	}
+	return nil
+}
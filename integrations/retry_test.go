@@ -0,0 +1,104 @@
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"neoprotect-notifier/config"
+)
+
+// waitForRetries waits until the retry queue has no pending deliveries left
+func waitForRetries(t *testing.T, q *retryQueue) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		q.mu.Lock()
+		pending := len(q.pending)
+		q.mu.Unlock()
+		if pending == 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("retry queue did not drain")
+}
+
+func deadLetters(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read dead letter file: %v", err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func TestRetryStopsOnGoneDestination(t *testing.T) {
+	gone := &fakeIntegration{name: "gone", err: fmt.Errorf("channel deleted: %w", ErrDestinationGone)}
+	m := newTestManager(gone)
+	m.config = &config.Config{DisableAfterFailures: 1}
+	deadLetterFile := filepath.Join(t.TempDir(), "dead-letters.jsonl")
+	m.EnableRetries(context.Background(), 5, time.Millisecond, deadLetterFile)
+
+	event := NewAttackEvent(EventNewAttack, testAttack(), nil)
+	m.retries.retry("gone", event, NewMessageTracker(), gone.err)
+	waitForRetries(t, m.retries)
+
+	if got := gone.notifiedCount(); got != 1 {
+		t.Fatalf("delivery attempts = %d, want 1 for a gone destination", got)
+	}
+	if got := len(deadLetters(t, deadLetterFile)); got != 1 {
+		t.Fatalf("dead letters = %d, want 1", got)
+	}
+	if !m.isDisabled("gone") {
+		t.Fatal("the failed retry was not counted towards disabling the integration")
+	}
+}
+
+func TestRetrySkipsDisabledIntegration(t *testing.T) {
+	disabled := &fakeIntegration{name: "disabled"}
+	m := newTestManager(disabled)
+	m.disabled["disabled"] = "channel deleted"
+	deadLetterFile := filepath.Join(t.TempDir(), "dead-letters.jsonl")
+	m.EnableRetries(context.Background(), 5, time.Millisecond, deadLetterFile)
+
+	event := NewAttackEvent(EventNewAttack, testAttack(), nil)
+	m.retries.retry("disabled", event, NewMessageTracker(), fmt.Errorf("timeout"))
+	waitForRetries(t, m.retries)
+
+	if got := disabled.notifiedCount(); got != 0 {
+		t.Fatalf("delivery attempts = %d, want none for a disabled integration", got)
+	}
+	if got := len(deadLetters(t, deadLetterFile)); got != 1 {
+		t.Fatalf("dead letters = %d, want 1", got)
+	}
+}
+
+func TestShutdownDeadLettersPendingRetries(t *testing.T) {
+	failing := &fakeIntegration{name: "failing"}
+	m := newTestManager(failing)
+	deadLetterFile := filepath.Join(t.TempDir(), "dead-letters.jsonl")
+	ctx, cancel := context.WithCancel(context.Background())
+	m.EnableRetries(ctx, 5, time.Hour, deadLetterFile)
+
+	attack := testAttack()
+	tracker := NewMessageTracker()
+	m.retries.retry("failing", NewAttackEvent(EventNewAttack, attack, nil), tracker, fmt.Errorf("timeout"))
+	m.retries.queueBehind("failing", NewAttackEvent(EventAttackEnded, attack, nil), tracker)
+
+	cancel()
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	if got := failing.notifiedCount(); got != 0 {
+		t.Fatalf("delivery attempts = %d, want none after shutting down", got)
+	}
+	if got := len(deadLetters(t, deadLetterFile)); got != 2 {
+		t.Fatalf("dead letters = %d, want both pending deliveries", got)
+	}
+}
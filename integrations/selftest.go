@@ -0,0 +1,63 @@
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"neoprotect-notifier/neoprotect"
+)
+
+// testTargetIP is the target of the synthetic test attack, from the TEST-NET-1 documentation range
+const testTargetIP = "192.0.2.1"
+
+// SendTestSequence sends a synthetic new, update and ended notification about a test attack through only the
+// named integration. Routing, maintenance mode and quiet hours are ignored and failed deliveries are not
+// retried, so the result reflects the integration alone. It stops at the first notification that fails.
+func (m *Manager) SendTestSequence(ctx context.Context, name string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	integration, exists := m.integrations[name]
+	if !exists {
+		return fmt.Errorf("integration %s is not loaded", name)
+	}
+
+	started := time.Now().Add(-5 * time.Minute)
+	initial := &neoprotect.Attack{
+		ID:               fmt.Sprintf("test-%d", started.Unix()),
+		DstAddressString: testTargetIP,
+		StartedAt:        &started,
+		Signatures: []neoprotect.AttackSignature{
+			{ID: "test-udp", Name: "UDP Flood", StartedAt: &started, BPSPeak: 1250000000, PPSPeak: 1500000},
+		},
+	}
+
+	joined := started.Add(2 * time.Minute)
+	updated := *initial
+	updated.Signatures = []neoprotect.AttackSignature{
+		{ID: "test-udp", Name: "UDP Flood", StartedAt: &started, BPSPeak: 2500000000, PPSPeak: 3000000},
+		{ID: "test-syn", Name: "TCP SYN Flood", StartedAt: &joined, BPSPeak: 500000000, PPSPeak: 900000},
+	}
+
+	ended := updated
+	endedAt := time.Now()
+	ended.EndedAt = &endedAt
+
+	messageTracker := NewMessageTracker()
+	events := []*AttackEvent{
+		m.newEvent(EventNewAttack, initial, nil),
+		m.newEvent(EventAttackUpdate, &updated, initial),
+		m.newEvent(EventAttackEnded, &ended, nil),
+	}
+
+	for _, event := range events {
+		if err := m.deliver(ctx, name, integration, event, messageTracker); err != nil {
+			return fmt.Errorf("failed to send test %s notification: %w", eventDescriptions[event.Type], err)
+		}
+		log.Printf("Sent test %s notification through integration %s", eventDescriptions[event.Type], name)
+	}
+
+	return nil
+}
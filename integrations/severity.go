@@ -0,0 +1,81 @@
+package integrations
+
+import (
+	"fmt"
+
+	"neoprotect-notifier/neoprotect"
+)
+
+// CriticalAlertConfig marks attacks above the given peaks as critical and controls how loudly they are announced
+type CriticalAlertConfig struct {
+	MinBandwidthMbps  float64 `json:"minBandwidthMbps"`
+	MinPacketRateKpps float64 `json:"minPacketRateKpps"`
+	TTS               bool    `json:"tts"`
+	Mention           string  `json:"mention"`
+}
+
+// IsCritical reports whether the attack reaches one of the configured thresholds
+func (c *CriticalAlertConfig) IsCritical(attack *neoprotect.Attack) bool {
+	if c == nil || attack == nil {
+		return false
+	}
+
+	if c.MinBandwidthMbps > 0 && float64(attack.GetPeakBPS()*8)/1000000.0 >= c.MinBandwidthMbps {
+		return true
+	}
+
+	if c.MinPacketRateKpps > 0 && float64(attack.GetPeakPPS())/1000.0 >= c.MinPacketRateKpps {
+		return true
+	}
+
+	return false
+}
+
+// Link capacities in bits per second, configured with SetLinkCapacity
+var (
+	defaultLinkCapacityBps int64
+	linkCapacityBpsByIP    map[string]int64
+	saturationPercent      float64 = 80
+)
+
+// SetLinkCapacity configures the upstream capacity behind the protected IPs and the share of it an attack's
+// peak must reach to be flagged as a saturation risk. IPs without their own capacity use defaultBps; 0 disables the flag.
+func SetLinkCapacity(defaultBps int64, byIP map[string]int64, thresholdPercent int) {
+	defaultLinkCapacityBps = defaultBps
+	linkCapacityBpsByIP = byIP
+	if thresholdPercent > 0 {
+		saturationPercent = float64(thresholdPercent)
+	}
+}
+
+// linkCapacity returns the capacity of the link behind the IP in bits per second, or 0 if it is unknown
+func linkCapacity(ip string) int64 {
+	if capacity, ok := linkCapacityBpsByIP[ip]; ok {
+		return capacity
+	}
+	return defaultLinkCapacityBps
+}
+
+// saturationRisk returns the attack's peak bandwidth as a percentage of its target's link capacity
+// and whether it reaches the saturation threshold
+func saturationRisk(attack *neoprotect.Attack) (percent float64, atRisk bool) {
+	capacity := linkCapacity(attack.DstAddressString)
+	if capacity <= 0 {
+		return 0, false
+	}
+
+	percent = float64(attack.GetPeakBPS()*8) / float64(capacity) * 100
+	return percent, percent >= saturationPercent
+}
+
+// saturationNote describes the saturation risk, e.g. "Saturation risk: peak is 92% of the 10 Gbps link",
+// or returns an empty string if the attack is below the threshold
+func saturationNote(attack *neoprotect.Attack) string {
+	percent, atRisk := saturationRisk(attack)
+	if !atRisk {
+		return ""
+	}
+
+	capacity := formatRate(float64(linkCapacity(attack.DstAddressString)), []string{"bps", "Kbps", "Mbps", "Gbps", "Tbps"})
+	return fmt.Sprintf("Saturation risk: peak is %.0f%% of the %s link", percent, capacity)
+}
@@ -0,0 +1,118 @@
+package integrations
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MonitorStatus is shared between the attack monitor and integrations that report on its health
+type MonitorStatus struct {
+	mu                   sync.RWMutex
+	startedAt            time.Time
+	lastPollAt           time.Time
+	lastSuccessfulPollAt time.Time
+	lastError            string
+	activeAttacks        int
+	integrations         []string
+	staleWarned          bool
+	nextPollAt           time.Time
+}
+
+type MonitorStatusSnapshot struct {
+	StartedAt            time.Time
+	LastPollAt           time.Time
+	LastSuccessfulPollAt time.Time
+	LastError            string
+	ActiveAttacks        int
+	Integrations         []string
+	// StaleWarned is set while a stale attack data warning is outstanding
+	StaleWarned bool
+	// NextPollAt is when the monitor scheduled its next poll, zero before the first poll finished
+	NextPollAt time.Time
+}
+
+// PollResult summarizes what a single poll found
+type PollResult struct {
+	// ActiveAttacks is the number of monitored active attacks
+	ActiveAttacks int
+	New           int
+	Updated       int
+	Ended         int
+	// Error is the error of a failed poll, empty on success
+	Error string
+}
+
+// PollTrigger runs a poll out of band from the poll interval and waits for its result
+type PollTrigger func(ctx context.Context) (PollResult, error)
+
+func NewMonitorStatus() *MonitorStatus {
+	return &MonitorStatus{
+		startedAt: time.Now(),
+	}
+}
+
+// RecordPoll stores the outcome of a single poll of the NeoProtect API
+func (s *MonitorStatus) RecordPoll(activeAttacks int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.lastPollAt = now
+
+	if err != nil {
+		s.lastError = err.Error()
+		return
+	}
+
+	s.lastSuccessfulPollAt = now
+	s.lastError = ""
+	s.activeAttacks = activeAttacks
+}
+
+// RecordPartialPoll stores a poll that fetched only some of the attacks. It counts as failed, since ended attacks
+// cannot be detected from it, so readiness and the stale poll warning keep reporting the error until a complete poll.
+func (s *MonitorStatus) RecordPartialPoll(activeAttacks int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastPollAt = time.Now()
+	s.lastError = err.Error()
+	s.activeAttacks = activeAttacks
+}
+
+func (s *MonitorStatus) SetIntegrations(names []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.integrations = names
+}
+
+// SetStaleWarned records whether integrations were warned that attack data is stale
+func (s *MonitorStatus) SetStaleWarned(warned bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.staleWarned = warned
+}
+
+// SetNextPollAt records when the monitor runs its next poll
+func (s *MonitorStatus) SetNextPollAt(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextPollAt = t
+}
+
+func (s *MonitorStatus) Snapshot() MonitorStatusSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return MonitorStatusSnapshot{
+		StartedAt:            s.startedAt,
+		LastPollAt:           s.lastPollAt,
+		LastSuccessfulPollAt: s.lastSuccessfulPollAt,
+		LastError:            s.lastError,
+		ActiveAttacks:        s.activeAttacks,
+		Integrations:         append([]string(nil), s.integrations...),
+		StaleWarned:          s.staleWarned,
+		NextPollAt:           s.nextPollAt,
+	}
+}
@@ -0,0 +1,30 @@
+package integrations
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPartialPollIsNotASuccessfulPoll(t *testing.T) {
+	status := NewMonitorStatus()
+	status.RecordPoll(1, nil)
+	lastSuccess := status.Snapshot().LastSuccessfulPollAt
+
+	status.RecordPartialPoll(3, errors.New("page 2 failed"))
+
+	snapshot := status.Snapshot()
+	if snapshot.LastError == "" {
+		t.Fatal("LastError is empty after a partial poll")
+	}
+	if !snapshot.LastSuccessfulPollAt.Equal(lastSuccess) {
+		t.Fatal("a partial poll moved LastSuccessfulPollAt")
+	}
+	if snapshot.ActiveAttacks != 3 {
+		t.Fatalf("ActiveAttacks = %d, want the 3 attacks fetched", snapshot.ActiveAttacks)
+	}
+
+	status.RecordPoll(2, nil)
+	if status.Snapshot().LastError != "" {
+		t.Fatal("a complete poll did not clear the partial poll error")
+	}
+}
@@ -0,0 +1,146 @@
+package integrations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Subscriptions maps IPs to the Discord users who get a direct message when the IP is attacked.
+// They are persisted to disk as a JSON object of IP to user IDs. IPs are stored in their canonical form, so
+// e.g. "2001:db8::1" and "2001:0db8::1" are the same subscription.
+type Subscriptions struct {
+	mu    sync.RWMutex
+	path  string
+	users map[string]map[string]bool
+}
+
+// NewSubscriptions loads the subscriptions persisted at path. It fails if the file exists but cannot be read or
+// parsed, so that saving a new subscription does not overwrite the existing ones.
+func NewSubscriptions(path string) (*Subscriptions, error) {
+	subscriptions := &Subscriptions{
+		path:  path,
+		users: make(map[string]map[string]bool),
+	}
+
+	if path == "" {
+		return subscriptions, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return subscriptions, nil
+		}
+		return nil, fmt.Errorf("failed to read subscriptions file: %w", err)
+	}
+
+	var stored map[string][]string
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to parse subscriptions file: %w", err)
+	}
+
+	for ip, userIDs := range stored {
+		for _, userID := range userIDs {
+			subscriptions.add(ip, userID)
+		}
+	}
+
+	return subscriptions, nil
+}
+
+// Add subscribes the user to attacks on the IP and returns false if they already were
+func (s *Subscriptions) Add(ip, userID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.add(ip, userID) {
+		return false, nil
+	}
+	return true, s.save()
+}
+
+func (s *Subscriptions) add(ip, userID string) bool {
+	ip = canonicalIP(ip)
+	if s.users[ip][userID] {
+		return false
+	}
+	if s.users[ip] == nil {
+		s.users[ip] = make(map[string]bool)
+	}
+	s.users[ip][userID] = true
+	return true
+}
+
+// Remove unsubscribes the user from attacks on the IP and returns false if they were not subscribed
+func (s *Subscriptions) Remove(ip, userID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ip = canonicalIP(ip)
+	if !s.users[ip][userID] {
+		return false, nil
+	}
+
+	delete(s.users[ip], userID)
+	if len(s.users[ip]) == 0 {
+		delete(s.users, ip)
+	}
+	return true, s.save()
+}
+
+// Subscribers returns the sorted IDs of the users subscribed to the IP
+func (s *Subscriptions) Subscribers(ip string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sortedKeys(s.users[canonicalIP(ip)])
+}
+
+// ForUser returns the sorted IPs the user is subscribed to
+func (s *Subscriptions) ForUser(userID string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var ips []string
+	for ip, users := range s.users {
+		if users[userID] {
+			ips = append(ips, ip)
+		}
+	}
+	sort.Strings(ips)
+	return ips
+}
+
+// canonicalIP returns the IP in its canonical form, or unchanged if it is not a valid IP
+func canonicalIP(ip string) string {
+	if parsed := net.ParseIP(ip); parsed != nil {
+		return parsed.String()
+	}
+	return ip
+}
+
+func (s *Subscriptions) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	stored := make(map[string][]string, len(s.users))
+	for ip, users := range s.users {
+		stored[ip] = sortedKeys(users)
+	}
+
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal subscriptions: %w", err)
+	}
+
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write subscriptions file: %w", err)
+	}
+
+	return nil
+}
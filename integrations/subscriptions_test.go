@@ -0,0 +1,36 @@
+package integrations
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSubscriptionsUseCanonicalIPs(t *testing.T) {
+	subscriptions, err := NewSubscriptions(filepath.Join(t.TempDir(), "subscriptions.json"))
+	if err != nil {
+		t.Fatalf("NewSubscriptions() error = %v", err)
+	}
+
+	if _, err := subscriptions.Add("2001:0db8::0001", "user-1"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if got := subscriptions.Subscribers("2001:db8::1"); len(got) != 1 {
+		t.Fatalf("Subscribers() = %v, want the user subscribed with the long form", got)
+	}
+	if removed, _ := subscriptions.Remove("2001:db8:0::1", "user-1"); !removed {
+		t.Fatal("Remove() did not find the subscription written in another form")
+	}
+}
+
+func TestSubscriptionsFailOnUnparsableFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "subscriptions.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	subscriptions, err := NewSubscriptions(path)
+	if err == nil || subscriptions != nil {
+		t.Fatalf("NewSubscriptions() = %v, %v, want no subscriptions and an error", subscriptions, err)
+	}
+}
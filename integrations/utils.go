@@ -2,34 +2,199 @@ package integrations
 
 import (
 	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
+
+	"neoprotect-notifier/neoprotect"
 )
 
-func formatBPS(bytesPerSecond int64) string {
-	bps := bytesPerSecond * 8
-	if bps < 1000 {
-		return fmt.Sprintf("%d bps", bps)
-	} else if bps < 1000000 {
-		return fmt.Sprintf("%.2f Kbps", float64(bps)/1000.0)
-	} else if bps < 1000000000 {
-		return fmt.Sprintf("%.2f Mbps", float64(bps)/1000000.0)
-	} else if bps < 1000000000000 {
-		return fmt.Sprintf("%.2f Gbps", float64(bps)/1000000000.0)
-	} else {
-		return fmt.Sprintf("%.2f Tbps", float64(bps)/1000000000000.0)
+// ratePrecision is the number of decimal places used for scaled bandwidth and packet rates
+var ratePrecision = 2
+
+// SetRatePrecision configures the number of decimal places used when formatting rates
+func SetRatePrecision(precision int) {
+	if precision >= 0 {
+		ratePrecision = precision
+	}
+}
+
+// numberFormat holds the separators used when formatting numbers for a numberLocale
+type numberFormat struct {
+	thousands string
+	decimal   string
+}
+
+// numberFormats are the supported numberLocale values
+var numberFormats = map[string]numberFormat{
+	"en":   {thousands: ",", decimal: "."},
+	"de":   {thousands: ".", decimal: ","},
+	"fr":   {thousands: "\u202f", decimal: ","},
+	"ch":   {thousands: "'", decimal: "."},
+	"none": {thousands: "", decimal: "."},
+}
+
+// numberLocale is the format of counts and rates in human-readable output
+var numberLocale = numberFormats["en"]
+
+// SetNumberLocale configures the thousands and decimal separators of human-readable numbers
+func SetNumberLocale(locale string) {
+	if format, ok := numberFormats[locale]; ok {
+		numberLocale = format
+	}
+}
+
+// formatCount formats the count with the thousands separator of the configured numberLocale
+func formatCount(n int64) string {
+	digits := strconv.FormatInt(n, 10)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+
+	if numberLocale.thousands == "" || len(digits) <= 3 {
+		return sign + digits
+	}
+
+	var b strings.Builder
+	b.WriteString(sign)
+	head := len(digits) % 3
+	if head > 0 {
+		b.WriteString(digits[:head])
+	}
+	for i := head; i < len(digits); i += 3 {
+		if b.Len() > len(sign) {
+			b.WriteString(numberLocale.thousands)
+		}
+		b.WriteString(digits[i : i+3])
 	}
+	return b.String()
+}
+
+// panelBaseURL is the NeoProtect panel that attack links point to
+var panelBaseURL = "https://panel.neoprotect.net"
+
+// SetPanelBaseURL configures the panel used for attack links, e.g. for white-label panel domains
+func SetPanelBaseURL(baseURL string) {
+	if baseURL != "" {
+		panelBaseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// attackIDDisplayLength is how many characters of attack IDs are shown, 0 shows them in full
+var attackIDDisplayLength int
+
+// SetAttackIDDisplayLength configures how many characters of attack IDs notifications show, 0 for the full ID
+func SetAttackIDDisplayLength(length int) {
+	if length >= 0 {
+		attackIDDisplayLength = length
+	}
+}
+
+// displayAttackID shortens the attack ID to attackIDDisplayLength for display. Lookups and links keep using the
+// full ID.
+func displayAttackID(id string) string {
+	if id == "" {
+		return "unknown"
+	}
+	if attackIDDisplayLength > 0 && len(id) > attackIDDisplayLength {
+		return id[:attackIDDisplayLength]
+	}
+	return id
+}
+
+// panelLink returns the panel page listing the attacks on the given IP
+func panelLink(ip string) string {
+	return fmt.Sprintf("%s/network/ips/%s?tab=attacks", panelBaseURL, url.PathEscape(ip))
+}
+
+// sampleRateCorrection scales displayed peaks by the sample rate of the attack when enabled
+var sampleRateCorrection bool
+
+// SetSampleRateCorrection configures whether displayed peaks are estimated from the sample rate
+func SetSampleRateCorrection(enabled bool) {
+	sampleRateCorrection = enabled
+}
+
+// editsInPlace reports whether an event ("update" or "ended") edits the tracked attack message instead of
+// posting a new one. Events missing from editInPlace are edited.
+func editsInPlace(editInPlace map[string]bool, event string) bool {
+	edit, set := editInPlace[event]
+	return edit || !set
+}
+
+// displayedPeakBPS returns the peak bandwidth to display, estimated from the sample rate if enabled
+func displayedPeakBPS(attack *neoprotect.Attack) int64 {
+	if sampleRateCorrection {
+		return attack.EstimatedPeakBPS()
+	}
+	return attack.RecordedPeakBPS()
+}
+
+// displayedPeakPPS returns the peak packet rate to display, estimated from the sample rate if enabled
+func displayedPeakPPS(attack *neoprotect.Attack) int64 {
+	if sampleRateCorrection {
+		return attack.EstimatedPeakPPS()
+	}
+	return attack.RecordedPeakPPS()
+}
+
+// samplingNote labels peaks measured at the sample rate as sampled or, with the correction enabled, estimated.
+// It is empty for unsampled traffic.
+func samplingNote(sampleRate int64) string {
+	if sampleRate <= 1 {
+		return ""
+	}
+	if sampleRateCorrection {
+		return fmt.Sprintf(" (estimated from 1:%s sampling)", formatCount(sampleRate))
+	}
+	return fmt.Sprintf(" (sampled 1:%s)", formatCount(sampleRate))
+}
+
+// FormatBPS formats a rate in bytes per second as bits per second, as shown in the notifications
+func FormatBPS(bytesPerSecond int64) string {
+	return formatBPS(bytesPerSecond)
+}
+
+// FormatPPS formats a rate in packets per second, as shown in the notifications
+func FormatPPS(pps int64) string {
+	return formatPPS(pps)
+}
+
+func formatBPS(bytesPerSecond int64) string {
+	return formatRate(float64(bytesPerSecond*8), []string{"bps", "Kbps", "Mbps", "Gbps", "Tbps"})
 }
 
 func formatPPS(pps int64) string {
-	if pps < 1000 {
-		return fmt.Sprintf("%d pps", pps)
-	} else if pps < 1000000 {
-		return fmt.Sprintf("%.2f Kpps", float64(pps)/1000)
-	} else if pps < 1000000000 {
-		return fmt.Sprintf("%.2f Mpps", float64(pps)/1000000)
-	} else {
-		return fmt.Sprintf("%.2f Gpps", float64(pps)/1000000000)
+	return formatRate(float64(pps), []string{"pps", "Kpps", "Mpps", "Gpps"})
+}
+
+// formatRate scales the value to the largest unit keeping it below 1000, including after rounding
+func formatRate(value float64, units []string) string {
+	unit := 0
+	for unit < len(units)-1 && value >= 1000 {
+		value /= 1000
+		unit++
+	}
+
+	if unit > 0 && unit < len(units)-1 {
+		rounded, err := strconv.ParseFloat(strconv.FormatFloat(value, 'f', ratePrecision, 64), 64)
+		if err == nil && rounded >= 1000 {
+			value /= 1000
+			unit++
+		}
 	}
+
+	if unit == 0 {
+		return fmt.Sprintf("%d %s", int64(value), units[0])
+	}
+	formatted := strconv.FormatFloat(value, 'f', ratePrecision, 64)
+	if numberLocale.decimal != "." {
+		formatted = strings.Replace(formatted, ".", numberLocale.decimal, 1)
+	}
+	return formatted + " " + units[unit]
 }
 
 func calculatePercentageChange(old, new int64) int {
@@ -42,6 +207,27 @@ func calculatePercentageChange(old, new int64) int {
 	return int((float64(new-old) / float64(old)) * 100)
 }
 
+// observedLateThreshold is how much later than its start an attack must be first observed to be reported
+const observedLateThreshold = time.Minute
+
+// observedLate reports whether the monitor began tracking the attack noticeably after it started
+func observedLate(attack *neoprotect.Attack) bool {
+	if attack.FirstObservedAt == nil {
+		return false
+	}
+	if attack.StartedAt == nil {
+		return true
+	}
+	return attack.FirstObservedAt.Sub(*attack.StartedAt) > observedLateThreshold
+}
+
+func changeSymbol(change int64) string {
+	if change > 0 {
+		return codePrefix("📈")
+	}
+	return codePrefix("📉")
+}
+
 func formatDuration(d time.Duration) string {
 	if d < time.Minute {
 		return fmt.Sprintf("%.0f seconds", d.Seconds())
@@ -95,3 +281,289 @@ func formatDurationReadable(d time.Duration) string {
 		return fmt.Sprintf("%d days, %d hours", days, hours)
 	}
 }
+
+// targetLabel names the attacked IP in notification titles, followed by its account when several are monitored
+func targetLabel(attack *neoprotect.Attack) string {
+	if attack.Account == "" {
+		return attack.DstAddressString
+	}
+	return fmt.Sprintf("%s (%s)", attack.DstAddressString, attack.Account)
+}
+
+// formatCompactAttack renders the attack as a single line for compact notifications
+func formatCompactAttack(emoji string, status string, attack *neoprotect.Attack) string {
+	line := fmt.Sprintf("%s%s %s — %s / %s%s", prefix(emoji), targetLabel(attack), status,
+		formatBPS(displayedPeakBPS(attack)), formatPPS(displayedPeakPPS(attack)), samplingNote(attack.SampleRate))
+
+	if names := attack.GetSignatureNames(); len(names) > 0 {
+		line += " — " + strings.Join(names, ", ")
+	}
+
+	return line
+}
+
+// compactAttackLine picks the emoji and wording of the compact line for a notification event
+func compactAttackLine(event string, attack *neoprotect.Attack) string {
+	switch event {
+	case "update":
+		if attack.Subsiding {
+			return formatCompactAttack("📉", "attack subsiding", attack)
+		}
+		return formatCompactAttack("📶", "under attack", attack)
+	case "ended":
+		return formatCompactAttack("🚀", fmt.Sprintf("attack ended after %s", formatDurationReadable(attack.Duration())), attack)
+	case "reminder":
+		return formatCompactAttack("⏰", fmt.Sprintf("still under attack after %s", formatDurationReadable(attack.Duration())), attack)
+	default:
+		return formatCompactAttack("🔥", "under attack", attack)
+	}
+}
+
+// peakAggregate sums up the peaks of several attacks. The sum of peaks overstates the traffic at any moment,
+// since the attacks rarely peak at the same time, so it is shown next to the largest single attack.
+type peakAggregate struct {
+	count       int
+	largestBPS  int64
+	largestPPS  int64
+	combinedBPS int64
+	combinedPPS int64
+}
+
+// aggregatePeaks computes the largest and the combined displayed peaks of the attacks
+func aggregatePeaks(attacks []*neoprotect.Attack) peakAggregate {
+	var aggregate peakAggregate
+	for _, attack := range attacks {
+		if attack == nil {
+			continue
+		}
+
+		bps, pps := displayedPeakBPS(attack), displayedPeakPPS(attack)
+		aggregate.count++
+		aggregate.combinedBPS += bps
+		aggregate.combinedPPS += pps
+		if bps > aggregate.largestBPS {
+			aggregate.largestBPS = bps
+		}
+		if pps > aggregate.largestPPS {
+			aggregate.largestPPS = pps
+		}
+	}
+	return aggregate
+}
+
+// format labels the largest attack and, for several attacks, the combined peaks
+func (a peakAggregate) format() string {
+	largest := fmt.Sprintf("largest attack %s / %s", formatBPS(a.largestBPS), formatPPS(a.largestPPS))
+	if a.count < 2 {
+		return largest
+	}
+	return fmt.Sprintf("%s, combined peaks across %d attacks %s / %s", largest, a.count, formatBPS(a.combinedBPS), formatPPS(a.combinedPPS))
+}
+
+// defaultMaxSignatures is how many signatures the Discord integrations list before truncating
+const defaultMaxSignatures = 10
+
+// formatSignatureList lists the most significant signatures, at most maxSignatures, with a suffix for the rest
+func formatSignatureList(attack *neoprotect.Attack, maxSignatures int) string {
+	names := attack.GetSignatureNamesByPeak()
+	if len(names) == 0 {
+		return "No signatures detected"
+	}
+
+	if maxSignatures <= 0 {
+		maxSignatures = defaultMaxSignatures
+	}
+
+	var result strings.Builder
+	for i, name := range names {
+		if i == maxSignatures {
+			result.WriteString(fmt.Sprintf("… and %d more\n", len(names)-maxSignatures))
+			break
+		}
+		result.WriteString(fmt.Sprintf("• `%s`\n", name))
+	}
+
+	return result.String()
+}
+
+// ProtocolMixLimit caps how many protocols are shown in a protocol mix, in /stats and in ended notifications
+const ProtocolMixLimit = 4
+
+// formatProtocolMix renders protocol shares as e.g. "UDP 78%, TCP 20%, ICMP 2%"
+func formatProtocolMix(mix []neoprotect.ProtocolShare) string {
+	parts := make([]string, 0, len(mix))
+	for _, share := range mix {
+		parts = append(parts, fmt.Sprintf("%s %.0f%%", strings.ToUpper(share.Protocol), share.Percent))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// defaultTargetPortsLimit is how many targeted ports are shown when the monitor configuration is not available
+const defaultTargetPortsLimit = 3
+
+// portServices names the services commonly found on targeted ports
+var portServices = map[string]string{
+	"22":    "SSH",
+	"53":    "DNS",
+	"80":    "HTTP",
+	"443":   "HTTPS",
+	"3306":  "MySQL",
+	"3389":  "RDP",
+	"7777":  "SA-MP",
+	"9987":  "TeamSpeak",
+	"19132": "Minecraft Bedrock",
+	"25565": "Minecraft",
+	"27015": "Source",
+	"30120": "FiveM",
+}
+
+// dominantProtocolPercent is the share of packets a protocol needs before targeted ports are labeled with it
+const dominantProtocolPercent = 90
+
+// formatTargetPorts renders targeted ports as e.g. "udp/25565 (Minecraft), udp/80 (HTTP)". Ports are only
+// labeled with a protocol when TCP or UDP carries nearly all packets, since the port statistics do not tell.
+func formatTargetPorts(ports []string, mix []neoprotect.ProtocolShare) string {
+	protocol := ""
+	if len(mix) > 0 && mix[0].Percent >= dominantProtocolPercent {
+		if name := strings.ToLower(mix[0].Protocol); name == "tcp" || name == "udp" {
+			protocol = name + "/"
+		}
+	}
+
+	parts := make([]string, 0, len(ports))
+	for _, port := range ports {
+		part := protocol + port
+		if service, ok := portServices[port]; ok {
+			part += " (" + service + ")"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sourcePortsLimit caps how many source ports /stats shows
+const sourcePortsLimit = 3
+
+// fixedSourcePortPercent is the share of packets a single source port needs to count as a fixed source port
+const fixedSourcePortPercent = 80
+
+// ephemeralSourcePortPercent is the share of packets from ephemeral ports at which the source ports are considered
+// ephemeral, as sent by the operating system of the attacking hosts
+const ephemeralSourcePortPercent = 80
+
+// ephemeralPortStart is the start of the dynamic port range most operating systems pick ephemeral ports from
+const ephemeralPortStart = 32768
+
+// reflectionPorts names the services commonly abused for reflection and amplification, which answer from their
+// well-known port
+var reflectionPorts = map[string]string{
+	"19":    "Chargen",
+	"53":    "DNS",
+	"123":   "NTP",
+	"161":   "SNMP",
+	"389":   "CLDAP",
+	"1900":  "SSDP",
+	"3702":  "WS-Discovery",
+	"11211": "Memcached",
+	"37810": "DVR",
+}
+
+// formatSourcePorts renders the top source ports as e.g. "53 (97%), 123 (2%)"
+func formatSourcePorts(shares []neoprotect.PortShare, limit int) string {
+	if len(shares) > limit {
+		shares = shares[:limit]
+	}
+
+	parts := make([]string, 0, len(shares))
+	for _, share := range shares {
+		parts = append(parts, fmt.Sprintf("%s (%.0f%%)", share.Port, share.Percent))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sourcePortSpread fingerprints an attack by its source ports: traffic from a single well-known port of a
+// reflection service points to reflection, traffic spread over ephemeral ports to a direct attack. It is empty
+// without source port data.
+func sourcePortSpread(shares []neoprotect.PortShare) string {
+	if len(shares) == 0 {
+		return ""
+	}
+
+	if top := shares[0]; top.Percent >= fixedSourcePortPercent {
+		if service, ok := reflectionPorts[top.Port]; ok {
+			return fmt.Sprintf("fixed port %s (%s), likely reflection", top.Port, service)
+		}
+		return fmt.Sprintf("fixed port %s", top.Port)
+	}
+
+	var ephemeral float64
+	for _, share := range shares {
+		if _, reflection := reflectionPorts[share.Port]; reflection {
+			continue
+		}
+		if port, err := strconv.Atoi(share.Port); err == nil && port >= ephemeralPortStart {
+			ephemeral += share.Percent
+		}
+	}
+	if ephemeral >= ephemeralSourcePortPercent {
+		return "mostly ephemeral ports, likely direct"
+	}
+	return "mixed source ports"
+}
+
+// formatOffset renders a duration relative to the attack start, e.g. "+2m" or "+1h5m"
+func formatOffset(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+
+	offset := d.Truncate(time.Second).String()
+	if strings.HasSuffix(offset, "m0s") {
+		offset = strings.TrimSuffix(offset, "0s")
+	}
+	if strings.HasSuffix(offset, "h0m") {
+		offset = strings.TrimSuffix(offset, "0m")
+	}
+	return "+" + offset
+}
+
+// formatVectorTimeline lists when each signature joined and stopped, relative to the attack start
+func formatVectorTimeline(attack *neoprotect.Attack) string {
+	signatures := make([]neoprotect.AttackSignature, len(attack.Signatures))
+	copy(signatures, attack.Signatures)
+
+	sort.SliceStable(signatures, func(i, j int) bool {
+		if signatures[i].StartedAt == nil {
+			return false
+		}
+		if signatures[j].StartedAt == nil {
+			return true
+		}
+		return signatures[i].StartedAt.Before(*signatures[j].StartedAt)
+	})
+
+	reference := attack.StartedAt
+	if reference == nil && len(signatures) > 0 {
+		reference = signatures[0].StartedAt
+	}
+
+	var timeline strings.Builder
+	for _, sig := range signatures {
+		name := neoprotect.DisplaySignatureName(sig.Name)
+
+		if reference == nil || sig.StartedAt == nil {
+			timeline.WriteString(fmt.Sprintf("• `%s` start unknown\n", name))
+			continue
+		}
+
+		line := fmt.Sprintf("• `%s` joined at %s", name, formatOffset(sig.StartedAt.Sub(*reference)))
+		if sig.EndedAt != nil {
+			line += fmt.Sprintf(", stopped at %s", formatOffset(sig.EndedAt.Sub(*reference)))
+		} else {
+			line += ", still active"
+		}
+		timeline.WriteString(line + "\n")
+	}
+
+	return timeline.String()
+}
@@ -0,0 +1,33 @@
+package integrations
+
+import (
+	"testing"
+
+	"neoprotect-notifier/neoprotect"
+)
+
+func TestFormatBPSConvertsBytesToBits(t *testing.T) {
+	if got := FormatBPS(100); got != "800 bps" {
+		t.Fatalf("FormatBPS(100) = %q, want %q", got, "800 bps")
+	}
+	if got := FormatPPS(999); got != "999 pps" {
+		t.Fatalf("FormatPPS(999) = %q, want %q", got, "999 pps")
+	}
+}
+
+func TestSourcePortSpreadIgnoresReflectionAndRegisteredPorts(t *testing.T) {
+	tests := []struct {
+		name   string
+		shares []neoprotect.PortShare
+		want   string
+	}{
+		{"ephemeral", []neoprotect.PortShare{{Port: "40000", Percent: 50}, {Port: "51000", Percent: 40}}, "mostly ephemeral ports, likely direct"},
+		{"reflection port in dynamic range", []neoprotect.PortShare{{Port: "37810", Percent: 60}, {Port: "40000", Percent: 30}}, "mixed source ports"},
+		{"registered ports", []neoprotect.PortShare{{Port: "1900", Percent: 45}, {Port: "8080", Percent: 45}}, "mixed source ports"},
+	}
+	for _, tt := range tests {
+		if got := sourcePortSpread(tt.shares); got != tt.want {
+			t.Errorf("%s: sourcePortSpread() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -7,15 +7,21 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"neoprotect-notifier/neoprotect"
 )
 
+func init() {
+	RegisterIntegration("webhook", func() Integration { return &WebhookIntegration{} })
+}
+
 type WebhookIntegration struct {
 	url     string
 	headers map[string]string
 	timeout time.Duration
+	format  string
 	client  *http.Client
 }
 
@@ -23,6 +29,8 @@ type WebhookConfig struct {
 	URL     string            `json:"url"`
 	Headers map[string]string `json:"headers"`
 	Timeout int               `json:"timeout"`
+	// Format is "generic" for the flat payload or "slack" for Slack-compatible attachments
+	Format string `json:"format"`
 }
 
 func (w *WebhookIntegration) Name() string {
@@ -49,8 +57,17 @@ func (w *WebhookIntegration) Initialize(rawConfig map[string]interface{}) error
 		timeout = config.Timeout
 	}
 
+	switch config.Format {
+	case "":
+		config.Format = "generic"
+	case "generic", "slack":
+	default:
+		return fmt.Errorf("webhook format must be either 'generic' or 'slack'")
+	}
+
 	w.url = config.URL
 	w.headers = config.Headers
+	w.format = config.Format
 	w.timeout = time.Duration(timeout) * time.Second
 	w.client = &http.Client{
 		Timeout: w.timeout,
@@ -60,6 +77,29 @@ func (w *WebhookIntegration) Initialize(rawConfig map[string]interface{}) error
 }
 
 func (w *WebhookIntegration) NotifyNewAttack(ctx context.Context, attack *neoprotect.Attack) (string, error) {
+	return w.NotifyEvent(ctx, NewAttackEvent(EventNewAttack, attack, nil), "")
+}
+
+func (w *WebhookIntegration) NotifyAttackUpdate(ctx context.Context, attack *neoprotect.Attack, previous *neoprotect.Attack, messageID string) error {
+	_, err := w.NotifyEvent(ctx, NewAttackEvent(EventAttackUpdate, attack, previous), messageID)
+	return err
+}
+
+func (w *WebhookIntegration) NotifyAttackEnded(ctx context.Context, attack *neoprotect.Attack, messageID string) error {
+	_, err := w.NotifyEvent(ctx, NewAttackEvent(EventAttackEnded, attack, nil), messageID)
+	return err
+}
+
+func (w *WebhookIntegration) NotifyEvent(ctx context.Context, event *AttackEvent, messageID string) (string, error) {
+	if w.format == "slack" {
+		return "", w.sendWebhook(ctx, slackEventPayload(event))
+	}
+	return "", w.sendWebhook(ctx, w.eventPayload(event))
+}
+
+func (w *WebhookIntegration) eventPayload(event *AttackEvent) map[string]interface{} {
+	attack := event.Attack
+
 	attackID := attack.ID
 	if attackID == "" {
 		attackID = "unknown"
@@ -70,84 +110,249 @@ func (w *WebhookIntegration) NotifyNewAttack(ctx context.Context, attack *neopro
 		targetIP = "unknown"
 	}
 
+	// Top-level values are machine readable: rates in bytes/packets per second, times in RFC 3339.
+	// Their human-readable renderings live under "formatted".
+	formatted := map[string]interface{}{
+		"peak_bps": formatBPS(displayedPeakBPS(attack)) + samplingNote(attack.SampleRate),
+		"peak_pps": formatPPS(displayedPeakPPS(attack)) + samplingNote(attack.SampleRate),
+	}
+
 	payload := map[string]interface{}{
-		"event":           "new_attack",
+		"event":           string(event.Type),
 		"attack_id":       attackID,
 		"target_ip":       targetIP,
-		"signatures":      attack.GetSignatureNames(),
-		"peak_bps":        attack.GetPeakBPS(),
-		"peak_pps":        attack.GetPeakPPS(),
-		"notification_ts": time.Now().Format(time.RFC3339),
+		"peak_bps":        attack.RecordedPeakBPS(),
+		"peak_pps":        attack.RecordedPeakPPS(),
+		"sample_rate":     attack.SampleRate,
+		"notification_ts": event.Timestamp.Format(time.RFC3339),
+		"formatted":       formatted,
 	}
 
-	if attack.StartedAt != nil {
-		payload["started_at"] = formatTimeToLocal(attack.StartedAt)
+	if attack.Account != "" {
+		payload["account"] = attack.Account
 	}
 
-	return "", w.sendWebhook(ctx, payload)
-}
+	if event.Severity != "" {
+		payload["severity"] = string(event.Severity)
+	}
 
-func (w *WebhookIntegration) NotifyAttackUpdate(ctx context.Context, attack *neoprotect.Attack, previous *neoprotect.Attack, messageID string) error {
-	diff := attack.CalculateDiff(previous)
+	if len(event.Labels) > 0 {
+		payload["labels"] = event.Labels
+	}
 
-	attackID := attack.ID
-	if attackID == "" {
-		attackID = "unknown"
+	if attack.SampleRate > 1 {
+		payload["estimated_peak_bps"] = attack.EstimatedPeakBPS()
+		payload["estimated_peak_pps"] = attack.EstimatedPeakPPS()
 	}
 
-	targetIP := attack.DstAddressString
-	if targetIP == "" {
-		targetIP = "unknown"
+	if linkCapacity(attack.DstAddressString) > 0 {
+		percent, atRisk := saturationRisk(attack)
+		payload["link_utilization_percent"] = percent
+		payload["saturation_risk"] = atRisk
 	}
 
-	payload := map[string]interface{}{
-		"event":              "attack_update",
-		"attack_id":          attackID,
-		"target_ip":          targetIP,
-		"current_signatures": attack.GetSignatureNames(),
-		"peak_bps":           attack.GetPeakBPS(),
-		"peak_pps":           attack.GetPeakPPS(),
-		"notification_ts":    time.Now().Format(time.RFC3339),
+	switch event.Type {
+	case EventAttackUpdate:
+		payload["current_signatures"] = attack.GetSignatureNames()
+		payload["changes"] = event.Diff
+		payload["new_record_peak"] = attack.NewRecordPeak
+		payload["subsiding"] = attack.Subsiding
+		payload["correlated_ips"] = attack.CorrelatedIPs
+	case EventAttackEnded:
+		payload["signatures"] = attack.GetSignatureNames()
+		payload["ended_at"] = rfc3339(attack.EndedAt)
+		payload["duration_seconds"] = int64(attack.Duration().Seconds())
+		payload["protocol_mix"] = attack.ProtocolMix
+		payload["target_ports"] = attack.TargetPorts
+		formatted["ended_at"] = formatTimeToLocal(attack.EndedAt)
+		formatted["duration"] = formatDurationReadable(attack.Duration())
+	default:
+		payload["signatures"] = attack.GetSignatureNames()
+		payload["correlated_ips"] = attack.CorrelatedIPs
 	}
 
-	if attack.StartedAt != nil {
-		payload["started_at"] = formatTimeToLocal(attack.StartedAt)
+	if event.Type == EventAttackEnded || attack.StartedAt != nil {
+		payload["started_at"] = rfc3339(attack.StartedAt)
+		formatted["started_at"] = formatTimeToLocal(attack.StartedAt)
 	}
 
-	if diff != nil {
-		payload["changes"] = diff
+	if attack.FirstObservedAt != nil {
+		payload["first_observed_at"] = rfc3339(attack.FirstObservedAt)
+		formatted["first_observed_at"] = formatTimeToLocal(attack.FirstObservedAt)
 	}
 
-	return w.sendWebhook(ctx, payload)
+	return payload
 }
 
-func (w *WebhookIntegration) NotifyAttackEnded(ctx context.Context, attack *neoprotect.Attack, messageID string) error {
-	attackID := attack.ID
-	if attackID == "" {
-		attackID = "unknown"
+// rfc3339 formats the time for machine consumers, nil becomes null
+func rfc3339(t *time.Time) interface{} {
+	if t == nil {
+		return nil
 	}
+	return t.UTC().Format(time.RFC3339)
+}
 
-	targetIP := attack.DstAddressString
-	if targetIP == "" {
-		targetIP = "unknown"
+func (w *WebhookIntegration) NotifyWarning(ctx context.Context, title string, message string) error {
+	if w.format == "slack" {
+		return w.sendWebhook(ctx, slackPayload(title, slackAttachment{
+			Color:    slackColor(DiscordColorYellow),
+			Title:    title,
+			Text:     message,
+			Fallback: title + ": " + message,
+			Ts:       time.Now().Unix(),
+		}))
 	}
 
 	payload := map[string]interface{}{
-		"event":           "attack_ended",
-		"attack_id":       attackID,
-		"target_ip":       targetIP,
-		"started_at":      formatTimeToLocal(attack.StartedAt),
-		"ended_at":        formatTimeToLocal(attack.EndedAt),
-		"duration":        formatDurationReadable(attack.Duration()),
-		"signatures":      attack.GetSignatureNames(),
-		"peak_bps":        attack.GetPeakBPS(),
-		"peak_pps":        attack.GetPeakPPS(),
+		"event":           "monitor_warning",
+		"title":           title,
+		"message":         message,
 		"notification_ts": time.Now().Format(time.RFC3339),
 	}
 
 	return w.sendWebhook(ctx, payload)
 }
 
+// NotifyReminder sends an attack_reminder event for an attack that is still ongoing
+func (w *WebhookIntegration) NotifyReminder(ctx context.Context, attack *neoprotect.Attack, messageID string) error {
+	duration := formatDurationReadable(attack.Duration())
+
+	if w.format == "slack" {
+		title := "DDoS attack on " + targetLabel(attack) + " still ongoing"
+		return w.sendWebhook(ctx, slackPayload(title, slackAttachment{
+			Color:     slackColor(DiscordColorRed),
+			Title:     title,
+			TitleLink: panelLink(attack.DstAddressString),
+			Text:      fmt.Sprintf("Running for %s, peak %s / %s%s", duration, formatBPS(displayedPeakBPS(attack)), formatPPS(displayedPeakPPS(attack)), samplingNote(attack.SampleRate)),
+			Fallback:  fmt.Sprintf("%s after %s", title, duration),
+			Ts:        time.Now().Unix(),
+		}))
+	}
+
+	payload := map[string]interface{}{
+		"event":            "attack_reminder",
+		"attack_id":        attack.ID,
+		"target_ip":        attack.DstAddressString,
+		"peak_bps":         attack.RecordedPeakBPS(),
+		"peak_pps":         attack.RecordedPeakPPS(),
+		"duration_seconds": int64(attack.Duration().Seconds()),
+		"notification_ts":  time.Now().Format(time.RFC3339),
+		"formatted": map[string]interface{}{
+			"duration": duration,
+		},
+	}
+	if attack.Account != "" {
+		payload["account"] = attack.Account
+	}
+
+	return w.sendWebhook(ctx, payload)
+}
+
+// slackAttachment is a message attachment in the Slack webhook format, also accepted by Mattermost and Rocket.Chat
+type slackAttachment struct {
+	Color     string       `json:"color"`
+	Title     string       `json:"title"`
+	TitleLink string       `json:"title_link,omitempty"`
+	Text      string       `json:"text,omitempty"`
+	Fallback  string       `json:"fallback"`
+	Fields    []slackField `json:"fields,omitempty"`
+	Ts        int64        `json:"ts"`
+}
+
+type slackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+func slackPayload(text string, attachment slackAttachment) map[string]interface{} {
+	return map[string]interface{}{
+		"text":        text,
+		"attachments": []slackAttachment{attachment},
+	}
+}
+
+// slackColor renders a Discord embed color as the hex color of a Slack attachment bar
+func slackColor(color int) string {
+	return fmt.Sprintf("#%06X", color)
+}
+
+// slackEventPayload renders the event as a Slack attachment with the same colors as the Discord embeds
+func slackEventPayload(event *AttackEvent) map[string]interface{} {
+	attack := event.Attack
+
+	color, title := DiscordColorRed, "New DDoS attack on "+targetLabel(attack)
+	switch event.Type {
+	case EventAttackUpdate:
+		color, title = DiscordColorYellow, "DDoS attack on "+targetLabel(attack)+" updated"
+		if attack.Subsiding {
+			color, title = DiscordColorBlue, "DDoS attack on "+targetLabel(attack)+" subsiding"
+		}
+	case EventAttackEnded:
+		color, title = DiscordColorGreen, "DDoS attack on "+targetLabel(attack)+" ended"
+	}
+
+	fields := []slackField{
+		{Title: "Attack ID", Value: displayAttackID(attack.ID), Short: true},
+		{Title: "Target IP", Value: attack.DstAddressString, Short: true},
+		{Title: "Peak Bandwidth", Value: formatBPS(displayedPeakBPS(attack)) + samplingNote(attack.SampleRate), Short: true},
+		{Title: "Peak Packet Rate", Value: formatPPS(displayedPeakPPS(attack)) + samplingNote(attack.SampleRate), Short: true},
+	}
+
+	if attack.Account != "" {
+		fields = append(fields, slackField{Title: "Account", Value: attack.Account, Short: true})
+	}
+
+	if attack.StartedAt != nil {
+		fields = append(fields, slackField{Title: "Started", Value: formatTimeToLocal(attack.StartedAt), Short: true})
+	}
+	if event.Type == EventAttackEnded {
+		fields = append(fields, slackField{Title: "Duration", Value: formatDurationReadable(attack.Duration()), Short: true})
+		if len(attack.TargetPorts) > 0 {
+			fields = append(fields, slackField{Title: "Targeted Ports", Value: formatTargetPorts(attack.TargetPorts, attack.ProtocolMix)})
+		}
+	}
+	if names := attack.GetSignatureNamesByPeak(); len(names) > 0 {
+		fields = append(fields, slackField{Title: "Signatures", Value: strings.Join(names, ", ")})
+	}
+	if note := saturationNote(attack); note != "" {
+		fields = append(fields, slackField{Title: "Link Saturation", Value: note})
+	}
+
+	return slackPayload(title, slackAttachment{
+		Color:     slackColor(color),
+		Title:     title,
+		TitleLink: panelLink(attack.DstAddressString),
+		Fallback:  fmt.Sprintf("%s, peak %s / %s%s", title, formatBPS(displayedPeakBPS(attack)), formatPPS(displayedPeakPPS(attack)), samplingNote(attack.SampleRate)),
+		Fields:    fields,
+		Ts:        event.Timestamp.Unix(),
+	})
+}
+
+// HealthCheck sends a HEAD request to the webhook URL. Any response short of a server error counts as
+// reachable, since endpoints commonly reject methods other than POST.
+func (w *WebhookIntegration) HealthCheck(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, w.url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+
+	for key, value := range w.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach webhook: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
 func (w *WebhookIntegration) sendWebhook(ctx context.Context, payload map[string]interface{}) error {
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
@@ -2,12 +2,20 @@ package main
 
 import (
 	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"expvar"
 	"flag"
-	_ "fmt"
+	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	_ "path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -17,92 +25,1097 @@ import (
 	"neoprotect-notifier/neoprotect"
 )
 
+// attackPruneInterval is how often ended attacks are pruned independently of polling
+const attackPruneInterval = 10 * time.Minute
+
+// debugLogging enables the Debug: log lines tracing the progress of poll cycles
+var debugLogging bool
+
+func debugf(format string, args ...interface{}) {
+	if debugLogging {
+		log.Printf("Debug: "+format, args...)
+	}
+}
+
 func main() {
 	configPath := flag.String("config", "config.json", "Path to configuration file")
+	once := flag.Bool("once", false, "Perform a single scan, print a summary and exit")
+	onceAttackExitCode := flag.Int("once-attack-exit-code", 1, "Exit code used by --once when active attacks are found")
+	generateConfig := flag.Bool("generate-config", false, "Write an example configuration to the -config path and exit")
+	force := flag.Bool("force", false, "Allow --generate-config to overwrite an existing file")
+	testIntegration := flag.String("test-integration", "", "Send a synthetic new/update/ended attack through only the named integration and exit")
 	flag.Parse()
 
-	log.SetOutput(os.Stdout)
-	log.SetFlags(log.LstdFlags | log.Lshortfile)
-	log.Println("Starting NeoProtect Attack Notifier")
+	if *generateConfig {
+		if err := config.WriteExample(*configPath, *force); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to generate configuration: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Wrote example configuration to %s\n", *configPath)
+		return
+	}
+
+	log.SetOutput(os.Stdout)
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	log.Println("Starting NeoProtect Attack Notifier")
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	neoprotect.SetSignatureDisplayNames(cfg.SignatureNames)
+	integrations.SetRatePrecision(cfg.RatePrecision)
+	integrations.SetSampleRateCorrection(cfg.CorrectSampleRate)
+	integrations.SetEmojiStyle(cfg.EmojiStyle)
+	integrations.SetNumberLocale(cfg.NumberLocale)
+	integrations.SetPanelBaseURL(cfg.PanelBaseURL)
+	integrations.SetAttackIDDisplayLength(cfg.AttackIDLength)
+	integrations.SetLinkCapacity(cfg.LinkCapacityBps, cfg.LinkCapacityBpsByIP, cfg.SaturationPercent)
+	debugLogging = cfg.DebugLogging
+
+	if *testIntegration != "" {
+		os.Exit(runIntegrationTest(cfg, *testIntegration))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Each account is monitored with its own client and status, the integrations are shared
+	accounts := cfg.AccountConfigs()
+	clients := make([]*neoprotect.Client, len(accounts))
+	statuses := make([]*integrations.MonitorStatus, len(accounts))
+	for i, accountCfg := range accounts {
+		client, err := newClient(accountCfg)
+		if err != nil {
+			log.Fatalf("Failed to create NeoProtect client%s: %v", accountLabel(accountCfg.AccountName), err)
+		}
+		clients[i] = client
+		statuses[i] = integrations.NewMonitorStatus()
+	}
+
+	integrationManager, err := integrations.NewManager("./integrations", cfg.EnabledIntegrations)
+	if err != nil {
+		log.Fatalf("Failed to initialize integration manager: %v", err)
+	}
+
+	integrationManager.SetConfig(cfg)
+
+	if err := integrationManager.InitializeIntegrations(cfg); err != nil {
+		log.Fatalf("Failed to initialize integrations: %v", err)
+	}
+
+	if err := integrationManager.LoadFilterPlugins(cfg.FilterPlugins); err != nil {
+		log.Fatalf("Failed to load filter plugins: %v", err)
+	}
+
+	integrationManager.EnableRetries(ctx, cfg.DeliveryRetryAttempts, cfg.DeliveryRetryDelay, cfg.DeadLetterFile)
+	integrationManager.SetAuditLog(integrations.NewAuditLog(cfg.AuditLogFile, int64(cfg.AuditLogMaxSizeMB)*1024*1024, cfg.AuditLogBackups))
+
+	if cfg.MaintenanceMode {
+		integrationManager.StartMaintenance(0)
+	}
+
+	// Commands of the Discord bot act on the first account
+	log.Println("Setting NeoProtect API client on integrations...")
+	integrationManager.SetAPIClient(clients[0])
+
+	integrationManager.SetMonitorStatus(statuses[0])
+	for _, status := range statuses[1:] {
+		status.SetIntegrations(integrationManager.IntegrationNames())
+	}
+
+	blacklist, err := integrations.NewBlacklist(cfg.BlacklistedIPs, cfg.BlacklistFile)
+	if err != nil {
+		log.Printf("Warning: failed to load blacklist file, using the configured blacklist only and refusing to change it until the file is fixed: %v", err)
+	}
+	integrationManager.SetBlacklist(blacklist)
+
+	if *once {
+		exitCode := 0
+		for i, accountCfg := range accounts {
+			code := runOnce(ctx, clients[i], integrationManager, statuses[i], blacklist, accountCfg, *onceAttackExitCode)
+			if code == onceErrorExitCode || exitCode == 0 {
+				exitCode = code
+			}
+		}
+		cancel()
+
+		shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		if err := integrationManager.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+		cancelShutdown()
+		os.Exit(exitCode)
+	}
+
+	if cfg.HealthListenAddr != "" {
+		go serveHealthProbes(ctx, cfg.HealthListenAddr, statuses, integrationManager, cfg.LivenessGrace)
+	}
+
+	pollNow := make([]chan struct{}, len(accounts))
+	for i := range pollNow {
+		pollNow[i] = make(chan struct{}, 1)
+	}
+	if cfg.InboundWebhookAddr != "" {
+		go serveInboundWebhook(ctx, cfg.InboundWebhookAddr, cfg.InboundWebhookSecret, pollNow)
+	}
+
+	var wg sync.WaitGroup
+	for i, accountCfg := range accounts {
+		client, status := clients[i], statuses[i]
+		primary := i == 0
+
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			monitorAttacks(ctx, client, integrationManager, status, blacklist, pollNow[i], primary, accountCfg.PollInterval, accountCfg)
+		}()
+		go func() {
+			defer wg.Done()
+			watchPollHealth(ctx, integrationManager, status, accountCfg.AccountName, accountCfg.PollInterval, accountCfg.StaleAfterPolls)
+		}()
+		if accountCfg.WarnOnIPRemoval {
+			var specificIPs []string
+			if accountCfg.MonitorMode == "specific" {
+				specificIPs = accountCfg.SpecificIPs
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				watchAccountIPs(ctx, client, integrationManager, accountCfg.AccountName, accountCfg.PollInterval, specificIPs)
+			}()
+		}
+	}
+	// The desired settings name IPs of any account, they are checked against the first account only
+	if len(cfg.DesiredIPSettings) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			watchIPSettings(ctx, clients[0], integrationManager, cfg.PollInterval, cfg.DesiredIPSettings, cfg.CorrectIPSettingsDrift)
+		}()
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	<-sigChan
+	log.Println("Received termination signal, shutting down...")
+
+	go func() {
+		<-sigChan
+		log.Println("Received a second termination signal, exiting immediately")
+		os.Exit(1)
+	}()
+
+	shutdown(cancel, &wg, integrationManager, cfg.ShutdownTimeout)
+}
+
+// newClient creates the NeoProtect client of an account
+func newClient(cfg *config.Config) (*neoprotect.Client, error) {
+	return neoprotect.NewClient(cfg.APIKey, cfg.APIEndpoint,
+		neoprotect.WithFallbackEndpoints(cfg.APIEndpoints[1:]...),
+		neoprotect.WithHeaders(cfg.APIHeaders),
+		neoprotect.WithRequestTimeout(cfg.RequestTimeout),
+		neoprotect.WithPaginationTimeout(cfg.PaginationTimeout),
+		neoprotect.WithLenientPagination(cfg.PaginationMode == "lenient"),
+		neoprotect.WithDebugLogging(cfg.DebugLogging))
+}
+
+// forEachConcurrently calls fn for every index below n, running at most limit calls at the same time
+func forEachConcurrently(n, limit int, fn func(i int)) {
+	if limit < 1 {
+		limit = 1
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, limit)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			fn(i)
+		}()
+	}
+	wg.Wait()
+}
+
+// accountLabel names the account in log lines and warnings, it is empty when a single account is monitored
+func accountLabel(account string) string {
+	if account == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (account %s)", account)
+}
+
+// shutdown stops the monitor goroutines before the integrations, so nothing is sent to an integration that is
+// shutting down. The process exits forcibly if shutting down takes longer than timeout.
+func shutdown(cancel context.CancelFunc, wg *sync.WaitGroup, manager *integrations.Manager, timeout time.Duration) {
+	ctx, cancelTimeout := context.WithTimeout(context.Background(), timeout)
+	defer cancelTimeout()
+
+	cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		log.Printf("Warning: monitor did not stop within %s, forcing exit", timeout)
+		os.Exit(1)
+	}
+
+	if err := manager.Shutdown(ctx); err != nil {
+		log.Printf("Warning: %v, forcing exit", err)
+		os.Exit(1)
+	}
+
+	log.Println("Shutdown complete")
+}
+
+// monitorAttacks polls the attacks of one account until ctx is cancelled. The primary account also serves the
+// bot's /resend and /poll commands and the debug variables.
+func monitorAttacks(ctx context.Context, client *neoprotect.Client, manager *integrations.Manager, status *integrations.MonitorStatus, blacklist *integrations.Blacklist, pollNow <-chan struct{}, primary bool, pollInterval time.Duration, cfg *config.Config) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	knownAttacks := integrations.NewAttackStore(cfg.Retention, cfg.RetainPeaks)
+	messageTracker := integrations.NewMessageTracker()
+
+	if cfg.AttackStateFile != "" {
+		if loaded, err := knownAttacks.Load(cfg.AttackStateFile); err != nil {
+			log.Printf("Warning: failed to load known attacks, starting without them: %v", err)
+		} else if loaded > 0 {
+			log.Printf("Loaded %d known attack(s)%s from %s", loaded, accountLabel(cfg.AccountName), cfg.AttackStateFile)
+		}
+	}
+
+	peakRecords, err := integrations.NewPeakRecordStore(cfg.PeakRecordsFile)
+	if err != nil {
+		log.Printf("Warning: failed to load peak records, starting with empty records: %v", err)
+	}
+
+	deliveries, err := integrations.NewDeliveryLog(cfg.DeliveryLogFile, cfg.Retention)
+	if err != nil {
+		log.Printf("Warning: failed to load delivery log, already delivered notifications may be sent again: %v", err)
+	}
+	messageTracker.UseDeliveryLog(deliveries)
+
+	throttle := newUpdateThrottle(cfg.UpdateInterval)
+	escalations := newEscalationTracker(cfg.Escalations)
+	reminders := newReminderTracker(cfg.ReminderInterval)
+
+	// Manual polls from the /poll command run in this loop, so they never overlap a regular poll
+	manualPolls := make(chan chan integrations.PollResult)
+	if primary {
+		manager.SetResendSource(knownAttacks, messageTracker)
+
+		if cfg.DebugListenAddr != "" {
+			go serveDebugVars(ctx, cfg.DebugListenAddr, manager, status, knownAttacks, messageTracker)
+		}
+
+		manager.SetPollTrigger(func(triggerCtx context.Context) (integrations.PollResult, error) {
+			reply := make(chan integrations.PollResult, 1)
+			select {
+			case manualPolls <- reply:
+			case <-triggerCtx.Done():
+				return integrations.PollResult{}, fmt.Errorf("monitor did not accept the poll in time: %w", triggerCtx.Err())
+			case <-ctx.Done():
+				return integrations.PollResult{}, fmt.Errorf("monitor is shutting down")
+			}
+
+			select {
+			case result := <-reply:
+				return result, nil
+			case <-triggerCtx.Done():
+				return integrations.PollResult{}, fmt.Errorf("poll did not finish in time: %w", triggerCtx.Err())
+			}
+		})
+	}
+
+	if cfg.BackfillHours > 0 {
+		backfillEndedAttacks(ctx, client, blacklist, knownAttacks, time.Duration(cfg.BackfillHours)*time.Hour, cfg)
+	}
+
+	log.Printf("Performing initial attack status fetch%s (active attacks only)", accountLabel(cfg.AccountName))
+	if cfg.ReconcileOnStartup {
+		reannounceUntrackedAttacks(ctx, manager, knownAttacks, messageTracker)
+		fetchAndProcessActiveAttacks(ctx, client, manager, status, cfg.MonitorMode, cfg.SpecificIPs, blacklist, knownAttacks, messageTracker, peakRecords, throttle, escalations, reminders, cfg)
+	} else {
+		seedKnownAttacks(ctx, client, status, blacklist, knownAttacks, peakRecords, cfg)
+	}
+
+	ticker.Reset(pollInterval)
+	status.SetNextPollAt(time.Now().Add(pollInterval))
+
+	pruneTicker := time.NewTicker(attackPruneInterval)
+	defer pruneTicker.Stop()
+
+	var failures int
+	var failingSince time.Time
+
+	// poll runs a poll cycle, widening the interval after consecutive failures and restoring it on success
+	poll := func() {
+		started := time.Now()
+		fetchAndProcessActiveAttacks(ctx, client, manager, status, cfg.MonitorMode, cfg.SpecificIPs, blacklist, knownAttacks, messageTracker, peakRecords, throttle, escalations, reminders, cfg)
+
+		elapsed := time.Since(started).Round(time.Millisecond)
+		debugf("poll cycle%s finished in %s", accountLabel(cfg.AccountName), elapsed)
+		if elapsed > pollInterval {
+			log.Printf("Warning: poll cycle%s took %s, longer than the poll interval of %s", accountLabel(cfg.AccountName), elapsed, pollInterval)
+		}
+
+		snapshot := status.Snapshot()
+		if snapshot.LastError != "" {
+			if failures == 0 {
+				failingSince = snapshot.LastPollAt
+			}
+			failures++
+
+			interval := pollBackoff(pollInterval, cfg.MaxPollBackoff, failures)
+			if interval != pollInterval {
+				log.Printf("Warning: %d consecutive failed polls%s, backing off to one poll every %s", failures, accountLabel(cfg.AccountName), interval)
+			}
+			ticker.Reset(interval)
+			status.SetNextPollAt(time.Now().Add(interval))
+			return
+		}
+
+		if pollBackoff(pollInterval, cfg.MaxPollBackoff, failures) != pollInterval {
+			message := fmt.Sprintf("The NeoProtect API is reachable again%s after %d failed polls over %s, polling every %s again.",
+				accountLabel(cfg.AccountName), failures, time.Since(failingSince).Round(time.Second), pollInterval)
+			log.Println(message)
+
+			// The stale data watcher announces the recovery itself once it has warned
+			if !snapshot.StaleWarned {
+				if err := manager.NotifyWarning(ctx, "NeoProtect API recovered", message); err != nil {
+					log.Printf("Error notifying integrations about API recovery: %v", err)
+				}
+			}
+		}
+		failures = 0
+		ticker.Reset(pollInterval)
+		status.SetNextPollAt(time.Now().Add(pollInterval))
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			saveKnownAttacks(knownAttacks, cfg)
+			log.Printf("Attack monitoring stopped%s", accountLabel(cfg.AccountName))
+			return
+		case <-ticker.C:
+			poll()
+		case <-pollNow:
+			poll()
+		case reply := <-manualPolls:
+			before := snapshotKnownAttacks(knownAttacks)
+			poll()
+			reply <- pollResult(before, knownAttacks, status.Snapshot())
+		case <-pruneTicker.C:
+			cleanupEndedAttacks(knownAttacks, messageTracker, throttle, escalations, reminders)
+			saveKnownAttacks(knownAttacks, cfg)
+		}
+	}
+}
+
+// saveKnownAttacks persists the known attacks if attackStateFile is set
+func saveKnownAttacks(knownAttacks *integrations.AttackStore, cfg *config.Config) {
+	if cfg.AttackStateFile == "" {
+		return
+	}
+	if err := knownAttacks.Save(cfg.AttackStateFile); err != nil {
+		log.Printf("Error saving known attacks%s: %v", accountLabel(cfg.AccountName), err)
+	}
+}
+
+// knownAttackState is a known attack as it was before a poll
+type knownAttackState struct {
+	attack *neoprotect.Attack
+	ended  bool
+}
+
+func snapshotKnownAttacks(knownAttacks *integrations.AttackStore) map[string]knownAttackState {
+	states := make(map[string]knownAttackState)
+	for _, attack := range knownAttacks.All() {
+		states[attack.ID] = knownAttackState{attack: attack, ended: attack.EndedAt != nil}
+	}
+	return states
+}
+
+// pollResult counts the attacks a poll discovered, changed and ended. Known attacks are only replaced in the
+// store when they changed, so a different pointer means the attack was updated.
+func pollResult(before map[string]knownAttackState, knownAttacks *integrations.AttackStore, snapshot integrations.MonitorStatusSnapshot) integrations.PollResult {
+	result := integrations.PollResult{
+		ActiveAttacks: snapshot.ActiveAttacks,
+		Error:         snapshot.LastError,
+	}
+
+	for _, attack := range knownAttacks.All() {
+		previous, known := before[attack.ID]
+		switch {
+		case !known:
+			result.New++
+		case !previous.ended && attack.EndedAt != nil:
+			result.Ended++
+		case previous.attack != attack:
+			result.Updated++
+		}
+	}
+
+	return result
+}
+
+// pollBackoff returns the interval until the next poll after the given number of consecutive failed polls.
+// The interval doubles from the second failure on, up to maxInterval.
+func pollBackoff(pollInterval, maxInterval time.Duration, failures int) time.Duration {
+	interval := pollInterval
+	for i := 1; i < failures && interval < maxInterval; i++ {
+		interval *= 2
+	}
+	if interval > maxInterval {
+		interval = maxInterval
+	}
+	return interval
+}
+
+// serveDebugVars publishes the monitor's bookkeeping on /debug/vars until ctx is cancelled
+func serveDebugVars(ctx context.Context, addr string, manager *integrations.Manager, status *integrations.MonitorStatus, knownAttacks *integrations.AttackStore, messageTracker *integrations.MessageTracker) {
+	expvar.Publish("known_attacks", expvar.Func(func() interface{} {
+		return knownAttacks.Len()
+	}))
+	expvar.Publish("active_attacks", expvar.Func(func() interface{} {
+		return status.Snapshot().ActiveAttacks
+	}))
+	expvar.Publish("tracked_messages", expvar.Func(func() interface{} {
+		return messageTracker.Len()
+	}))
+	expvar.Publish("last_poll_at", expvar.Func(func() interface{} {
+		return status.Snapshot().LastPollAt
+	}))
+	expvar.Publish("last_successful_poll_at", expvar.Func(func() interface{} {
+		return status.Snapshot().LastSuccessfulPollAt
+	}))
+	expvar.Publish("integration_errors", expvar.Func(func() interface{} {
+		return manager.ErrorCounts()
+	}))
+	expvar.Publish("disabled_integrations", expvar.Func(func() interface{} {
+		return manager.DisabledIntegrations()
+	}))
+
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	server := &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	log.Printf("Serving debug variables on http://%s/debug/vars", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("Warning: debug server stopped: %v", err)
+	}
+}
+
+// serveHealthProbes serves Kubernetes style probes until ctx is cancelled. /healthz fails once the next poll is
+// overdue by more than grace, so a wedged poller gets restarted, while failing polls during an API outage keep
+// it passing. /readyz fails until integrations are initialized, while the last poll of the API failed and while
+// an integration fails its health check, checked at most once per readinessCheckTTL. With several accounts, both
+// fail as soon as one account's monitor does.
+func serveHealthProbes(ctx context.Context, addr string, statuses []*integrations.MonitorStatus, manager *integrations.Manager, grace time.Duration) {
+	health := integrations.NewHealthCache(manager, readinessCheckTTL)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		for _, status := range statuses {
+			snapshot := status.Snapshot()
+			if !snapshot.NextPollAt.IsZero() {
+				if overdue := time.Since(snapshot.NextPollAt); overdue > grace {
+					http.Error(w, fmt.Sprintf("poll overdue by %s", overdue.Round(time.Second)), http.StatusServiceUnavailable)
+					return
+				}
+			}
+		}
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		for _, status := range statuses {
+			snapshot := status.Snapshot()
+			switch {
+			case len(snapshot.Integrations) == 0:
+				http.Error(w, "integrations not initialized", http.StatusServiceUnavailable)
+				return
+			case snapshot.LastSuccessfulPollAt.IsZero():
+				http.Error(w, "no successful poll yet", http.StatusServiceUnavailable)
+				return
+			case snapshot.LastError != "":
+				http.Error(w, "NeoProtect API unreachable: "+snapshot.LastError, http.StatusServiceUnavailable)
+				return
+			}
+		}
+
+		checkCtx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		unhealthy := integrations.UnhealthyIntegrations(health.Results(checkCtx))
+		cancel()
+
+		if len(unhealthy) > 0 {
+			http.Error(w, "unhealthy integrations: "+strings.Join(unhealthy, "; "), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+
+	server := &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	log.Printf("Serving health probes on http://%s/healthz and /readyz", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("Warning: health probe server stopped: %v", err)
+	}
+}
+
+// readinessCheckTTL is how long /readyz reuses the results of the integration health checks
+const readinessCheckTTL = 30 * time.Second
+
+// inboundWebhookMaxBody limits the size of pushed attack events
+const inboundWebhookMaxBody = 1 << 20
+
+// serveInboundWebhook accepts attack event pushes from NeoProtect and triggers an immediate poll for each of them,
+// so pushed events go through the same processing as polled ones. Pushes arriving during a poll are coalesced.
+// The push does not say which account it belongs to, so every account is polled.
+func serveInboundWebhook(ctx context.Context, addr string, secret string, pollNow []chan struct{}) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/webhook", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		provided := r.Header.Get("X-Webhook-Secret")
+		if provided == "" {
+			provided = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		}
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(secret)) != 1 {
+			log.Printf("Warning: Rejected inbound webhook from %s with an invalid secret", r.RemoteAddr)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var event struct {
+			ID               string `json:"id"`
+			DstAddressString string `json:"dstAddressString"`
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, inboundWebhookMaxBody))
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+		if err := json.Unmarshal(body, &event); err == nil && event.ID != "" {
+			log.Printf("Received pushed event for attack %s on %s, polling now", event.ID, event.DstAddressString)
+		} else {
+			log.Printf("Received pushed attack event, polling now")
+		}
+
+		for _, accountPollNow := range pollNow {
+			select {
+			case accountPollNow <- struct{}{}:
+			default:
+			}
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	log.Printf("Accepting pushed attack events on http://%s/webhook", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("Warning: inbound webhook server stopped, falling back to polling only: %v", err)
+	}
+}
+
+// onceErrorExitCode is returned by --once when the NeoProtect API could not be polled
+const onceErrorExitCode = 2
+
+// runOnce performs a single poll cycle, emitting notifications as usual, and returns the process exit code
+func runOnce(ctx context.Context, client *neoprotect.Client, manager *integrations.Manager, status *integrations.MonitorStatus, blacklist *integrations.Blacklist, cfg *config.Config, attackExitCode int) int {
+	knownAttacks := integrations.NewAttackStore(cfg.Retention, cfg.RetainPeaks)
+	messageTracker := integrations.NewMessageTracker()
+
+	peakRecords, err := integrations.NewPeakRecordStore(cfg.PeakRecordsFile)
+	if err != nil {
+		log.Printf("Warning: failed to load peak records, starting with empty records: %v", err)
+	}
+
+	deliveries, err := integrations.NewDeliveryLog(cfg.DeliveryLogFile, cfg.Retention)
+	if err != nil {
+		log.Printf("Warning: failed to load delivery log, already delivered notifications may be sent again: %v", err)
+	}
+	messageTracker.UseDeliveryLog(deliveries)
+
+	fetchAndProcessActiveAttacks(ctx, client, manager, status, cfg.MonitorMode, cfg.SpecificIPs, blacklist, knownAttacks, messageTracker, peakRecords, newUpdateThrottle(0), newEscalationTracker(cfg.Escalations), newReminderTracker(0), cfg)
+
+	snapshot := status.Snapshot()
+	if snapshot.LastError != "" {
+		fmt.Printf("Scan%s failed: %s\n", accountLabel(cfg.AccountName), snapshot.LastError)
+		return onceErrorExitCode
+	}
+
+	fmt.Printf("Scan%s complete: %d active attack(s)\n", accountLabel(cfg.AccountName), snapshot.ActiveAttacks)
+	for _, attack := range knownAttacks.All() {
+		fmt.Printf("  %s on %s, peak %s / %s, signatures: %v\n", attack.ID, attack.DstAddressString,
+			integrations.FormatBPS(attack.RecordedPeakBPS()), integrations.FormatPPS(attack.RecordedPeakPPS()), attack.GetSignatureNames())
+	}
+
+	if snapshot.ActiveAttacks > 0 {
+		return attackExitCode
+	}
+	return 0
+}
+
+// runIntegrationTest initializes only the named integration, sends it a synthetic attack and returns the process
+// exit code
+func runIntegrationTest(cfg *config.Config, name string) int {
+	manager, err := integrations.NewManager("./integrations", []string{name})
+	if err != nil {
+		fmt.Printf("Test of integration %s failed: %v\n", name, err)
+		return 1
+	}
+
+	manager.SetConfig(cfg)
+	if err := manager.InitializeIntegrations(cfg); err != nil {
+		fmt.Printf("Test of integration %s failed: %v\n", name, err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	err = manager.SendTestSequence(ctx, name)
+	cancel()
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	if shutdownErr := manager.Shutdown(shutdownCtx); shutdownErr != nil {
+		log.Printf("Warning: %v", shutdownErr)
+	}
+	cancelShutdown()
+
+	if err != nil {
+		fmt.Printf("Test of integration %s failed: %v\n", name, err)
+		return 1
+	}
+
+	fmt.Printf("Test of integration %s succeeded: sent new attack, update and end notifications\n", name)
+	return 0
+}
+
+// watchPollHealth warns when no successful poll happened for staleAfterPolls intervals.
+// It runs separately from the monitor so a wedged poll loop is still detected.
+func watchPollHealth(ctx context.Context, manager *integrations.Manager, status *integrations.MonitorStatus, account string, pollInterval time.Duration, staleAfterPolls int) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	staleAfter := time.Duration(staleAfterPolls) * pollInterval
+	warned := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			snapshot := status.Snapshot()
+
+			lastSuccess := snapshot.LastSuccessfulPollAt
+			if lastSuccess.IsZero() {
+				lastSuccess = snapshot.StartedAt
+			}
+
+			stale := time.Since(lastSuccess) > staleAfter
+			if stale && !warned {
+				message := fmt.Sprintf("No successful poll of the NeoProtect API%s for %s. Attack data may be stale.",
+					accountLabel(account), time.Since(lastSuccess).Round(time.Second))
+				if snapshot.LastError != "" {
+					message += fmt.Sprintf("\nLast error: %s", snapshot.LastError)
+				}
+
+				log.Printf("Warning: %s", message)
+				if err := manager.NotifyWarning(ctx, "Attack data is stale", message); err != nil {
+					log.Printf("Error notifying integrations about stale data: %v", err)
+				}
+				warned = true
+				status.SetStaleWarned(true)
+			} else if !stale && warned {
+				log.Println("Polling recovered, attack data is up to date again")
+				message := fmt.Sprintf("Successful polls of the NeoProtect API%s have resumed.", accountLabel(account))
+				if err := manager.NotifyWarning(ctx, "Polling recovered", message); err != nil {
+					log.Printf("Error notifying integrations about polling recovery: %v", err)
+				}
+				warned = false
+				status.SetStaleWarned(false)
+			}
+		}
+	}
+}
+
+// watchAccountIPs warns once when an IP seen in the account, or a configured specific IP, is no longer listed,
+// e.g. because its protection expired or was deprovisioned
+func watchAccountIPs(ctx context.Context, client *neoprotect.Client, manager *integrations.Manager, account string, pollInterval time.Duration, specificIPs []string) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	expected := make(map[string]bool)
+	for _, ip := range specificIPs {
+		expected[ip] = true
+	}
+	missing := make(map[string]bool)
+
+	for {
+		checkAccountIPs(ctx, client, manager, account, expected, missing)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func checkAccountIPs(ctx context.Context, client *neoprotect.Client, manager *integrations.Manager, account string, expected map[string]bool, missing map[string]bool) {
+	addresses, err := client.GetIPAddresses(ctx)
+	if err != nil {
+		log.Printf("Error fetching IP addresses for account check: %v", err)
+		return
+	}
+
+	listed := make(map[string]bool)
+	for _, address := range addresses {
+		if address != nil && address.IPv4 != "" {
+			listed[address.IPv4] = true
+		}
+	}
+
+	if len(listed) == 0 && len(expected) > 0 {
+		log.Printf("Warning: The account lists no IP addresses, skipping the removed IP check")
+		return
+	}
+
+	for ip := range listed {
+		expected[ip] = true
+		if missing[ip] {
+			log.Printf("IP %s is listed in the account again", ip)
+			delete(missing, ip)
+		}
+	}
+
+	var removed []string
+	for ip := range expected {
+		if !listed[ip] && !missing[ip] {
+			missing[ip] = true
+			removed = append(removed, ip)
+		}
+	}
+	if len(removed) == 0 {
+		return
+	}
+	sort.Strings(removed)
+
+	message := fmt.Sprintf("No longer listed in the NeoProtect account%s: %s. Attacks on these IPs are not monitored anymore.", accountLabel(account), strings.Join(removed, ", "))
+	log.Printf("Warning: %s", message)
+	if err := manager.NotifyWarning(ctx, "IP removed from account", message); err != nil {
+		log.Printf("Error sending removed IP warning: %v", err)
+	}
+}
+
+// watchIPSettings warns once when an IP's settings drift from the desired state and optionally restores them
+func watchIPSettings(ctx context.Context, client *neoprotect.Client, manager *integrations.Manager, pollInterval time.Duration, desired map[string]config.DesiredIPSettings, correct bool) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	drifted := make(map[string]bool)
+
+	for {
+		checkIPSettings(ctx, client, manager, desired, correct, drifted)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
 
-	cfg, err := config.LoadConfig(*configPath)
+func checkIPSettings(ctx context.Context, client *neoprotect.Client, manager *integrations.Manager, desired map[string]config.DesiredIPSettings, correct bool, drifted map[string]bool) {
+	addresses, err := client.GetIPAddresses(ctx)
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		log.Printf("Error fetching IP addresses for settings check: %v", err)
+		return
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	settings := make(map[string]*neoprotect.IPSettings)
+	for _, address := range addresses {
+		if address != nil {
+			settings[address.IPv4] = address.Settings
+		}
+	}
 
-	client, err := neoprotect.NewClient(cfg.APIKey, cfg.APIEndpoint)
-	if err != nil {
-		log.Fatalf("Failed to create NeoProtect client: %v", err)
+	for ip, want := range desired {
+		current, exists := settings[ip]
+		if !exists {
+			log.Printf("Warning: IP %s from desiredIpSettings is not in the account", ip)
+			continue
+		}
+		if current == nil || want.AutoMitigation == nil {
+			continue
+		}
+
+		if current.AutoMitigation == *want.AutoMitigation {
+			if drifted[ip] {
+				log.Printf("Settings of IP %s are back in the desired state", ip)
+				delete(drifted, ip)
+			}
+			continue
+		}
+
+		if drifted[ip] {
+			continue
+		}
+		drifted[ip] = true
+
+		message := fmt.Sprintf("Auto-mitigation of %s is %s, expected %s.", ip, onOff(current.AutoMitigation), onOff(*want.AutoMitigation))
+		if correct {
+			corrected := *current
+			corrected.AutoMitigation = *want.AutoMitigation
+			if err := client.UpdateIPSettings(ctx, ip, corrected); err != nil {
+				message += fmt.Sprintf("\nFailed to restore the setting: %v", err)
+			} else {
+				message += "\nThe setting was restored."
+			}
+		}
+
+		log.Printf("Warning: %s", message)
+		if err := manager.NotifyWarning(ctx, "IP settings drift", message); err != nil {
+			log.Printf("Error sending IP settings drift warning: %v", err)
+		}
 	}
+}
 
-	integrationManager, err := integrations.NewManager("./integrations", cfg.EnabledIntegrations)
-	if err != nil {
-		log.Fatalf("Failed to initialize integration manager: %v", err)
+func onOff(enabled bool) string {
+	if enabled {
+		return "on"
 	}
+	return "off"
+}
 
-	if err := integrationManager.InitializeIntegrations(cfg); err != nil {
-		log.Fatalf("Failed to initialize integrations: %v", err)
+func fetchAndProcessActiveAttacks(ctx context.Context, client *neoprotect.Client, manager *integrations.Manager, status *integrations.MonitorStatus, monitorMode string, ipsToMonitor []string, blacklist *integrations.Blacklist, knownAttacks *integrations.AttackStore, messageTracker *integrations.MessageTracker, peakRecords *integrations.PeakRecordStore, throttle *updateThrottle, escalations *escalationTracker, reminders *reminderTracker, cfg *config.Config) {
+	validAttacks, err := fetchMonitoredAttacks(ctx, client, monitorMode, ipsToMonitor, blacklist, cfg.AccountName)
+	var partial *neoprotect.PartialResultsError
+	if errors.As(err, &partial) {
+		// Attacks on the missing pages would look ended, so ended attacks and reminders wait for a complete fetch
+		log.Printf("Warning: %v, processing the attacks fetched so far and skipping ended attack detection", err)
+	} else if err != nil {
+		log.Printf("Error fetching active attacks: %v", err)
+		status.RecordPoll(0, err)
+		return
 	}
 
-	log.Println("Setting NeoProtect API client on integrations...")
-	integrationManager.SetAPIClient(client)
+	if cfg.EndOnSignaturesEnded {
+		validAttacks = dropSignatureEndedAttacks(validAttacks, knownAttacks)
+	}
 
-	var wg sync.WaitGroup
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		monitorAttacks(ctx, client, integrationManager, cfg.PollInterval, cfg)
-	}()
+	validAttacks = filterNewAttacks(manager, validAttacks, knownAttacks)
 
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	if partial != nil {
+		status.RecordPartialPoll(len(validAttacks), err)
+	} else {
+		status.RecordPoll(len(validAttacks), nil)
+	}
 
-	<-sigChan
-	log.Println("Received termination signal, shutting down...")
-	integrationManager.Shutdown()
-	cancel()
+	debugf("fetched %d monitored active attack(s)%s", len(validAttacks), accountLabel(cfg.AccountName))
 
-	wg.Wait()
-	log.Println("Shutdown complete")
-}
+	if cfg.CorrelateBySourceASN {
+		correlateActiveAttacks(ctx, client, validAttacks, cfg.MaxConcurrentIPs)
+	}
 
-func monitorAttacks(ctx context.Context, client *neoprotect.Client, manager *integrations.Manager, pollInterval time.Duration, cfg *config.Config) {
-	ticker := time.NewTicker(pollInterval)
-	defer ticker.Stop()
+	processActiveAttacks(ctx, client, manager, validAttacks, knownAttacks, messageTracker, peakRecords, throttle, cfg.SubsidingThresholdPercent)
+	escalations.check(ctx, manager, validAttacks, messageTracker)
+	if partial == nil {
+		reminders.check(ctx, manager, validAttacks, messageTracker)
+		checkForEndedAttacks(ctx, client, manager, validAttacks, knownAttacks, messageTracker, cfg.TargetPortsLimit, cfg.MaxConcurrentIPs)
+	}
+	cleanupEndedAttacks(knownAttacks, messageTracker, throttle, escalations, reminders)
+}
 
-	knownAttacks := make(map[string]*neoprotect.Attack)
-	messageTracker := integrations.NewMessageTracker()
+// filterNewAttacks holds back attacks that are not known yet and rejected by a filter plugin.
+// Known attacks were already announced and are kept, so they are not mistaken for ended attacks.
+func filterNewAttacks(manager *integrations.Manager, attacks []*neoprotect.Attack, knownAttacks *integrations.AttackStore) []*neoprotect.Attack {
+	var accepted []*neoprotect.Attack
+	for _, attack := range attacks {
+		if _, known := knownAttacks.Get(attack.ID); !known && !manager.ShouldNotify(attack) {
+			continue
+		}
+		accepted = append(accepted, attack)
+	}
+	return accepted
+}
 
-	log.Println("Performing initial attack status fetch (active attacks only)")
-	fetchAndProcessActiveAttacks(ctx, client, manager, cfg.MonitorMode, cfg.SpecificIPs, knownAttacks, messageTracker, cfg)
+// dropSignatureEndedAttacks removes attacks whose signatures have all ended, so they are treated as ended
+// even while the API still lists them as active. Known attacks get their final signatures for the end notification.
+func dropSignatureEndedAttacks(attacks []*neoprotect.Attack, knownAttacks *integrations.AttackStore) []*neoprotect.Attack {
+	var active []*neoprotect.Attack
+	for _, attack := range attacks {
+		if attack.SignaturesEnded() == nil {
+			active = append(active, attack)
+			continue
+		}
 
-	for {
-		select {
-		case <-ctx.Done():
-			log.Println("Attack monitoring stopped")
-			return
-		case <-ticker.C:
-			fetchAndProcessActiveAttacks(ctx, client, manager, cfg.MonitorMode, cfg.SpecificIPs, knownAttacks, messageTracker, cfg)
+		if known, exists := knownAttacks.Get(attack.ID); exists && known.EndedAt == nil {
+			known.Signatures = attack.Signatures
 		}
 	}
+	return active
 }
 
-func fetchAndProcessActiveAttacks(ctx context.Context, client *neoprotect.Client, manager *integrations.Manager, monitorMode string, ipsToMonitor []string, knownAttacks map[string]*neoprotect.Attack, messageTracker *integrations.MessageTracker, cfg *config.Config) {
-	attacks, err := client.GetAllAttacksAllPages(ctx, true)
-	if err != nil {
+// seedKnownAttacks records the currently active attacks without notifying integrations
+func seedKnownAttacks(ctx context.Context, client *neoprotect.Client, status *integrations.MonitorStatus, blacklist *integrations.Blacklist, knownAttacks *integrations.AttackStore, peakRecords *integrations.PeakRecordStore, cfg *config.Config) {
+	attacks, err := fetchMonitoredAttacks(ctx, client, cfg.MonitorMode, cfg.SpecificIPs, blacklist, cfg.AccountName)
+	var partial *neoprotect.PartialResultsError
+	if errors.As(err, &partial) {
+		log.Printf("Warning: %v, seeding the attacks fetched so far", err)
+	} else if err != nil {
 		log.Printf("Error fetching active attacks: %v", err)
+		status.RecordPoll(0, err)
 		return
 	}
 
+	if partial != nil {
+		status.RecordPartialPoll(len(attacks), err)
+	} else {
+		status.RecordPoll(len(attacks), nil)
+	}
+
+	now := time.Now()
+	for _, attack := range attacks {
+		attack.FirstObservedAt = &now
+		if _, err := peakRecords.Update(attack); err != nil {
+			log.Printf("Error updating peak records for IP %s: %v", attack.DstAddressString, err)
+		}
+		knownAttacks.Set(attack)
+	}
+
+	log.Printf("Tracking %d pre-existing active attack(s) without announcing them", len(attacks))
+}
+
+// backfillPageLimit caps how many history pages are fetched per IP during the startup backfill
+const backfillPageLimit = 20
+
+// backfillEndedAttacks preloads attacks that ended within the window, so commands have history right after startup.
+// Only ended attacks are stored, active attacks are left to the regular poll, so nothing is announced as new.
+func backfillEndedAttacks(ctx context.Context, client *neoprotect.Client, blacklist *integrations.Blacklist, knownAttacks *integrations.AttackStore, window time.Duration, cfg *config.Config) {
+	ips := cfg.SpecificIPs
+	if cfg.MonitorMode != "specific" {
+		addresses, err := client.GetIPAddresses(ctx)
+		if err != nil {
+			log.Printf("Error fetching IP addresses for backfill: %v", err)
+			return
+		}
+
+		ips = nil
+		for _, address := range addresses {
+			if address != nil && address.IPv4 != "" {
+				ips = append(ips, address.IPv4)
+			}
+		}
+	}
+
+	cutoff := time.Now().Add(-window)
+
+	var mu sync.Mutex
+	backfilled, processed := 0, 0
+	forEachConcurrently(len(ips), cfg.MaxConcurrentIPs, func(i int) {
+		ip := ips[i]
+		defer func() {
+			mu.Lock()
+			processed++
+			debugf("backfilled %d/%d IP(s)", processed, len(ips))
+			mu.Unlock()
+		}()
+
+		if blacklist.Contains(ip) {
+			return
+		}
+
+		for page := 0; page < backfillPageLimit; page++ {
+			attacks, err := client.GetAttacks(ctx, ip, page)
+			if err != nil {
+				log.Printf("Error backfilling attacks for IP %s, page %d: %v", ip, page, err)
+				break
+			}
+
+			olderThanWindow := len(attacks) == 0
+			for _, attack := range attacks {
+				if attack == nil {
+					continue
+				}
+				attack.DropInvalidSignatures()
+				if attack.Validate() != nil {
+					continue
+				}
+				if attack.StartedAt != nil && attack.StartedAt.Before(cutoff) {
+					olderThanWindow = true
+				}
+				if attack.EndedAt == nil || attack.EndedAt.Before(cutoff) {
+					continue
+				}
+
+				mu.Lock()
+				if _, exists := knownAttacks.Get(attack.ID); !exists {
+					attack.Account = cfg.AccountName
+					knownAttacks.Set(attack)
+					backfilled++
+				}
+				mu.Unlock()
+			}
+
+			if olderThanWindow {
+				break
+			}
+		}
+	})
+
+	log.Printf("Backfilled %d attack(s) that ended in the last %s", backfilled, window)
+}
+
+// fetchMonitoredAttacks fetches active attacks and filters them by monitor mode, blacklist and validity.
+// When only some pages could be fetched, the attacks on them are returned with the *neoprotect.PartialResultsError.
+// The attacks are tagged with the account they were fetched from.
+func fetchMonitoredAttacks(ctx context.Context, client *neoprotect.Client, monitorMode string, ipsToMonitor []string, blacklist *integrations.Blacklist, account string) ([]*neoprotect.Attack, error) {
+	attacks, fetchErr := client.GetAllAttacksAllPages(ctx, true)
+	var partial *neoprotect.PartialResultsError
+	if fetchErr != nil && !errors.As(fetchErr, &partial) {
+		return nil, fetchErr
+	}
+
 	if monitorMode == "specific" {
 		var filteredAttacks []*neoprotect.Attack
 		for _, attack := range attacks {
 			for _, ip := range ipsToMonitor {
 				if attack.DstAddressString == ip {
-					if !cfg.IsBlacklisted(ip) {
+					if !blacklist.Contains(ip) {
 						filteredAttacks = append(filteredAttacks, attack)
 					}
 					break
@@ -113,95 +1126,426 @@ func fetchAndProcessActiveAttacks(ctx context.Context, client *neoprotect.Client
 	} else if monitorMode == "all" {
 		var filteredAttacks []*neoprotect.Attack
 		for _, attack := range attacks {
-			if !cfg.IsBlacklisted(attack.DstAddressString) {
+			if !blacklist.Contains(attack.DstAddressString) {
 				filteredAttacks = append(filteredAttacks, attack)
 			}
 		}
 		attacks = filteredAttacks
 	} else {
-		log.Printf("Invalid monitor mode: %s", monitorMode)
-		return
+		return nil, fmt.Errorf("invalid monitor mode: %s", monitorMode)
 	}
 
 	var validAttacks []*neoprotect.Attack
 	for _, attack := range attacks {
-		if !isValidAttack(attack) {
-			log.Printf("Skipping invalid attack: ID=%s, IP=%s", attack.ID, attack.DstAddressString)
+		if attack == nil {
+			continue
+		}
+		for _, err := range attack.DropInvalidSignatures() {
+			log.Printf("Dropping invalid signature of attack %s: %v", attack.ID, err)
+		}
+		if err := attack.Validate(); err != nil {
+			log.Printf("Skipping invalid attack: ID=%s, IP=%s: %v", attack.ID, attack.DstAddressString, err)
 			continue
 		}
+		attack.Account = account
 		validAttacks = append(validAttacks, attack)
 	}
 
-	processActiveAttacks(ctx, client, manager, validAttacks, knownAttacks, messageTracker)
-	checkForEndedAttacks(ctx, manager, validAttacks, knownAttacks, messageTracker)
-	cleanupEndedAttacks(knownAttacks)
+	return validAttacks, fetchErr
 }
 
-func isValidAttack(attack *neoprotect.Attack) bool {
-	if attack == nil {
-		return false
-	}
-	if attack.ID == "" {
-		return false
+func correlateActiveAttacks(ctx context.Context, client *neoprotect.Client, attacks []*neoprotect.Attack, concurrency int) {
+	if len(attacks) < 2 {
+		return
 	}
-	if attack.DstAddressString == "" {
-		return false
+
+	var mu sync.Mutex
+	dominantASNs := make(map[string][]string)
+	processed := 0
+	forEachConcurrently(len(attacks), concurrency, func(i int) {
+		attack := attacks[i]
+		defer func() {
+			mu.Lock()
+			processed++
+			debugf("fetched source ASNs of %d/%d active attack(s)", processed, len(attacks))
+			mu.Unlock()
+		}()
+
+		stats, err := client.GetAttackStats(ctx, attack.ID)
+		if err != nil {
+			log.Printf("Error fetching stats for attack %s: %v", attack.ID, err)
+			return
+		}
+
+		asns, err := stats.DominantSourceASNs(3)
+		if err != nil {
+			log.Printf("Error reading source ASNs for attack %s: %v", attack.ID, err)
+			return
+		}
+
+		mu.Lock()
+		dominantASNs[attack.ID] = asns
+		mu.Unlock()
+	})
+
+	correlated := neoprotect.CorrelateBySourceASN(attacks, dominantASNs)
+	for _, attack := range attacks {
+		attack.CorrelatedIPs = correlated[attack.ID]
 	}
-	return true
 }
 
-func processActiveAttacks(ctx context.Context, client *neoprotect.Client, manager *integrations.Manager, attacks []*neoprotect.Attack, knownAttacks map[string]*neoprotect.Attack, messageTracker *integrations.MessageTracker) {
+func processActiveAttacks(ctx context.Context, client *neoprotect.Client, manager *integrations.Manager, attacks []*neoprotect.Attack, knownAttacks *integrations.AttackStore, messageTracker *integrations.MessageTracker, peakRecords *integrations.PeakRecordStore, throttle *updateThrottle, subsidingThreshold int) {
 	seenAttacks := make(map[string]bool)
 
 	for _, attack := range attacks {
 		seenAttacks[attack.ID] = true
 
-		existingAttack, exists := knownAttacks[attack.ID]
+		existingAttack, exists := knownAttacks.Get(attack.ID)
+
+		newRecord, err := peakRecords.Update(attack)
+		if err != nil {
+			log.Printf("Error updating peak records for IP %s: %v", attack.DstAddressString, err)
+		}
+		attack.NewRecordPeak = newRecord || (exists && existingAttack.NewRecordPeak)
+
+		if exists && existingAttack.FirstObservedAt != nil {
+			attack.FirstObservedAt = existingAttack.FirstObservedAt
+		} else {
+			now := time.Now()
+			attack.FirstObservedAt = &now
+		}
 
 		if !exists {
-			knownAttacks[attack.ID] = attack
+			knownAttacks.Set(attack)
 
 			err := manager.NotifyNewAttack(ctx, attack, messageTracker)
 			if err != nil {
-				log.Printf("Error notifying integrations about new attack: %v", err)
+				log.Printf("Error notifying integrations about new attack %s: %v", attack.ID, err)
 			}
+			throttle.announced(attack.ID)
 		} else if !attack.Equal(existingAttack) {
 			previousState := *existingAttack
-			knownAttacks[attack.ID] = attack
+			knownAttacks.Set(attack)
+
+			attack.Subsiding = attack.CalculateDiff(&previousState).IsSubsiding(subsidingThreshold)
+
+			previous, ok := throttle.check(attack, &previousState)
+			if !ok {
+				manager.RecordObserved(integrations.EventAttackUpdate, attack)
+				continue
+			}
+
+			err := manager.NotifyAttackUpdate(ctx, attack, previous, messageTracker)
+			if err != nil {
+				log.Printf("Error notifying integrations about update of attack %s: %v", attack.ID, err)
+			}
+		} else if previous, ok := throttle.due(attack.ID); ok {
+			// The attack stopped changing while its last change was suppressed, send that change now
+			attack.Subsiding = attack.CalculateDiff(previous).IsSubsiding(subsidingThreshold)
 
-			err := manager.NotifyAttackUpdate(ctx, attack, &previousState, messageTracker)
+			err := manager.NotifyAttackUpdate(ctx, attack, previous, messageTracker)
 			if err != nil {
-				log.Printf("Error notifying integrations about attack update: %v", err)
+				log.Printf("Error notifying integrations about update of attack %s: %v", attack.ID, err)
 			}
 		}
 	}
 }
 
-func checkForEndedAttacks(ctx context.Context, manager *integrations.Manager, activeAttacks []*neoprotect.Attack, knownAttacks map[string]*neoprotect.Attack, messageTracker *integrations.MessageTracker) {
+// reannounceUntrackedAttacks announces the active attacks loaded from the attack state again. Their messages are not
+// tracked across restarts, so without a fresh notification their updates and end would have no message to edit.
+func reannounceUntrackedAttacks(ctx context.Context, manager *integrations.Manager, knownAttacks *integrations.AttackStore, messageTracker *integrations.MessageTracker) {
+	for _, attack := range untrackedActiveAttacks(knownAttacks, messageTracker) {
+		log.Printf("Announcing attack %s on %s again, its messages from before the restart are not tracked", attack.ID, attack.DstAddressString)
+		if err := manager.Resend(ctx, attack, messageTracker); err != nil {
+			log.Printf("Error announcing attack %s again: %v", attack.ID, err)
+		}
+	}
+}
+
+// untrackedActiveAttacks returns the known attacks that are still active but have no tracked message
+func untrackedActiveAttacks(knownAttacks *integrations.AttackStore, messageTracker *integrations.MessageTracker) []*neoprotect.Attack {
+	var untracked []*neoprotect.Attack
+	for _, attack := range knownAttacks.All() {
+		if attack.EndedAt == nil && !messageTracker.HasMessages(attack.ID) {
+			untracked = append(untracked, attack)
+		}
+	}
+	return untracked
+}
+
+// checkForEndedAttacks announces known attacks that are no longer active. Their final state is fetched for up to
+// concurrency attacks at the same time, the notifications are sent one after another.
+func checkForEndedAttacks(ctx context.Context, client *neoprotect.Client, manager *integrations.Manager, activeAttacks []*neoprotect.Attack, knownAttacks *integrations.AttackStore, messageTracker *integrations.MessageTracker, portsLimit int, concurrency int) {
 	activeAttackIDs := make(map[string]bool)
 	for _, attack := range activeAttacks {
 		activeAttackIDs[attack.ID] = true
 	}
 
-	for id, attack := range knownAttacks {
-		if !activeAttackIDs[id] && attack.EndedAt == nil {
-			now := time.Now()
-			attack.EndedAt = &now
+	var ended []*neoprotect.Attack
+	for _, attack := range knownAttacks.All() {
+		if !activeAttackIDs[attack.ID] && attack.EndedAt == nil {
+			ended = append(ended, attack)
+		}
+	}
+	if len(ended) == 0 {
+		return
+	}
 
-			err := manager.NotifyAttackEnded(ctx, attack, messageTracker)
-			if err != nil {
-				log.Printf("Error notifying integrations about implicitly ended attack: %v", err)
+	var mu sync.Mutex
+	processed := 0
+	forEachConcurrently(len(ended), concurrency, func(i int) {
+		attack := finalSnapshot(ctx, client, ended[i])
+		if attack.EndedAt == nil {
+			endedAt := time.Now()
+			if signaturesEnded := attack.SignaturesEnded(); signaturesEnded != nil {
+				endedAt = *signaturesEnded
+			}
+			attack.EndedAt = &endedAt
+		}
+		applyEndStats(ctx, client, attack, portsLimit)
+		ended[i] = attack
+
+		mu.Lock()
+		processed++
+		debugf("fetched the final state of %d/%d ended attack(s)", processed, len(ended))
+		mu.Unlock()
+	})
+
+	for _, attack := range ended {
+		err := manager.NotifyAttackEnded(ctx, attack, messageTracker)
+		if err != nil {
+			log.Printf("Error notifying integrations about implicitly ended attack %s: %v", attack.ID, err)
+		}
+
+		knownAttacks.Set(attack)
+	}
+}
+
+// finalSnapshot fetches an ended attack one last time, so the ended notification and the stored record include
+// growth since the last poll. The last polled state is kept if the attack cannot be fetched.
+func finalSnapshot(ctx context.Context, client *neoprotect.Client, attack *neoprotect.Attack) *neoprotect.Attack {
+	if attack.DstAddressString == "" {
+		return attack
+	}
+
+	attacks, err := client.GetAttacks(ctx, attack.DstAddressString, 0)
+	if err != nil {
+		log.Printf("Warning: failed to fetch the final state of attack %s, using the last polled state: %v", attack.ID, err)
+		return attack
+	}
+
+	for _, fetched := range attacks {
+		if fetched == nil || fetched.ID != attack.ID {
+			continue
+		}
+		if len(fetched.Signatures) == 0 {
+			return attack
+		}
+
+		fetched.NewRecordPeak = attack.NewRecordPeak
+		fetched.FirstObservedAt = attack.FirstObservedAt
+		fetched.Subsiding = attack.Subsiding
+		fetched.CorrelatedIPs = attack.CorrelatedIPs
+		fetched.ObservedPeakBPS = attack.RecordedPeakBPS()
+		fetched.ObservedPeakPPS = attack.RecordedPeakPPS()
+		fetched.Account = attack.Account
+		return fetched
+	}
+
+	log.Printf("Warning: attack %s is no longer listed for %s, using the last polled state", attack.ID, attack.DstAddressString)
+	return attack
+}
+
+// applyEndStats adds the protocol mix and up to portsLimit targeted ports from the attack stats to an ended attack
+func applyEndStats(ctx context.Context, client *neoprotect.Client, attack *neoprotect.Attack, portsLimit int) {
+	stats, err := client.GetAttackStats(ctx, attack.ID)
+	if err != nil {
+		log.Printf("Error fetching stats for attack %s: %v", attack.ID, err)
+		return
+	}
+
+	if attack.ProtocolMix, err = stats.ProtocolMix(integrations.ProtocolMixLimit); err != nil {
+		log.Printf("Error reading protocols for attack %s: %v", attack.ID, err)
+	}
+
+	if portsLimit > 0 {
+		if attack.TargetPorts, err = stats.TopDestinationPorts(portsLimit); err != nil {
+			log.Printf("Error reading destination ports for attack %s: %v", attack.ID, err)
+		}
+	}
+}
+
+func cleanupEndedAttacks(knownAttacks *integrations.AttackStore, messageTracker *integrations.MessageTracker, throttle *updateThrottle, escalations *escalationTracker, reminders *reminderTracker) {
+	for _, id := range knownAttacks.Prune() {
+		messageTracker.RemoveMessage(id)
+		throttle.forget(id)
+		escalations.forget(id)
+		reminders.forget(id)
+	}
+}
+
+// updateThrottle limits how often update notifications are sent for a single attack
+type updateThrottle struct {
+	interval   time.Duration
+	lastUpdate map[string]time.Time
+	suppressed map[string]*neoprotect.Attack
+}
+
+func newUpdateThrottle(interval time.Duration) *updateThrottle {
+	return &updateThrottle{
+		interval:   interval,
+		lastUpdate: make(map[string]time.Time),
+		suppressed: make(map[string]*neoprotect.Attack),
+	}
+}
+
+// check reports whether an update may be sent now and returns the state to diff against.
+// While updates are suppressed the last notified state is kept, so the next diff covers all changes.
+func (t *updateThrottle) check(attack *neoprotect.Attack, previous *neoprotect.Attack) (*neoprotect.Attack, bool) {
+	baseline, pending := t.suppressed[attack.ID]
+	if !pending {
+		baseline = previous
+	}
+
+	if t.interval > 0 {
+		last, notified := t.lastUpdate[attack.ID]
+		diff := attack.CalculateDiff(baseline)
+		if notified && time.Since(last) < t.interval && len(diff.NewSignatures) == 0 && diff.VectorShift == nil && !attack.Subsiding {
+			if !pending {
+				t.suppressed[attack.ID] = previous
 			}
+			return nil, false
+		}
+	}
+
+	delete(t.suppressed, attack.ID)
+	t.lastUpdate[attack.ID] = time.Now()
+	return baseline, true
+}
+
+// announced starts the interval of an attack with its new attack notification, so the first update is throttled too
+func (t *updateThrottle) announced(attackID string) {
+	t.lastUpdate[attackID] = time.Now()
+}
+
+// due reports whether a suppressed update of the attack can be sent now that the interval has passed, and returns
+// the last notified state to diff against
+func (t *updateThrottle) due(attackID string) (*neoprotect.Attack, bool) {
+	baseline, pending := t.suppressed[attackID]
+	if !pending || time.Since(t.lastUpdate[attackID]) < t.interval {
+		return nil, false
+	}
+
+	delete(t.suppressed, attackID)
+	t.lastUpdate[attackID] = time.Now()
+	return baseline, true
+}
+
+func (t *updateThrottle) forget(attackID string) {
+	delete(t.lastUpdate, attackID)
+	delete(t.suppressed, attackID)
+}
+
+// escalationTracker re-notifies about long-running attacks once per configured duration threshold
+type escalationTracker struct {
+	rules []config.EscalationRule
+	fired map[string]int
+}
+
+func newEscalationTracker(rules []config.EscalationRule) *escalationTracker {
+	return &escalationTracker{
+		rules: rules,
+		fired: make(map[string]int),
+	}
+}
+
+// check sends an escalation warning for every attack that crossed a threshold it was not escalated for yet.
+// When several thresholds were crossed since the last poll only the longest one is announced.
+func (e *escalationTracker) check(ctx context.Context, manager *integrations.Manager, attacks []*neoprotect.Attack, messageTracker *integrations.MessageTracker) {
+	for _, attack := range attacks {
+		if attack.StartedAt == nil {
+			continue
+		}
+
+		duration := attack.Duration()
+		fired := e.fired[attack.ID]
+		crossed := fired
+		for crossed < len(e.rules) && duration >= e.rules[crossed].After {
+			crossed++
+		}
+
+		if crossed == fired {
+			continue
+		}
+		e.fired[attack.ID] = crossed
+
+		rule := e.rules[crossed-1]
+		message := fmt.Sprintf("Attack %s on %s has been running for %s, exceeding the %s escalation threshold.\nCurrent peak: %s / %s",
+			attack.ID, attack.DstAddressString, duration.Round(time.Second), rule.After,
+			integrations.FormatBPS(attack.RecordedPeakBPS()), integrations.FormatPPS(attack.RecordedPeakPPS()))
 
-			knownAttacks[id] = attack
+		log.Printf("Escalating long-running attack %s on %s after %s", attack.ID, attack.DstAddressString, rule.After)
+		if err := manager.NotifyEscalation(ctx, attack, messageTracker, message, rule.Mention); err != nil {
+			log.Printf("Error notifying integrations about attack escalation: %v", err)
 		}
 	}
 }
 
-func cleanupEndedAttacks(knownAttacks map[string]*neoprotect.Attack) {
-	for id, attack := range knownAttacks {
-		if attack.EndedAt != nil && time.Since(*attack.EndedAt) > 24*time.Hour {
-			delete(knownAttacks, id)
+func (e *escalationTracker) forget(attackID string) {
+	delete(e.fired, attackID)
+}
+
+// reminderTracker schedules the periodic "still ongoing" reminders about active attacks
+type reminderTracker struct {
+	interval time.Duration
+	next     map[string]time.Time
+}
+
+func newReminderTracker(interval time.Duration) *reminderTracker {
+	return &reminderTracker{
+		interval: interval,
+		next:     make(map[string]time.Time),
+	}
+}
+
+// check sends a reminder for every active attack whose reminder is due. The first reminder is due one interval
+// after the attack was first seen active, and attacks that are no longer active lose their schedule.
+func (r *reminderTracker) check(ctx context.Context, manager *integrations.Manager, attacks []*neoprotect.Attack, messageTracker *integrations.MessageTracker) {
+	if r.interval <= 0 {
+		return
+	}
+
+	now := time.Now()
+	active := make(map[string]bool, len(attacks))
+	for _, attack := range attacks {
+		if !attack.IsActive() {
+			continue
+		}
+		active[attack.ID] = true
+
+		next, scheduled := r.next[attack.ID]
+		if !scheduled {
+			r.next[attack.ID] = now.Add(r.interval)
+			continue
+		}
+		if now.Before(next) {
+			continue
+		}
+		r.next[attack.ID] = now.Add(r.interval)
+
+		log.Printf("Reminding that attack %s on %s is still ongoing after %s", attack.ID, attack.DstAddressString, attack.Duration().Round(time.Second))
+		if err := manager.NotifyReminder(ctx, attack, messageTracker); err != nil {
+			log.Printf("Error notifying integrations about ongoing attack: %v", err)
+		}
+	}
+
+	for id := range r.next {
+		if !active[id] {
+			delete(r.next, id)
 		}
 	}
 }
+
+func (r *reminderTracker) forget(attackID string) {
+	delete(r.next, attackID)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"neoprotect-notifier/integrations"
+	"neoprotect-notifier/neoprotect"
+)
+
+func TestUpdateThrottleSendsSuppressedUpdateOnceDue(t *testing.T) {
+	throttle := newUpdateThrottle(time.Minute)
+	announced := &neoprotect.Attack{ID: "attack-1"}
+	grown := &neoprotect.Attack{ID: "attack-1"}
+
+	throttle.announced("attack-1")
+	if _, ok := throttle.check(grown, announced); ok {
+		t.Fatal("the first update right after the announcement was not throttled")
+	}
+	if _, ok := throttle.due("attack-1"); ok {
+		t.Fatal("the suppressed update is due before the interval passed")
+	}
+
+	throttle.lastUpdate["attack-1"] = time.Now().Add(-2 * time.Minute)
+	previous, ok := throttle.due("attack-1")
+	if !ok || previous != announced {
+		t.Fatalf("due() = %v, %v, want the suppressed update diffed against the announced state", previous, ok)
+	}
+	if _, ok := throttle.due("attack-1"); ok {
+		t.Fatal("the suppressed update was due twice")
+	}
+}
+
+func TestUntrackedActiveAttacksAreAnnouncedAgain(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	end := time.Now()
+	knownAttacks := integrations.NewAttackStore(time.Hour, false)
+	knownAttacks.Set(&neoprotect.Attack{ID: "untracked", StartedAt: &start})
+	knownAttacks.Set(&neoprotect.Attack{ID: "tracked", StartedAt: &start})
+	knownAttacks.Set(&neoprotect.Attack{ID: "ended", StartedAt: &start, EndedAt: &end})
+
+	messageTracker := integrations.NewMessageTracker()
+	messageTracker.TrackMessage("tracked", "discord", "message-1")
+
+	untracked := untrackedActiveAttacks(knownAttacks, messageTracker)
+	if len(untracked) != 1 || untracked[0].ID != "untracked" {
+		t.Fatalf("untrackedActiveAttacks() = %v, want only the active attack without a tracked message", untracked)
+	}
+}
@@ -1,6 +1,7 @@
 package neoprotect
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -9,6 +10,7 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -16,15 +18,94 @@ var (
 	ErrNoActiveAttack = errors.New("no active attack found")
 	ErrRequestFailed  = errors.New("API request failed")
 	ErrIPNotFound     = errors.New("IP address not found")
+	ErrAttackNotFound = errors.New("attack not found")
 )
 
 type Client struct {
 	apiKey     string
-	baseURL    string
+	baseURLs   []string
+	headers    map[string]string
 	httpClient *http.Client
+
+	// endpointMu guards current, the index of the endpoint that last answered
+	endpointMu sync.Mutex
+	current    int
+
+	paginationTimeout time.Duration
+	// lenientPagination makes GetAllAttacksAllPages return the pages fetched before a failing one
+	lenientPagination bool
+	debugLogging      bool
+}
+
+// PartialResultsError is returned next to the attacks of the pages fetched before a page failed, when lenient
+// pagination is enabled
+type PartialResultsError struct {
+	// Pages is the number of pages fetched successfully
+	Pages int
+	Err   error
+}
+
+func (e *PartialResultsError) Error() string {
+	return fmt.Sprintf("fetched only %d page(s), the next page failed: %v", e.Pages, e.Err)
+}
+
+func (e *PartialResultsError) Unwrap() error {
+	return e.Err
+}
+
+// ClientOption configures optional Client behaviour
+type ClientOption func(*Client)
+
+// WithHeaders adds extra headers sent with every request, e.g. for proxies in front of the API
+func WithHeaders(headers map[string]string) ClientOption {
+	return func(c *Client) {
+		c.headers = headers
+	}
+}
+
+// WithFallbackEndpoints adds API endpoints tried in order when the primary endpoint is unreachable
+func WithFallbackEndpoints(baseURLs ...string) ClientOption {
+	return func(c *Client) {
+		for _, baseURL := range baseURLs {
+			if baseURL != "" {
+				c.baseURLs = append(c.baseURLs, baseURL)
+			}
+		}
+	}
+}
+
+// WithRequestTimeout sets the timeout of a single API request
+func WithRequestTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		if timeout > 0 {
+			c.httpClient.Timeout = timeout
+		}
+	}
+}
+
+// WithPaginationTimeout bounds the total time spent walking all pages of a listing
+func WithPaginationTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.paginationTimeout = timeout
+	}
+}
+
+// WithLenientPagination makes GetAllAttacksAllPages return the attacks of the pages fetched before a failing
+// page together with a *PartialResultsError, instead of failing the whole listing
+func WithLenientPagination(lenient bool) ClientOption {
+	return func(c *Client) {
+		c.lenientPagination = lenient
+	}
 }
 
-func NewClient(apiKey, baseURL string) (*Client, error) {
+// WithDebugLogging logs the progress of paginated listings, e.g. to find out why a poll cycle is slow
+func WithDebugLogging(enabled bool) ClientOption {
+	return func(c *Client) {
+		c.debugLogging = enabled
+	}
+}
+
+func NewClient(apiKey, baseURL string, opts ...ClientOption) (*Client, error) {
 	if apiKey == "" {
 		return nil, errors.New("API key is required")
 	}
@@ -33,34 +114,111 @@ func NewClient(apiKey, baseURL string) (*Client, error) {
 		baseURL = "https://api.neoprotect.net/v2"
 	}
 
-	return &Client{
-		apiKey:  apiKey,
-		baseURL: baseURL,
+	client := &Client{
+		apiKey:   apiKey,
+		baseURLs: []string{baseURL},
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-	}, nil
+	}
+
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	return client, nil
 }
 
-// GetAttacks fetches all attacks for a specific IP address with pagination
-func (c *Client) GetAttacks(ctx context.Context, ip string, page int) ([]*Attack, error) {
-	endpoint := fmt.Sprintf("%s/ips/%s/attacks", c.baseURL, ip)
+// MaxAttackPages is the last page of an attack listing that is walked, so a listing that never runs out of pages
+// cannot be walked forever
+const MaxAttackPages = 100
 
-	if page > 0 {
-		endpoint += fmt.Sprintf("?page=%d", page)
+// paginationContext applies the pagination deadline, if one is configured, to a page walk
+func (c *Client) paginationContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.paginationTimeout <= 0 {
+		return ctx, func() {}
 	}
+	return context.WithTimeout(ctx, c.paginationTimeout)
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+func (c *Client) setHeaders(req *http.Request) {
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
 	}
 
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
 	req.Header.Set("Accept", "application/json")
+}
+
+// get sends a GET request for path, failing over to the next endpoint when the current one is unreachable.
+// The endpoint that answered is remembered and returned as a full URL for error messages.
+func (c *Client) get(ctx context.Context, path string) (*http.Response, string, error) {
+	return c.do(ctx, http.MethodGet, path, nil)
+}
+
+// do sends a request with an optional JSON body with the same endpoint failover as get
+func (c *Client) do(ctx context.Context, method, path string, body []byte) (*http.Response, string, error) {
+	c.endpointMu.Lock()
+	start := c.current
+	c.endpointMu.Unlock()
+
+	var lastErr error
+	var endpoint string
+	for attempt := 0; attempt < len(c.baseURLs); attempt++ {
+		index := (start + attempt) % len(c.baseURLs)
+		endpoint = c.baseURLs[index] + path
+
+		var reqBody io.Reader
+		if body != nil {
+			reqBody = bytes.NewReader(body)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, endpoint, reqBody)
+		if err != nil {
+			return nil, endpoint, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		c.setHeaders(req)
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("failed to send request: %w", err)
+			if ctx.Err() != nil {
+				break
+			}
+			if len(c.baseURLs) > 1 {
+				log.Printf("Warning: NeoProtect API endpoint %s is unreachable: %v", c.baseURLs[index], err)
+			}
+			continue
+		}
 
-	resp, err := c.httpClient.Do(req)
+		if index != start {
+			c.endpointMu.Lock()
+			c.current = index
+			c.endpointMu.Unlock()
+			log.Printf("Switched to NeoProtect API endpoint %s", c.baseURLs[index])
+		}
+
+		return resp, endpoint, nil
+	}
+
+	return nil, endpoint, lastErr
+}
+
+// GetAttacks fetches all attacks for a specific IP address with pagination
+func (c *Client) GetAttacks(ctx context.Context, ip string, page int) ([]*Attack, error) {
+	path := fmt.Sprintf("/ips/%s/attacks", ip)
+
+	if page > 0 {
+		path += fmt.Sprintf("?page=%d", page)
+	}
+
+	resp, endpoint, err := c.get(ctx, path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, err
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
@@ -84,6 +242,9 @@ func (c *Client) GetAttacks(ctx context.Context, ip string, page int) ([]*Attack
 
 // GetAllAttacksForIP fetches all attacks for a specific IP across all pages
 func (c *Client) GetAllAttacksForIP(ctx context.Context, ip string) ([]*Attack, error) {
+	ctx, cancel := c.paginationContext(ctx)
+	defer cancel()
+
 	var allAttacks []*Attack
 	page := 0
 
@@ -100,30 +261,22 @@ func (c *Client) GetAllAttacksForIP(ctx context.Context, ip string) ([]*Attack,
 		allAttacks = append(allAttacks, attacks...)
 		page++
 
-		if page > 100 {
-			log.Printf("Warning: Reached maximum page limit (100) when fetching attacks for IP %s", ip)
+		if page > MaxAttackPages {
+			log.Printf("Warning: Reached maximum page limit (%d) when fetching attacks for IP %s", MaxAttackPages, ip)
 			break
 		}
 	}
 
-	return allAttacks, nil
+	return DedupeAttacks(allAttacks), nil
 }
 
 // GetActiveAttack fetches the currently active attack for a specific IP address
 func (c *Client) GetActiveAttack(ctx context.Context, ip string) (*Attack, error) {
-	endpoint := fmt.Sprintf("%s/ips/%s/attack", c.baseURL, ip)
+	path := fmt.Sprintf("/ips/%s/attack", ip)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	resp, endpoint, err := c.get(ctx, path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, err
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
@@ -151,19 +304,11 @@ func (c *Client) GetActiveAttack(ctx context.Context, ip string) (*Attack, error
 
 // GetAttackStats fetches detailed statistics for a specific attack
 func (c *Client) GetAttackStats(ctx context.Context, attackID string) (*AttackStats, error) {
-	endpoint := fmt.Sprintf("%s/ips/attacks/%s/stats", c.baseURL, attackID)
+	path := fmt.Sprintf("/ips/attacks/%s/stats", attackID)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	resp, endpoint, err := c.get(ctx, path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, err
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
@@ -187,19 +332,11 @@ func (c *Client) GetAttackStats(ctx context.Context, attackID string) (*AttackSt
 
 // GetAttackSample fetches a sample file URL for a specific attack
 func (c *Client) GetAttackSample(ctx context.Context, attackID string) (string, error) {
-	endpoint := fmt.Sprintf("%s/ips/attacks/%s/sample", c.baseURL, attackID)
+	path := fmt.Sprintf("/ips/attacks/%s/sample", attackID)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	resp, endpoint, err := c.get(ctx, path)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return "", err
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
@@ -223,7 +360,7 @@ func (c *Client) GetAttackSample(ctx context.Context, attackID string) (string,
 
 // GetAllAttacks fetches all attacks with pagination support
 func (c *Client) GetAllAttacks(ctx context.Context, activeOnly bool, page int) ([]*Attack, error) {
-	endpoint := fmt.Sprintf("%s/ips/attacks", c.baseURL)
+	path := "/ips/attacks"
 
 	var queryParams []string
 	if activeOnly {
@@ -234,20 +371,12 @@ func (c *Client) GetAllAttacks(ctx context.Context, activeOnly bool, page int) (
 	}
 
 	if len(queryParams) > 0 {
-		endpoint += "?" + strings.Join(queryParams, "&")
+		path += "?" + strings.Join(queryParams, "&")
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	resp, endpoint, err := c.get(ctx, path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, err
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
@@ -269,14 +398,21 @@ func (c *Client) GetAllAttacks(ctx context.Context, activeOnly bool, page int) (
 	return attacks, nil
 }
 
-// GetAllAttacksAllPages fetches all attacks across all pages
+// GetAllAttacksAllPages fetches all attacks across all pages. With lenient pagination, a page failing after the
+// first one returns the attacks fetched so far with a *PartialResultsError.
 func (c *Client) GetAllAttacksAllPages(ctx context.Context, activeOnly bool) ([]*Attack, error) {
+	ctx, cancel := c.paginationContext(ctx)
+	defer cancel()
+
 	var allAttacks []*Attack
 	page := 0
 
 	for {
 		attacks, err := c.GetAllAttacks(ctx, activeOnly, page)
 		if err != nil {
+			if c.lenientPagination && page > 0 {
+				return DedupeAttacks(allAttacks), &PartialResultsError{Pages: page, Err: err}
+			}
 			return nil, err
 		}
 
@@ -286,31 +422,172 @@ func (c *Client) GetAllAttacksAllPages(ctx context.Context, activeOnly bool) ([]
 
 		allAttacks = append(allAttacks, attacks...)
 		page++
+		if c.debugLogging {
+			log.Printf("Debug: fetched %d attack page(s), %d attack(s) so far", page, len(allAttacks))
+		}
 
-		if page > 100 {
-			log.Printf("Warning: Reached maximum page limit (100) when fetching all attacks")
+		if page > MaxAttackPages {
+			log.Printf("Warning: Reached maximum page limit (%d) when fetching all attacks", MaxAttackPages)
 			break
 		}
 	}
 
-	return allAttacks, nil
+	return DedupeAttacks(allAttacks), nil
 }
 
-// GetIPAddresses fetches all IP addresses assigned to the account
-func (c *Client) GetIPAddresses(ctx context.Context) ([]*IPAddressModel, error) {
-	endpoint := fmt.Sprintf("%s/ips", c.baseURL)
+// ListActiveAttacks returns the active attacks of all IPs of the account from the bulk attack listing, instead of
+// asking for the active attack of every IP separately
+func (c *Client) ListActiveAttacks(ctx context.Context) ([]*Attack, error) {
+	attacks, err := c.GetAllAttacksAllPages(ctx, true)
+	if err != nil && len(attacks) == 0 {
+		return nil, err
+	}
+
+	active := make([]*Attack, 0, len(attacks))
+	for _, attack := range attacks {
+		if attack != nil && attack.IsActive() {
+			active = append(active, attack)
+		}
+	}
+	return active, err
+}
+
+// FindAttack looks up an attack by ID by walking the attack listing of the account, newest pages first
+func (c *Client) FindAttack(ctx context.Context, attackID string) (*Attack, error) {
+	attack, _, err := c.GetAttackRaw(ctx, attackID)
+	return attack, err
+}
+
+// GetAttackRaw looks up an attack by ID by walking the attack listing of the account, newest pages first, and
+// returns it together with the JSON the API returned for it, e.g. to check what the API reported when a
+// notification looks wrong
+func (c *Client) GetAttackRaw(ctx context.Context, attackID string) (*Attack, json.RawMessage, error) {
+	ctx, cancel := c.paginationContext(ctx)
+	defer cancel()
+
+	for page := 0; page <= MaxAttackPages; page++ {
+		path := "/ips/attacks"
+		if page > 0 {
+			path += fmt.Sprintf("?page=%d", page)
+		}
+
+		attacks, err := c.getRawAttacks(ctx, path)
+		if err != nil {
+			return nil, nil, err
+		}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if len(attacks) == 0 {
+			break
+		}
+
+		for _, raw := range attacks {
+			if attack, ok := decodeRawAttack(raw); ok && attack.ID == attackID {
+				return attack, raw, nil
+			}
+		}
+	}
+
+	return nil, nil, ErrAttackNotFound
+}
+
+// GetLatestAttackRaw returns the most recently started attack on an IP together with the JSON the API returned
+// for it
+func (c *Client) GetLatestAttackRaw(ctx context.Context, ip string) (*Attack, json.RawMessage, error) {
+	attacks, err := c.getRawAttacks(ctx, fmt.Sprintf("/ips/%s/attacks", ip))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, nil, err
 	}
 
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
-	req.Header.Set("Accept", "application/json")
+	var latest *Attack
+	var latestRaw json.RawMessage
+	for _, raw := range attacks {
+		attack, ok := decodeRawAttack(raw)
+		if !ok {
+			continue
+		}
+		if latest == nil || (attack.StartedAt != nil && (latest.StartedAt == nil || attack.StartedAt.After(*latest.StartedAt))) {
+			latest, latestRaw = attack, raw
+		}
+	}
+
+	if latest == nil {
+		return nil, nil, ErrAttackNotFound
+	}
+	return latest, latestRaw, nil
+}
+
+// getRawAttacks fetches an attack listing without decoding the attacks
+func (c *Client) getRawAttacks(ctx context.Context, path string) ([]json.RawMessage, error) {
+	resp, endpoint, err := c.get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Error closing response body: %v", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%w: %s (status code %d): %s",
+			ErrRequestFailed, endpoint, resp.StatusCode, string(body))
+	}
+
+	var attacks []json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&attacks); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return attacks, nil
+}
+
+func decodeRawAttack(raw json.RawMessage) (*Attack, bool) {
+	var attack *Attack
+	if err := json.Unmarshal(raw, &attack); err != nil || attack == nil {
+		return nil, false
+	}
+	return attack, true
+}
+
+// DedupeAttacks drops attacks repeated across pages, which happens when new attacks shift the pages mid-pagination.
+// The most complete record of each attack is kept at the position it first appeared.
+func DedupeAttacks(attacks []*Attack) []*Attack {
+	positions := make(map[string]int, len(attacks))
+	deduped := make([]*Attack, 0, len(attacks))
+
+	for _, attack := range attacks {
+		if attack == nil || attack.ID == "" {
+			deduped = append(deduped, attack)
+			continue
+		}
+
+		pos, seen := positions[attack.ID]
+		if !seen {
+			positions[attack.ID] = len(deduped)
+			deduped = append(deduped, attack)
+			continue
+		}
+
+		if attack.completeness() > deduped[pos].completeness() {
+			deduped[pos] = attack
+		}
+	}
 
-	resp, err := c.httpClient.Do(req)
+	if removed := len(attacks) - len(deduped); removed > 0 {
+		log.Printf("Dropped %d duplicate attack record(s) returned across pages", removed)
+	}
+
+	return deduped
+}
+
+// GetIPAddresses fetches all IP addresses assigned to the account
+func (c *Client) GetIPAddresses(ctx context.Context) ([]*IPAddressModel, error) {
+	path := "/ips"
+
+	resp, endpoint, err := c.get(ctx, path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, err
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
@@ -331,3 +608,67 @@ func (c *Client) GetIPAddresses(ctx context.Context) ([]*IPAddressModel, error)
 
 	return addresses, nil
 }
+
+// GetIPAddress fetches a single IP address of the account including its settings
+func (c *Client) GetIPAddress(ctx context.Context, ip string) (*IPAddressModel, error) {
+	path := fmt.Sprintf("/ips/%s", ip)
+
+	resp, endpoint, err := c.get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Error closing response body: %v", err)
+		}
+	}()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrIPNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%w: %s (status code %d): %s",
+			ErrRequestFailed, endpoint, resp.StatusCode, string(body))
+	}
+
+	var address IPAddressModel
+	if err := json.NewDecoder(resp.Body).Decode(&address); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &address, nil
+}
+
+// UpdateIPSettings changes the settings of one of the account's IP addresses
+func (c *Client) UpdateIPSettings(ctx context.Context, ip string, settings IPSettings) error {
+	path := fmt.Sprintf("/ips/%s/settings", ip)
+
+	body, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("failed to encode IP settings: %w", err)
+	}
+
+	resp, endpoint, err := c.do(ctx, http.MethodPut, path, body)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Error closing response body: %v", err)
+		}
+	}()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrIPNotFound
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%w: %s (status code %d): %s",
+			ErrRequestFailed, endpoint, resp.StatusCode, string(body))
+	}
+
+	return nil
+}
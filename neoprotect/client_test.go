@@ -0,0 +1,129 @@
+package neoprotect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDedupeAttacksAcrossOverlappingPages(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	sparse := &Attack{ID: "b", StartedAt: &start}
+	complete := &Attack{ID: "b", StartedAt: &start, EndedAt: &end, Signatures: []AttackSignature{{ID: "sig-1", StartedAt: &start}}}
+
+	// A new attack shifted the second page, so "b" and "c" are returned again, "b" with more data
+	page0 := []*Attack{{ID: "a"}, sparse, {ID: "c"}}
+	page1 := []*Attack{complete, {ID: "c"}, {ID: "d"}}
+
+	deduped := DedupeAttacks(append(page0, page1...))
+
+	wantIDs := []string{"a", "b", "c", "d"}
+	if len(deduped) != len(wantIDs) {
+		t.Fatalf("DedupeAttacks() returned %d attacks, want %d", len(deduped), len(wantIDs))
+	}
+	for i, id := range wantIDs {
+		if deduped[i].ID != id {
+			t.Errorf("attack %d = %q, want %q", i, deduped[i].ID, id)
+		}
+	}
+	if deduped[1] != complete {
+		t.Error("attack b is not the most complete record")
+	}
+}
+
+func TestDedupeAttacksKeepsFirstOfEquallyCompleteRecords(t *testing.T) {
+	first := &Attack{ID: "a"}
+	second := &Attack{ID: "a"}
+
+	deduped := DedupeAttacks([]*Attack{first, second})
+
+	if len(deduped) != 1 || deduped[0] != first {
+		t.Fatalf("DedupeAttacks() = %v, want only the first record", deduped)
+	}
+}
+
+func TestFindAttackWalksPages(t *testing.T) {
+	pages := map[string]string{
+		"":  `[{"id": "attack-1"}]`,
+		"1": `[{"id": "attack-2"}]`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if page, ok := pages[r.URL.Query().Get("page")]; ok {
+			fmt.Fprint(w, page)
+			return
+		}
+		fmt.Fprint(w, `[]`)
+	}))
+	defer server.Close()
+
+	client, err := NewClient("key", server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	attack, err := client.FindAttack(context.Background(), "attack-2")
+	if err != nil || attack == nil || attack.ID != "attack-2" {
+		t.Fatalf("FindAttack() = %v, %v, want attack-2 from the second page", attack, err)
+	}
+
+	if _, err := client.FindAttack(context.Background(), "attack-3"); !errors.Is(err, ErrAttackNotFound) {
+		t.Fatalf("FindAttack() error = %v, want ErrAttackNotFound", err)
+	}
+}
+
+// statsBody is shaped like the stats the API returns, with the distributions as JSON objects
+const statsBody = `{
+	"id": "attack-1",
+	"packetsTotal": 1000,
+	"sourceAsns": {"AS13335": 600, "AS15169": 300, "AS16509": 100},
+	"sourcePorts": {"53": 900, "40000": 100},
+	"destinationPorts": {"443": 700, "80": 300},
+	"protocols": {"UDP": 750, "TCP": 250},
+	"sourceCountries": {"US": 1000},
+	"payloads": null
+}`
+
+func TestGetAttackStatsDecodesDistributions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, statsBody)
+	}))
+	defer server.Close()
+
+	client, err := NewClient("key", server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	stats, err := client.GetAttackStats(context.Background(), "attack-1")
+	if err != nil {
+		t.Fatalf("GetAttackStats() error = %v", err)
+	}
+
+	asns, err := stats.SourceAsnCounts()
+	if err != nil || asns["AS13335"] != 600 || len(asns) != 3 {
+		t.Fatalf("SourceAsnCounts() = %v, %v, want the three ASNs", asns, err)
+	}
+	if dominant, err := stats.DominantSourceASNs(1); err != nil || len(dominant) != 1 || dominant[0] != "AS13335" {
+		t.Fatalf("DominantSourceASNs(1) = %v, %v, want [AS13335]", dominant, err)
+	}
+
+	mix, err := stats.ProtocolMix(4)
+	if err != nil || len(mix) != 2 || mix[0].Protocol != "UDP" || mix[0].Percent != 75 {
+		t.Fatalf("ProtocolMix(4) = %v, %v, want UDP at 75%% first", mix, err)
+	}
+
+	if ports, err := stats.TopDestinationPorts(1); err != nil || len(ports) != 1 || ports[0] != "443" {
+		t.Fatalf("TopDestinationPorts(1) = %v, %v, want [443]", ports, err)
+	}
+
+	shares, err := stats.SourcePortShares()
+	if err != nil || len(shares) != 2 || shares[0].Port != "53" || shares[0].Percent != 90 {
+		t.Fatalf("SourcePortShares() = %v, %v, want port 53 at 90%% first", shares, err)
+	}
+}
@@ -0,0 +1,39 @@
+package neoprotect
+
+import "sort"
+
+// CorrelateBySourceASN finds attacks on different IPs that share at least one dominant source ASN.
+// dominantASNs is keyed by attack ID; the result maps each attack ID to the target IPs of correlated attacks.
+func CorrelateBySourceASN(attacks []*Attack, dominantASNs map[string][]string) map[string][]string {
+	attacksByASN := make(map[string][]*Attack)
+	for _, attack := range attacks {
+		for _, asn := range dominantASNs[attack.ID] {
+			attacksByASN[asn] = append(attacksByASN[asn], attack)
+		}
+	}
+
+	correlated := make(map[string]map[string]struct{})
+	for _, group := range attacksByASN {
+		for _, attack := range group {
+			for _, other := range group {
+				if other.ID == attack.ID || other.DstAddressString == attack.DstAddressString {
+					continue
+				}
+				if correlated[attack.ID] == nil {
+					correlated[attack.ID] = make(map[string]struct{})
+				}
+				correlated[attack.ID][other.DstAddressString] = struct{}{}
+			}
+		}
+	}
+
+	result := make(map[string][]string, len(correlated))
+	for attackID, ips := range correlated {
+		for ip := range ips {
+			result[attackID] = append(result[attackID], ip)
+		}
+		sort.Strings(result[attackID])
+	}
+
+	return result
+}
@@ -1,9 +1,14 @@
 package neoprotect
 
 import (
+	"encoding/json"
+	"fmt"
+	"sort"
 	"time"
 )
 
+var signatureDisplayNames map[string]string
+
 type IPAddressModel struct {
 	IPv4     string      `json:"ipv4"`
 	Settings *IPSettings `json:"settings,omitempty"`
@@ -30,6 +35,36 @@ type Attack struct {
 	StartedAt        *time.Time        `json:"startedAt"`
 	EndedAt          *time.Time        `json:"endedAt"`
 	SampleRate       int64             `json:"sampleRate"`
+
+	// NewRecordPeak is set by the monitor when the attack beats the all-time peak of its target IP
+	NewRecordPeak bool `json:"-"`
+	// FirstObservedAt is set by the monitor to the time it first saw the attack
+	FirstObservedAt *time.Time `json:"-"`
+	// Subsiding is set by the monitor when the attack's peaks dropped significantly since the last poll
+	Subsiding bool `json:"-"`
+	// CorrelatedIPs is set by the monitor to the targets of other active attacks sharing dominant source ASNs
+	CorrelatedIPs []string `json:"-"`
+	// ProtocolMix is set by the monitor from the attack stats when the attack ends
+	ProtocolMix []ProtocolShare `json:"-"`
+	// TargetPorts is set by the monitor from the attack stats when the attack ends, most targeted first
+	TargetPorts []string `json:"-"`
+	// Account is set by the monitor to the name of the account the attack belongs to, when several are monitored
+	Account string `json:"-"`
+	// ObservedPeakBPS and ObservedPeakPPS are set by the monitor to the highest peaks seen in earlier polls
+	ObservedPeakBPS int64 `json:"-"`
+	ObservedPeakPPS int64 `json:"-"`
+}
+
+// ProtocolShare is the percentage of an attack's packets carried by a single protocol
+type ProtocolShare struct {
+	Protocol string  `json:"protocol"`
+	Percent  float64 `json:"percent"`
+}
+
+// PortShare is the percentage of an attack's packets sent from or to a single port
+type PortShare struct {
+	Port    string  `json:"port"`
+	Percent float64 `json:"percent"`
 }
 
 type AttackStats struct {
@@ -46,15 +81,197 @@ type AttackStats struct {
 	CreatedAt             *time.Time `json:"createdAt"`
 	UpdatedAt             *time.Time `json:"updatedAt"`
 
-	SourceIps        []byte `json:"sourceIps"`
-	SourcePorts      []byte `json:"sourcePorts"`
-	DestinationPorts []byte `json:"destinationPorts"`
-	SourceCountries  []byte `json:"sourceCountries"`
-	SourceAsns       []byte `json:"sourceAsns"`
-	Protocols        []byte `json:"protocols"`
-	PacketLengths    []byte `json:"packetLengths"`
-	TTLs             []byte `json:"ttls"`
-	Payloads         []byte `json:"payloads"`
+	// The distributions are JSON objects of value to packet count, kept raw and decoded by the helpers below
+	SourceIps        json.RawMessage `json:"sourceIps"`
+	SourcePorts      json.RawMessage `json:"sourcePorts"`
+	DestinationPorts json.RawMessage `json:"destinationPorts"`
+	SourceCountries  json.RawMessage `json:"sourceCountries"`
+	SourceAsns       json.RawMessage `json:"sourceAsns"`
+	Protocols        json.RawMessage `json:"protocols"`
+	PacketLengths    json.RawMessage `json:"packetLengths"`
+	TTLs             json.RawMessage `json:"ttls"`
+	Payloads         json.RawMessage `json:"payloads"`
+}
+
+// SourceAsnCounts decodes the SourceAsns distribution into a map of ASN to packet count
+func (s *AttackStats) SourceAsnCounts() (map[string]int64, error) {
+	counts := make(map[string]int64)
+	if len(s.SourceAsns) == 0 {
+		return counts, nil
+	}
+
+	if err := json.Unmarshal(s.SourceAsns, &counts); err != nil {
+		return nil, fmt.Errorf("failed to decode source ASNs: %w", err)
+	}
+
+	return counts, nil
+}
+
+// SourceCountryCounts decodes the SourceCountries distribution into a map of country code to packet count
+func (s *AttackStats) SourceCountryCounts() (map[string]int64, error) {
+	counts := make(map[string]int64)
+	if len(s.SourceCountries) == 0 {
+		return counts, nil
+	}
+
+	if err := json.Unmarshal(s.SourceCountries, &counts); err != nil {
+		return nil, fmt.Errorf("failed to decode source countries: %w", err)
+	}
+
+	return counts, nil
+}
+
+// DestinationPortCounts decodes the DestinationPorts distribution into a map of port to packet count
+func (s *AttackStats) DestinationPortCounts() (map[string]int64, error) {
+	counts := make(map[string]int64)
+	if len(s.DestinationPorts) == 0 {
+		return counts, nil
+	}
+
+	if err := json.Unmarshal(s.DestinationPorts, &counts); err != nil {
+		return nil, fmt.Errorf("failed to decode destination ports: %w", err)
+	}
+
+	return counts, nil
+}
+
+// SourcePortCounts decodes the SourcePorts distribution into a map of port to packet count
+func (s *AttackStats) SourcePortCounts() (map[string]int64, error) {
+	counts := make(map[string]int64)
+	if len(s.SourcePorts) == 0 {
+		return counts, nil
+	}
+
+	if err := json.Unmarshal(s.SourcePorts, &counts); err != nil {
+		return nil, fmt.Errorf("failed to decode source ports: %w", err)
+	}
+
+	return counts, nil
+}
+
+// SourcePortShares returns every source port with its share of packets, largest first
+func (s *AttackStats) SourcePortShares() ([]PortShare, error) {
+	counts, err := s.SourcePortCounts()
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for _, count := range counts {
+		total += count
+	}
+	if total <= 0 {
+		return nil, nil
+	}
+
+	shares := make([]PortShare, 0, len(counts))
+	for _, port := range topKeys(counts, len(counts)) {
+		shares = append(shares, PortShare{
+			Port:    port,
+			Percent: float64(counts[port]) / float64(total) * 100,
+		})
+	}
+
+	return shares, nil
+}
+
+// ProtocolCounts decodes the Protocols distribution into a map of protocol to packet count
+func (s *AttackStats) ProtocolCounts() (map[string]int64, error) {
+	counts := make(map[string]int64)
+	if len(s.Protocols) == 0 {
+		return counts, nil
+	}
+
+	if err := json.Unmarshal(s.Protocols, &counts); err != nil {
+		return nil, fmt.Errorf("failed to decode protocols: %w", err)
+	}
+
+	return counts, nil
+}
+
+// ProtocolMix returns up to limit protocols with their share of packets, largest first
+func (s *AttackStats) ProtocolMix(limit int) ([]ProtocolShare, error) {
+	counts, err := s.ProtocolCounts()
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for _, count := range counts {
+		total += count
+	}
+	if total <= 0 {
+		return nil, nil
+	}
+
+	mix := make([]ProtocolShare, 0, len(counts))
+	for protocol, count := range counts {
+		mix = append(mix, ProtocolShare{
+			Protocol: protocol,
+			Percent:  float64(count) / float64(total) * 100,
+		})
+	}
+
+	sort.Slice(mix, func(i, j int) bool {
+		if mix[i].Percent == mix[j].Percent {
+			return mix[i].Protocol < mix[j].Protocol
+		}
+		return mix[i].Percent > mix[j].Percent
+	})
+
+	if len(mix) > limit {
+		mix = mix[:limit]
+	}
+
+	return mix, nil
+}
+
+// DominantSourceASNs returns up to limit ASNs with the highest packet counts
+func (s *AttackStats) DominantSourceASNs(limit int) ([]string, error) {
+	counts, err := s.SourceAsnCounts()
+	if err != nil {
+		return nil, err
+	}
+	return topKeys(counts, limit), nil
+}
+
+// TopSourceCountries returns up to limit country codes with the highest packet counts
+func (s *AttackStats) TopSourceCountries(limit int) ([]string, error) {
+	counts, err := s.SourceCountryCounts()
+	if err != nil {
+		return nil, err
+	}
+	return topKeys(counts, limit), nil
+}
+
+// TopDestinationPorts returns up to limit destination ports with the highest packet counts
+func (s *AttackStats) TopDestinationPorts(limit int) ([]string, error) {
+	counts, err := s.DestinationPortCounts()
+	if err != nil {
+		return nil, err
+	}
+	return topKeys(counts, limit), nil
+}
+
+// topKeys returns up to limit keys with the highest counts, ties broken alphabetically
+func topKeys(counts map[string]int64, limit int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] == counts[keys[j]] {
+			return keys[i] < keys[j]
+		}
+		return counts[keys[i]] > counts[keys[j]]
+	})
+
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+
+	return keys
 }
 
 // Equal compares two Attack objects to determine if they are equal
@@ -71,6 +288,10 @@ func (a *Attack) Equal(other *Attack) bool {
 		return false
 	}
 
+	if a.SampleRate != other.SampleRate || dstAddressChanged(other, a) {
+		return false
+	}
+
 	if len(a.Signatures) != len(other.Signatures) {
 		return false
 	}
@@ -98,6 +319,37 @@ func (a *Attack) Equal(other *Attack) bool {
 	return true
 }
 
+// AutoMitigation returns the target IP's auto-mitigation setting and whether the attack carries it
+func (a *Attack) AutoMitigation() (enabled bool, known bool) {
+	if a.DstAddress == nil || a.DstAddress.Settings == nil {
+		return false, false
+	}
+	return a.DstAddress.Settings.AutoMitigation, true
+}
+
+// autoMitigationChanged reports whether auto-mitigation was toggled between the two states of an attack.
+// A state without settings, e.g. from an endpoint that omits them, is not treated as a change.
+func autoMitigationChanged(previous, current *Attack) bool {
+	previousEnabled, previousKnown := previous.AutoMitigation()
+	currentEnabled, currentKnown := current.AutoMitigation()
+	return previousKnown && currentKnown && previousEnabled != currentEnabled
+}
+
+// dstAddressChanged reports whether the target IP's address or any of its settings differ between the two states
+// of an attack. Like for auto-mitigation, a state without the address or settings is not treated as a change.
+func dstAddressChanged(previous, current *Attack) bool {
+	if previous.DstAddress == nil || current.DstAddress == nil {
+		return false
+	}
+	if previous.DstAddress.IPv4 != current.DstAddress.IPv4 {
+		return true
+	}
+	if previous.DstAddress.Settings == nil || current.DstAddress.Settings == nil {
+		return false
+	}
+	return *previous.DstAddress.Settings != *current.DstAddress.Settings
+}
+
 func timeEqual(t1, t2 *time.Time) bool {
 	if t1 == nil && t2 == nil {
 		return true
@@ -108,11 +360,97 @@ func timeEqual(t1, t2 *time.Time) bool {
 	return t1.Equal(*t2)
 }
 
+// Validate checks the invariants the monitor relies on, so malformed API data is not turned into nonsense durations
+func (a *Attack) Validate() error {
+	if a.ID == "" {
+		return fmt.Errorf("attack has no ID")
+	}
+	if a.DstAddressString == "" {
+		return fmt.Errorf("attack has no destination address")
+	}
+	if a.StartedAt != nil && a.EndedAt != nil && a.EndedAt.Before(*a.StartedAt) {
+		return fmt.Errorf("attack ended at %s before it started at %s", a.EndedAt.Format(time.RFC3339), a.StartedAt.Format(time.RFC3339))
+	}
+
+	for _, sig := range a.Signatures {
+		if err := sig.validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DropInvalidSignatures removes the signatures Validate would reject, so one malformed signature does not
+// discard an attack that is still running. It returns the reason each dropped signature was rejected.
+func (a *Attack) DropInvalidSignatures() []error {
+	var dropped []error
+	kept := make([]AttackSignature, 0, len(a.Signatures))
+	for _, sig := range a.Signatures {
+		if err := sig.validate(); err != nil {
+			dropped = append(dropped, err)
+			continue
+		}
+		kept = append(kept, sig)
+	}
+	a.Signatures = kept
+	return dropped
+}
+
+func (s AttackSignature) validate() error {
+	if s.StartedAt == nil {
+		return fmt.Errorf("signature %q has no start time", s.Name)
+	}
+	if s.EndedAt != nil && s.EndedAt.Before(*s.StartedAt) {
+		return fmt.Errorf("signature %q ended before it started", s.Name)
+	}
+	if s.BPSPeak < 0 || s.PPSPeak < 0 {
+		return fmt.Errorf("signature %q has negative peak values", s.Name)
+	}
+	return nil
+}
+
+// completeness scores how much of the attack's data is filled in, to pick the better of two records of the same attack
+func (a *Attack) completeness() int {
+	score := len(a.Signatures)
+	if a.StartedAt != nil {
+		score++
+	}
+	if a.EndedAt != nil {
+		score++
+	}
+	for _, sig := range a.Signatures {
+		if sig.EndedAt != nil {
+			score++
+		}
+	}
+	return score
+}
+
 // IsActive returns true if the attack is currently active (no EndedAt timestamp)
 func (a *Attack) IsActive() bool {
 	return a.EndedAt == nil
 }
 
+// SignaturesEnded returns the end of the last signature if every signature of the attack has ended, nil otherwise
+func (a *Attack) SignaturesEnded() *time.Time {
+	if len(a.Signatures) == 0 {
+		return nil
+	}
+
+	var last *time.Time
+	for _, sig := range a.Signatures {
+		if sig.EndedAt == nil {
+			return nil
+		}
+		if last == nil || sig.EndedAt.After(*last) {
+			last = sig.EndedAt
+		}
+	}
+
+	return last
+}
+
 // Duration returns the duration of the attack
 func (a *Attack) Duration() time.Duration {
 	if a.StartedAt == nil {
@@ -145,11 +483,58 @@ func (a *Attack) GetPeakPPS() int64 {
 	return sum
 }
 
-// GetSignatureNames returns all unique signature names
+// RecordedPeakBPS returns the highest peak bandwidth seen across all polls of the attack, so a poll missed
+// during the attack's crescendo does not understate it
+func (a *Attack) RecordedPeakBPS() int64 {
+	if peak := a.GetPeakBPS(); peak > a.ObservedPeakBPS {
+		return peak
+	}
+	return a.ObservedPeakBPS
+}
+
+// RecordedPeakPPS returns the highest peak packet rate seen across all polls of the attack
+func (a *Attack) RecordedPeakPPS() int64 {
+	if peak := a.GetPeakPPS(); peak > a.ObservedPeakPPS {
+		return peak
+	}
+	return a.ObservedPeakPPS
+}
+
+// EstimatedPeakBPS returns the recorded peak bandwidth scaled by the sample rate, an estimate of the real traffic
+// when the packets behind the figures were sampled
+func (a *Attack) EstimatedPeakBPS() int64 {
+	if a.SampleRate > 1 {
+		return a.RecordedPeakBPS() * a.SampleRate
+	}
+	return a.RecordedPeakBPS()
+}
+
+// EstimatedPeakPPS returns the recorded peak packet rate scaled by the sample rate
+func (a *Attack) EstimatedPeakPPS() int64 {
+	if a.SampleRate > 1 {
+		return a.RecordedPeakPPS() * a.SampleRate
+	}
+	return a.RecordedPeakPPS()
+}
+
+// SetSignatureDisplayNames configures display names for raw signature names
+func SetSignatureDisplayNames(names map[string]string) {
+	signatureDisplayNames = names
+}
+
+// DisplaySignatureName returns the configured display name, unmapped names pass through unchanged
+func DisplaySignatureName(name string) string {
+	if displayName, ok := signatureDisplayNames[name]; ok && displayName != "" {
+		return displayName
+	}
+	return name
+}
+
+// GetSignatureNames returns all unique signature display names
 func (a *Attack) GetSignatureNames() []string {
 	nameMap := make(map[string]struct{})
 	for _, sig := range a.Signatures {
-		nameMap[sig.Name] = struct{}{}
+		nameMap[DisplaySignatureName(sig.Name)] = struct{}{}
 	}
 
 	names := make([]string, 0, len(nameMap))
@@ -160,48 +545,180 @@ func (a *Attack) GetSignatureNames() []string {
 	return names
 }
 
+// GetSignatureNamesByPeak returns the unique signature display names, largest bandwidth contribution first
+func (a *Attack) GetSignatureNamesByPeak() []string {
+	bps := make(map[string]int64)
+	pps := make(map[string]int64)
+	for _, sig := range a.Signatures {
+		name := DisplaySignatureName(sig.Name)
+		bps[name] += sig.BPSPeak
+		pps[name] += sig.PPSPeak
+	}
+
+	names := make([]string, 0, len(bps))
+	for name := range bps {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		if bps[names[i]] != bps[names[j]] {
+			return bps[names[i]] > bps[names[j]]
+		}
+		if pps[names[i]] != pps[names[j]] {
+			return pps[names[i]] > pps[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	return names
+}
+
+// AttackDiff describes the changes between two snapshots of the same attack.
+// All fields are always populated so that consumers get a stable structure.
+type AttackDiff struct {
+	BPSChange       int64    `json:"bpsChange"`
+	BPSCurrent      int64    `json:"bpsCurrent"`
+	PPSChange       int64    `json:"ppsChange"`
+	PPSCurrent      int64    `json:"ppsCurrent"`
+	NewSignatures   []string `json:"newSignatures"`
+	EndedSignatures []string `json:"endedSignatures"`
+	Ended           bool     `json:"ended"`
+	DurationSeconds int64    `json:"durationSeconds"`
+	// AutoMitigation is the new auto-mitigation setting of the target IP, only set when it was toggled
+	AutoMitigation    *bool `json:"autoMitigation,omitempty"`
+	SampleRateChange  int64 `json:"sampleRateChange"`
+	SampleRateCurrent int64 `json:"sampleRateCurrent"`
+	// VectorShift is set when the dominant signature changed, e.g. the attacker switched to another vector
+	VectorShift *VectorShift `json:"vectorShift,omitempty"`
+}
+
+// VectorShift names the previous and the current dominant signature of an attack
+type VectorShift struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// HasChanges returns true if the diff contains at least one change
+func (d *AttackDiff) HasChanges() bool {
+	if d == nil {
+		return false
+	}
+	return d.BPSChange != 0 || d.PPSChange != 0 || d.Ended ||
+		len(d.NewSignatures) > 0 || len(d.EndedSignatures) > 0 ||
+		d.AutoMitigation != nil || d.SampleRateChange != 0 || d.VectorShift != nil
+}
+
+// DominantSignature returns the signature contributing the most bandwidth, with ties broken by packet rate.
+// Ended signatures are only considered when none is active anymore. It returns nil for attacks without signatures.
+func (a *Attack) DominantSignature() *AttackSignature {
+	var dominant *AttackSignature
+	for i := range a.Signatures {
+		sig := &a.Signatures[i]
+		switch {
+		case dominant == nil:
+			dominant = sig
+		case (dominant.EndedAt == nil) != (sig.EndedAt == nil):
+			if sig.EndedAt == nil {
+				dominant = sig
+			}
+		case sig.BPSPeak > dominant.BPSPeak || (sig.BPSPeak == dominant.BPSPeak && sig.PPSPeak > dominant.PPSPeak):
+			dominant = sig
+		}
+	}
+	return dominant
+}
+
 // CalculateDiff Calculates differences between this attack and a previous state
-func (a *Attack) CalculateDiff(previous *Attack) map[string]interface{} {
+func (a *Attack) CalculateDiff(previous *Attack) *AttackDiff {
 	if previous == nil {
 		return nil
 	}
 
-	diff := make(map[string]interface{})
-
-	if previous.EndedAt == nil && a.EndedAt != nil {
-		diff["ended"] = true
-		diff["duration"] = a.Duration().String()
+	diff := &AttackDiff{
+		BPSCurrent:      a.GetPeakBPS(),
+		PPSCurrent:      a.GetPeakPPS(),
+		NewSignatures:   []string{},
+		EndedSignatures: []string{},
+		DurationSeconds: int64(a.Duration().Seconds()),
 	}
 
-	currentPeakBPS := a.GetPeakBPS()
-	previousPeakBPS := previous.GetPeakBPS()
-	if currentPeakBPS != previousPeakBPS {
-		diff["bpsPeakChange"] = currentPeakBPS - previousPeakBPS
-		diff["bpsPeakCurrent"] = currentPeakBPS
-	}
+	diff.BPSChange = diff.BPSCurrent - previous.GetPeakBPS()
+	diff.PPSChange = diff.PPSCurrent - previous.GetPeakPPS()
+	diff.Ended = previous.EndedAt == nil && a.EndedAt != nil
+
+	diff.SampleRateCurrent = a.SampleRate
+	diff.SampleRateChange = a.SampleRate - previous.SampleRate
 
-	currentPeakPPS := a.GetPeakPPS()
-	previousPeakPPS := previous.GetPeakPPS()
-	if currentPeakPPS != previousPeakPPS {
-		diff["ppsPeakChange"] = currentPeakPPS - previousPeakPPS
-		diff["ppsPeakCurrent"] = currentPeakPPS
+	if autoMitigationChanged(previous, a) {
+		enabled, _ := a.AutoMitigation()
+		diff.AutoMitigation = &enabled
 	}
 
-	previousSigs := make(map[string]struct{})
+	previousSigs := make(map[string]AttackSignature)
 	for _, sig := range previous.Signatures {
-		previousSigs[sig.ID] = struct{}{}
+		previousSigs[sig.ID] = sig
 	}
 
-	var newSignatures []string
+	currentSigs := make(map[string]struct{})
 	for _, sig := range a.Signatures {
-		if _, exists := previousSigs[sig.ID]; !exists {
-			newSignatures = append(newSignatures, sig.Name)
+		currentSigs[sig.ID] = struct{}{}
+
+		prevSig, exists := previousSigs[sig.ID]
+		if !exists {
+			diff.NewSignatures = append(diff.NewSignatures, DisplaySignatureName(sig.Name))
+		} else if prevSig.EndedAt == nil && sig.EndedAt != nil {
+			diff.EndedSignatures = append(diff.EndedSignatures, DisplaySignatureName(sig.Name))
+		}
+	}
+
+	for _, sig := range previous.Signatures {
+		if _, exists := currentSigs[sig.ID]; !exists && sig.EndedAt == nil {
+			diff.EndedSignatures = append(diff.EndedSignatures, DisplaySignatureName(sig.Name))
 		}
 	}
 
-	if len(newSignatures) > 0 {
-		diff["newSignatures"] = newSignatures
+	previousDominant, currentDominant := previous.DominantSignature(), a.DominantSignature()
+	if previousDominant != nil && currentDominant != nil && previousDominant.Name != currentDominant.Name {
+		diff.VectorShift = &VectorShift{
+			From: DisplaySignatureName(previousDominant.Name),
+			To:   DisplaySignatureName(currentDominant.Name),
+		}
 	}
 
 	return diff
 }
+
+// IsSubsiding reports whether bandwidth or packet rate dropped by at least thresholdPercent while neither grew
+func (d *AttackDiff) IsSubsiding(thresholdPercent int) bool {
+	if d == nil || thresholdPercent <= 0 || d.BPSChange > 0 || d.PPSChange > 0 {
+		return false
+	}
+
+	return decreasePercent(d.BPSCurrent-d.BPSChange, d.BPSCurrent) >= float64(thresholdPercent) ||
+		decreasePercent(d.PPSCurrent-d.PPSChange, d.PPSCurrent) >= float64(thresholdPercent)
+}
+
+func decreasePercent(previous, current int64) float64 {
+	if previous <= 0 || current >= previous {
+		return 0
+	}
+	return float64(previous-current) / float64(previous) * 100
+}
+
+// ToMap converts the diff into a generic map using the JSON field names
+func (d *AttackDiff) ToMap() map[string]interface{} {
+	if d == nil {
+		return nil
+	}
+
+	return map[string]interface{}{
+		"bpsChange":       d.BPSChange,
+		"bpsCurrent":      d.BPSCurrent,
+		"ppsChange":       d.PPSChange,
+		"ppsCurrent":      d.PPSCurrent,
+		"newSignatures":   d.NewSignatures,
+		"endedSignatures": d.EndedSignatures,
+		"ended":           d.Ended,
+		"durationSeconds": d.DurationSeconds,
+	}
+}
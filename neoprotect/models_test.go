@@ -0,0 +1,121 @@
+package neoprotect
+
+import (
+	"testing"
+	"time"
+)
+
+func validAttack() *Attack {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	return &Attack{
+		ID:               "attack-1",
+		DstAddressString: "192.0.2.1",
+		StartedAt:        &start,
+		Signatures: []AttackSignature{
+			{Name: "UDP Flood", StartedAt: &start, BPSPeak: 1000, PPSPeak: 10},
+		},
+	}
+}
+
+func TestValidateAcceptsValidAttack(t *testing.T) {
+	if err := validAttack().Validate(); err != nil {
+		t.Fatalf("Validate() = %v, want nil", err)
+	}
+}
+
+func TestValidateRejectsInvertedTimestamps(t *testing.T) {
+	attack := validAttack()
+	end := attack.StartedAt.Add(-time.Minute)
+	attack.EndedAt = &end
+
+	if err := attack.Validate(); err == nil {
+		t.Fatal("Validate() = nil, want error for attack ending before it started")
+	}
+}
+
+func TestDropInvalidSignatures(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	before := start.Add(-time.Minute)
+
+	tests := []struct {
+		name string
+		sig  AttackSignature
+	}{
+		{"nil start", AttackSignature{Name: "No Start", BPSPeak: 10}},
+		{"inverted timestamps", AttackSignature{Name: "Inverted", StartedAt: &start, EndedAt: &before}},
+		{"negative bps peak", AttackSignature{Name: "Negative BPS", StartedAt: &start, BPSPeak: -1}},
+		{"negative pps peak", AttackSignature{Name: "Negative PPS", StartedAt: &start, PPSPeak: -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attack := validAttack()
+			attack.Signatures = append(attack.Signatures, tt.sig)
+
+			if err := attack.Validate(); err == nil {
+				t.Fatal("Validate() = nil before dropping, want error")
+			}
+
+			dropped := attack.DropInvalidSignatures()
+			if len(dropped) != 1 {
+				t.Fatalf("DropInvalidSignatures() dropped %d signatures, want 1", len(dropped))
+			}
+			if len(attack.Signatures) != 1 || attack.Signatures[0].Name != "UDP Flood" {
+				t.Fatalf("remaining signatures = %+v, want only the valid one", attack.Signatures)
+			}
+			if err := attack.Validate(); err != nil {
+				t.Fatalf("Validate() after dropping = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func withAutoMitigation(attack *Attack, enabled bool) *Attack {
+	attack.DstAddress = &IPAddressModel{IPv4: attack.DstAddressString, Settings: &IPSettings{AutoMitigation: enabled}}
+	return attack
+}
+
+func TestSettingsOnlyChangeIsAnUpdate(t *testing.T) {
+	previous := withAutoMitigation(validAttack(), false)
+	current := withAutoMitigation(validAttack(), true)
+
+	if current.Equal(previous) {
+		t.Fatal("Equal() = true, want false when only auto-mitigation changed")
+	}
+
+	diff := current.CalculateDiff(previous)
+	if !diff.HasChanges() {
+		t.Fatal("HasChanges() = false, want true when only auto-mitigation changed")
+	}
+	if diff.AutoMitigation == nil || !*diff.AutoMitigation {
+		t.Fatalf("diff.AutoMitigation = %v, want true", diff.AutoMitigation)
+	}
+	if diff.BPSChange != 0 || diff.PPSChange != 0 || len(diff.NewSignatures) != 0 || len(diff.EndedSignatures) != 0 {
+		t.Fatalf("diff reports unrelated changes: %+v", diff)
+	}
+}
+
+func TestSampleRateOnlyChangeIsAnUpdate(t *testing.T) {
+	previous := validAttack()
+	current := validAttack()
+	previous.SampleRate = 1000
+	current.SampleRate = 2000
+
+	if current.Equal(previous) {
+		t.Fatal("Equal() = true, want false when only the sample rate changed")
+	}
+
+	diff := current.CalculateDiff(previous)
+	if !diff.HasChanges() || diff.SampleRateChange != 1000 {
+		t.Fatalf("diff = %+v, want a sample rate change of 1000", diff)
+	}
+}
+
+func TestMissingSettingsAreNotAChange(t *testing.T) {
+	previous := withAutoMitigation(validAttack(), true)
+	current := validAttack()
+
+	if !current.Equal(previous) {
+		t.Fatal("Equal() = false, want true when the current state has no settings")
+	}
+}